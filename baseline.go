@@ -0,0 +1,62 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"fmt"
+	"log"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/k8s"
+)
+
+// loadBaseline loads the --baseline-deployment manifest and returns it with the namespace it is deployed into: its
+// own, or --namespace if the manifest names none.
+func loadBaseline(config Config) (*appsv1.Deployment, string, error) {
+	deployment, err := k8s.LoadDeploymentManifest(config.baselineDeployment)
+	if err != nil {
+		return nil, "", err
+	}
+	namespace := deployment.Namespace
+	if namespace == "" {
+		namespace = config.namespace
+	}
+	return deployment, namespace, nil
+}
+
+// baselineReplicas returns the replica count of the baseline manifest, defaulting to 1 like Kubernetes does.
+func baselineReplicas(deployment *appsv1.Deployment) int {
+	if deployment.Spec.Replicas == nil {
+		return 1
+	}
+	return int(*deployment.Spec.Replicas)
+}
+
+// deployBaseline creates the --baseline-deployment and waits until all its pods are ready, so the measured workload
+// scales up a cluster that is already busy instead of an empty one. It returns a function deleting the baseline again.
+func deployBaseline(clientset *kubernetes.Clientset, config Config) (func(), error) {
+	deployment, namespace, err := loadBaseline(config)
+	if err != nil {
+		return nil, err
+	}
+	replicas := baselineReplicas(deployment)
+	progressf("Deploying baseline workload '%s' with %d replicas in the namespace '%s'...\n", deployment.Name, replicas, namespace)
+	if err := shutdown.Guard(func() error {
+		return k8s.ApplyDeploymentManifest(clientset, deployment, namespace, replicas)
+	}); err != nil {
+		return nil, err
+	}
+	deleteBaseline := func() {
+		if err := k8s.DeleteDeployment(clientset, deployment.Name, namespace); err != nil {
+			log.Printf("Failed to delete baseline deployment: %v", err)
+		}
+	}
+	if _, err := k8s.WaitForPodsReady(clientset, deployment.Name, namespace, replicas, config.podReadinessTimeout, verbosity(config)); err != nil {
+		deleteBaseline()
+		return nil, fmt.Errorf("Baseline deployment did not become ready: %w", err)
+	}
+	return deleteBaseline, nil
+}
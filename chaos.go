@@ -0,0 +1,78 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/aws"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/k8s"
+)
+
+// chaosRecoveryTimeout bounds how long the autoscaler may take to replace the terminated instance and the pods to recover.
+const chaosRecoveryTimeout = 10 * time.Minute
+
+// executeChaosTermination terminates one random instance launched by the benchmark and measures how fast the autoscaler
+// launches a replacement and how fast all replicas are ready again. The pods count as recovered once the ready replica
+// count, having dropped below replicas after the termination, is back to replicas.
+func executeChaosTermination(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, config Config, result *bench.BenchmarkResult, tagKey, tagValue string, replicas int) error {
+	instances, err := aws.GetEC2Instances(instanceClients(ec2Svc, config), "tag:"+tagKey, tagValue, config.startTime)
+	if err != nil {
+		return fmt.Errorf("Error retrieving EC2 instances: %w", err)
+	}
+	knownIDs := aws.InstanceIDs(instances)
+	if len(knownIDs) == 0 {
+		return fmt.Errorf("No launched instance left to terminate")
+	}
+
+	// Pick from a stable order so that the same --seed terminates the same instance.
+	sort.Strings(knownIDs)
+	victim := knownIDs[randomIntn(len(knownIDs))]
+	progressf("Chaos: terminating instance %s...\n", victim)
+	startTime := time.Now()
+	if err := aws.TerminateInstances(ec2Svc, []string{victim}); err != nil {
+		return err
+	}
+	result.ChaosTerminatedInstance = victim
+
+	podsDisrupted := false
+	for {
+		if time.Since(startTime) >= chaosRecoveryTimeout {
+			return fmt.Errorf("Timed out waiting for the replacement of instance %s and %d ready replicas: %w", victim, replicas, bench.ErrSchedulingFailed)
+		}
+
+		pollTime := time.Now()
+		if result.ChaosReplacementTime == 0 {
+			current, err := aws.GetEC2Instances(instanceClients(ec2Svc, config), "tag:"+tagKey, tagValue, config.startTime)
+			if err != nil {
+				return fmt.Errorf("Error retrieving EC2 instances: %w", err)
+			}
+			if len(aws.MissingInstanceIDs(current, instances)) > 0 {
+				result.ChaosReplacementTime = pollTime.Sub(startTime)
+				progressf("Chaos: replacement instance launched after %.2f seconds.\n", result.ChaosReplacementTime.Seconds())
+			}
+		}
+
+		readyReplicas, err := k8s.GetReadyReplicas(clientset, config.deploymentName, config.namespace)
+		if err != nil {
+			return err
+		}
+		if readyReplicas < replicas {
+			podsDisrupted = true
+		} else if podsDisrupted && result.ChaosReplacementTime > 0 {
+			result.ChaosRecoveryTime = pollTime.Sub(startTime)
+			progressf("Chaos: all %d replicas ready again after %.2f seconds.\n", replicas, result.ChaosRecoveryTime.Seconds())
+			return nil
+		}
+
+		time.Sleep(1 * time.Second)
+	}
+}
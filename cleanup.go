@@ -0,0 +1,74 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/k8s"
+)
+
+// runCleanupOnly tears down the resources a crashed run of each configuration left behind for --cleanup-only, without
+// benchmarking. Resources that are already gone are skipped, so it can be repeated safely. With --cleanup-wait it then
+// waits until the instances of the crashed run given with --cleanup-run-id have terminated. The errors of all steps are
+// joined together.
+func runCleanupOnly(clientset kubernetes.Interface, ec2Svc *ec2.EC2, configs []Config) error {
+	if len(configs) > 0 && configs[0].cleanupWait && (configs[0].cleanupRunID == "" || configs[0].cleanupWaitTimeout <= 0) {
+		return bench.NewPhaseError("configuration", fmt.Errorf("--cleanup-wait requires --cleanup-run-id and a positive --cleanup-wait-timeout: %w", bench.ErrInvalidConfig))
+	}
+
+	var errs []error
+	for _, config := range configs {
+		for _, action := range cleanupActions(clientset, config) {
+			if action.exists != nil {
+				exists, err := action.exists()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("Failed to %s: %w", action.description, err))
+					continue
+				}
+				if !exists {
+					progressf("Nothing to do to %s: it does not exist.\n", action.description)
+					continue
+				}
+			}
+			if err := action.run(); err != nil {
+				errs = append(errs, fmt.Errorf("Failed to %s: %w", action.description, err))
+			}
+		}
+	}
+	if len(configs) > 0 && configs[0].cleanupWait {
+		if err := waitForLeftoverInstances(ec2Svc, configs[0]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// waitForLeftoverInstances waits until no EC2 instance tagged with the --cleanup-run-id is running anymore, in the
+// regions of the configuration, for at most --cleanup-wait-timeout. Instances of other runs or of the node pool itself
+// are not waited for.
+func waitForLeftoverInstances(ec2Svc *ec2.EC2, config Config) error {
+	progressf("Waiting for the instances tagged %s=%s to terminate...\n", runIDTagKey, config.cleanupRunID)
+	var stats k8s.TerminationStats
+	termChan := make(chan time.Duration, 1)
+	errChan := make(chan error, 1)
+	go k8s.MonitorNodeTermination(instanceClients(ec2Svc, config), runIDTagKey, config.cleanupRunID, time.Time{}, nil, config.maxTransientErrors, verbosity(config), nil, &stats, termChan, errChan)
+	select {
+	case err := <-errChan:
+		return bench.NewPhaseError("instance termination", err)
+	case duration := <-termChan:
+		log.Printf("The instances tagged %s=%s terminated after %.2f seconds.", runIDTagKey, config.cleanupRunID, duration.Seconds())
+		return nil
+	case <-time.After(config.cleanupWaitTimeout):
+		return bench.NewPhaseError("instance termination", fmt.Errorf("Timed out after %v waiting for the instances tagged %s=%s to terminate", config.cleanupWaitTimeout, runIDTagKey, config.cleanupRunID))
+	}
+}
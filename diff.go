@@ -0,0 +1,43 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/utilities"
+)
+
+// runDiff implements the "diff [--no-color] a.json b.json" command: it loads two saved benchmark reports and prints the
+// phase-by-phase difference from the first to the second, without running a benchmark. Like the summaries, the
+// difference is printed without colors with --no-color or when stdout is not a terminal.
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	noColor := flags.Bool("no-color", false, "Print the difference without ANSI colors.")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%v: %w", err, bench.ErrInvalidConfig)
+	}
+	args = flags.Args()
+	if len(args) != 2 {
+		return fmt.Errorf("Usage: k8s-autoscaler-benchmarker diff [--no-color] <before.json> <after.json>: %w", bench.ErrInvalidConfig)
+	}
+	utilities.SetColor(colorOutput(*noColor))
+
+	before, err := bench.LoadBenchmarkReport(args[0])
+	if err != nil {
+		return err
+	}
+	after, err := bench.LoadBenchmarkReport(args[1])
+	if err != nil {
+		return err
+	}
+	if before.AutoscalerType != after.AutoscalerType {
+		fmt.Printf("Note: comparing a %s run with a %s run.\n", before.AutoscalerType, after.AutoscalerType)
+	}
+
+	utilities.PrintResultDiff(before.Result, after.Result, args[0], args[1])
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package aws
+
+import (
+	"fmt"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// NewAutoScaling returns an Auto Scaling client using the same credentials and region as the EC2 client. Callers pass
+// the client of the first --region: a node group's Auto Scaling groups live in the region of its cluster.
+func NewAutoScaling(ec2Svc *ec2.EC2) (*autoscaling.AutoScaling, error) {
+	sess, err := session.NewSession(&ec2Svc.Config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Auto Scaling session: %w", err)
+	}
+	return autoscaling.New(sess), nil
+}
+
+// GetNodeGroupDesiredCapacities returns the desired capacity of each Auto Scaling group backing the given EKS managed
+// node group, keyed by Auto Scaling group name. The groups are found by the eks:nodegroup-name tag EKS sets on them.
+func GetNodeGroupDesiredCapacities(asgSvc autoscalingiface.AutoScalingAPI, nodeGroup string) (map[string]int64, error) {
+	capacities := make(map[string]int64)
+	input := &autoscaling.DescribeAutoScalingGroupsInput{
+		Filters: []*autoscaling.Filter{
+			{Name: aws.String("tag:eks:nodegroup-name"), Values: []*string{aws.String(nodeGroup)}},
+		},
+	}
+	err := asgSvc.DescribeAutoScalingGroupsPages(input, func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+		for _, group := range page.AutoScalingGroups {
+			capacities[aws.StringValue(group.AutoScalingGroupName)] = aws.Int64Value(group.DesiredCapacity)
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to describe Auto Scaling groups of node group %q: %w", nodeGroup, err)
+	}
+	return capacities, nil
+}
+
+// RestoreDesiredCapacities sets the desired capacity of each Auto Scaling group back to the given value, skipping
+// groups that already have it.
+func RestoreDesiredCapacities(asgSvc autoscalingiface.AutoScalingAPI, capacities map[string]int64) error {
+	for name, capacity := range capacities {
+		output, err := asgSvc.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: []*string{aws.String(name)}})
+		if err != nil {
+			return fmt.Errorf("Failed to describe Auto Scaling group %q: %w", name, err)
+		}
+		if len(output.AutoScalingGroups) == 1 && aws.Int64Value(output.AutoScalingGroups[0].DesiredCapacity) == capacity {
+			continue
+		}
+
+		_, err = asgSvc.SetDesiredCapacity(&autoscaling.SetDesiredCapacityInput{
+			AutoScalingGroupName: aws.String(name),
+			DesiredCapacity:      aws.Int64(capacity),
+			HonorCooldown:        aws.Bool(false),
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to restore desired capacity of Auto Scaling group %q: %w", name, err)
+		}
+		logging.Progress("Restored desired capacity of Auto Scaling group", "group", name, "capacity", capacity)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package aws
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+)
+
+// fakeAutoScaling serves Auto Scaling groups from a map of desired capacities and records SetDesiredCapacity calls.
+type fakeAutoScaling struct {
+	autoscalingiface.AutoScalingAPI
+	desired map[string]int64
+	sets    int
+}
+
+// groups returns the Auto Scaling groups with the given names, or all groups when no names are given.
+func (f *fakeAutoScaling) groups(names []*string) []*autoscaling.Group {
+	var groups []*autoscaling.Group
+	for name, capacity := range f.desired {
+		if len(names) == 0 || aws.StringValue(names[0]) == name {
+			groups = append(groups, &autoscaling.Group{AutoScalingGroupName: aws.String(name), DesiredCapacity: aws.Int64(capacity)})
+		}
+	}
+	return groups
+}
+
+// DescribeAutoScalingGroupsPages returns all groups in a single page.
+func (f *fakeAutoScaling) DescribeAutoScalingGroupsPages(input *autoscaling.DescribeAutoScalingGroupsInput, fn func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool) error {
+	fn(&autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: f.groups(nil)}, true)
+	return nil
+}
+
+// DescribeAutoScalingGroups returns the requested groups.
+func (f *fakeAutoScaling) DescribeAutoScalingGroups(input *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	return &autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: f.groups(input.AutoScalingGroupNames)}, nil
+}
+
+// SetDesiredCapacity updates the desired capacity of the group.
+func (f *fakeAutoScaling) SetDesiredCapacity(input *autoscaling.SetDesiredCapacityInput) (*autoscaling.SetDesiredCapacityOutput, error) {
+	f.desired[aws.StringValue(input.AutoScalingGroupName)] = aws.Int64Value(input.DesiredCapacity)
+	f.sets++
+	return &autoscaling.SetDesiredCapacityOutput{}, nil
+}
+
+// TestRestoreDesiredCapacities checks that the captured desired capacities are restored and unchanged groups skipped.
+func TestRestoreDesiredCapacities(t *testing.T) {
+	asgSvc := &fakeAutoScaling{desired: map[string]int64{"eks-ng-a": 0, "eks-ng-b": 1}}
+	captured, err := GetNodeGroupDesiredCapacities(asgSvc, "benchmark")
+	if err != nil {
+		t.Fatalf("GetNodeGroupDesiredCapacities() returned error: %v", err)
+	}
+
+	asgSvc.desired["eks-ng-a"] = 5
+	if err := RestoreDesiredCapacities(asgSvc, captured); err != nil {
+		t.Fatalf("RestoreDesiredCapacities() returned error: %v", err)
+	}
+	if want := map[string]int64{"eks-ng-a": 0, "eks-ng-b": 1}; !reflect.DeepEqual(asgSvc.desired, want) {
+		t.Errorf("RestoreDesiredCapacities() left capacities %v, want %v", asgSvc.desired, want)
+	}
+	if asgSvc.sets != 1 {
+		t.Errorf("RestoreDesiredCapacities() made %d SetDesiredCapacity calls, want 1", asgSvc.sets)
+	}
+}
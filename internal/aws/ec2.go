@@ -8,23 +8,103 @@ package aws
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/config"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 
 	"k8s.io/client-go/kubernetes"
 )
 
-// GetEC2Instances retrieves a list of EC2 instances based on the specified filter name and value,
-// with an exponential backoff mechanism in case of throttling.
-func GetEC2Instances(ec2Svc *ec2.EC2, filterName, filterValue string) ([]*ec2.Instance, error) {
+// CountDescribeInstancesCalls returns a copy of the EC2 client that counts every DescribeInstances request it sends,
+// including pagination and SDK retries, in the returned counter. Each benchmark run uses its own copy so that
+// concurrent runs do not share counts.
+func CountDescribeInstancesCalls(ec2Svc *ec2.EC2) (*ec2.EC2, *atomic.Int64) {
+	counter := &atomic.Int64{}
+	return CountDescribeInstancesCallsIn(ec2Svc, counter), counter
+}
+
+// CountDescribeInstancesCallsIn returns a copy of the EC2 client that counts every DescribeInstances request it sends
+// in counter, so the clients of several regions can share the count of one run.
+func CountDescribeInstancesCallsIn(ec2Svc *ec2.EC2, counter *atomic.Int64) *ec2.EC2 {
+	client := *ec2Svc.Client
+	client.Handlers = ec2Svc.Handlers.Copy()
+	client.Handlers.Send.PushFront(func(r *request.Request) {
+		if r.Operation != nil && r.Operation.Name == "DescribeInstances" {
+			counter.Add(1)
+		}
+	})
+	return &ec2.EC2{Client: &client}
+}
+
+// NewRegionalEC2Clients returns an EC2 client for each of the given regions, using the credentials and endpoint of the
+// session.
+func NewRegionalEC2Clients(sess *session.Session, regions []string) []*ec2.EC2 {
+	clients := make([]*ec2.EC2, 0, len(regions))
+	for _, region := range regions {
+		clients = append(clients, ec2.New(sess, aws.NewConfig().WithRegion(region)))
+	}
+	return clients
+}
+
+// escapeFilterValue escapes the characters EC2 treats as wildcards in filter values (* and ?) and the backslash escape
+// character itself, so a tag value is matched literally.
+func escapeFilterValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(value)
+}
+
+// GetEC2Instances retrieves the EC2 instances matching the filter in the regions of the given clients, one per region,
+// and merges them in the order of the clients. Several regions are queried concurrently, for node pools that span
+// regions. It fails if any region fails, see getRegionInstances.
+func GetEC2Instances(ec2Svcs []*ec2.EC2, filterName, filterValue string, since time.Time) ([]*ec2.Instance, error) {
+	if len(ec2Svcs) == 1 {
+		return getRegionInstances(ec2Svcs[0], filterName, filterValue, since)
+	}
+
+	regionInstances := make([][]*ec2.Instance, len(ec2Svcs))
+	errs := make([]error, len(ec2Svcs))
+	var wg sync.WaitGroup
+	for i, ec2Svc := range ec2Svcs {
+		wg.Add(1)
+		go func(i int, ec2Svc *ec2.EC2) {
+			defer wg.Done()
+			regionInstances[i], errs[i] = getRegionInstances(ec2Svc, filterName, filterValue, since)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("Region %s: %w", aws.StringValue(ec2Svc.Config.Region), errs[i])
+			}
+		}(i, ec2Svc)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	var instances []*ec2.Instance
+	for _, regional := range regionInstances {
+		instances = append(instances, regional...)
+	}
+	return instances, nil
+}
+
+// getRegionInstances retrieves a list of EC2 instances based on the specified filter name and value,
+// with an exponential backoff mechanism in case of throttling. The filter value is matched literally.
+// Only instances launched after since (the start of the benchmark run) that are not terminated are returned.
+func getRegionInstances(ec2Svc *ec2.EC2, filterName, filterValue string, since time.Time) ([]*ec2.Instance, error) {
 	var instances []*ec2.Instance
 	var backoffDuration = 1 * time.Second
 	const maxRetries = 5
@@ -33,17 +113,17 @@ func GetEC2Instances(ec2Svc *ec2.EC2, filterName, filterValue string) ([]*ec2.In
 		Filters: []*ec2.Filter{
 			{
 				Name:   aws.String(filterName),
-				Values: []*string{aws.String(filterValue)},
+				Values: []*string{aws.String(escapeFilterValue(filterValue))},
 			},
 			// Optionally, add more filters here if needed.
 		},
 	}
 
 	for retries := 0; retries < maxRetries; retries++ {
-		err := ec2Svc.DescribeInstancesPages(input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		err := ec2Svc.DescribeInstancesPagesWithContext(aws.BackgroundContext(), input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
 			for _, reservation := range page.Reservations {
 				for _, instance := range reservation.Instances {
-					if instance.LaunchTime.After(config.ProgramStartTime) && *instance.State.Name != ec2.InstanceStateNameTerminated {
+					if instance.LaunchTime.After(since) && *instance.State.Name != ec2.InstanceStateNameTerminated {
 						instances = append(instances, instance)
 					}
 				}
@@ -54,7 +134,7 @@ func GetEC2Instances(ec2Svc *ec2.EC2, filterName, filterValue string) ([]*ec2.In
 		if err != nil {
 			awsErr, ok := err.(awserr.Error)
 			if ok && awsErr.Code() == "Throttling" {
-				fmt.Printf("Throttling error encountered, backing off for %v\n", backoffDuration)
+				logging.Warn("Throttling error encountered, backing off", "backoff", backoffDuration)
 				time.Sleep(backoffDuration)
 				backoffDuration *= 2
 			} else {
@@ -68,52 +148,331 @@ func GetEC2Instances(ec2Svc *ec2.EC2, filterName, filterValue string) ([]*ec2.In
 	return instances, nil
 }
 
-// MonitorInstanceProvisioning tracks the provisioning status of EC2 instances by filtering with tag key and value.
-// It prompts the user for action if provisioning exceeds the predefined timeout.
+// MonitorInstanceProvisioning tracks the provisioning status of EC2 instances by filtering with tag key and value in
+// the regions of the given clients.
+// Each time provisioning exceeds timeout, it prompts the user whether to keep waiting and reads the answer from prompt,
+// usually os.Stdin. When prompt is nil, e.g. in CI without a TTY, it fails with an error wrapping
+// bench.ErrProvisioningTimeout instead.
 // The function logs the provisioning status, including launched instances, until all required instances are in a 'Pending' state or a user intervention occurs.
-func MonitorInstanceProvisioning(clientset kubernetes.Interface, ec2Svc *ec2.EC2, tagKey, tagValue, deploymentName, namespace string) (time.Duration, int, error) {
-	fmt.Println("Monitoring EC2 instance provisioning...")
+// It returns the launched instances so callers can derive counts and metadata from them.
+// Only instances launched after since (the start of the benchmark run) are considered.
+// When expectedInstances is positive, it waits until that many instances are pending or running and measures up to
+// the launch of the last of them, timing the full provisioning of a multi-node scale-up instead of the first instance.
+// When events is not nil, an instance's launch is timed by its pending state-change event when one was received.
+// When firstInstanceTimeout is positive and no instance at all has appeared within it, it fails fast with an error
+// wrapping bench.ErrProvisioningTimeout instead of waiting for the full timeout and prompting.
+// When ctx is cancelled, e.g. on SIGINT, it stops polling and stops waiting for an answer to the prompt, and returns
+// ctx.Err().
+func MonitorInstanceProvisioning(ctx context.Context, clientset kubernetes.Interface, ec2Svcs []*ec2.EC2, tagKey, tagValue, deploymentName, namespace string, since time.Time, expectedInstances int, firstInstanceTimeout, timeout time.Duration, prompt io.Reader, events *InstanceEvents) (time.Duration, []*ec2.Instance, error) {
+	logging.Progress("Monitoring EC2 instance provisioning")
 	var instanceDetails []string
 	startTime := time.Now()
-	reader := bufio.NewReader(os.Stdin)
-	timeout := 60 * time.Second
-	instanceCount := 0
+	monitorStart := startTime
+	var reader *bufio.Reader
+	if prompt != nil {
+		reader = bufio.NewReader(prompt)
+	}
+	previousPoll := startTime
 
 	for {
 			time.Sleep(1 * time.Second)
-			if time.Since(startTime) >= timeout {
-					for {
-							fmt.Println("Provisioning timeout exceeded. There may be an issue (check pod for errors). Do you want to continue waiting to troubleshoot issue? [yes/no]: ")
-							answer, err := reader.ReadString('\n')
-							if err != nil {
-									return time.Since(startTime), instanceCount, fmt.Errorf("Failed to read input: %w", err)
-							}
-							answer = strings.TrimSpace(answer)
-							if answer == "no" {
-									return time.Since(startTime), instanceCount, fmt.Errorf("Exiting due to user input.")
-							} else if answer == "yes" {
-									startTime = time.Now()
-									fmt.Println("Please input 'no' at next timeout instead of force closing so that cleanup steps can be run by the program...")
-									break
-							} else {
-									fmt.Println("Invalid input. Please enter 'yes' or 'no'.")
-							}
-					}
+			if err := ctx.Err(); err != nil {
+					return time.Since(startTime), nil, err
 			}
 
-			instances, err := GetEC2Instances(ec2Svc, "tag:"+tagKey, tagValue)
+			pollTime := time.Now()
+			instances, err := GetEC2Instances(ec2Svcs, "tag:"+tagKey, tagValue, since)
 			if err != nil {
-					return time.Since(startTime), instanceCount, fmt.Errorf("Error retrieving EC2 instances: %w", err)
+					return time.Since(startTime), nil, fmt.Errorf("Error retrieving EC2 instances: %w", err)
+			}
+			if len(instances) == 0 && firstInstanceTimeout > 0 && time.Since(monitorStart) >= firstInstanceTimeout {
+					return time.Since(startTime), nil, fmt.Errorf("No instance appeared within the first instance timeout of %v, which usually means a misconfiguration such as a wrong node pool or unschedulable pods: %w", firstInstanceTimeout, bench.ErrProvisioningTimeout)
 			}
 
-			if len(instances) > 0 && *instances[0].State.Name == ec2.InstanceStateNamePending {
+			if launchedEnough(instances, expectedInstances) {
+					var launchTimes []time.Time
 					for _, instance := range instances {
 							detail := fmt.Sprintf("%s (%s)", *instance.InstanceId, *instance.PrivateDnsName)
 							instanceDetails = append(instanceDetails, detail)
+							launchTime := aws.TimeValue(instance.LaunchTime)
+							if pending, ok := events.StateTime(aws.StringValue(instance.InstanceId), ec2.InstanceStateNamePending); ok {
+									launchTime = pending
+							}
+							launchTimes = append(launchTimes, launchTime)
+					}
+					logging.Progress("Instances launched", "instances", strings.Join(instanceDetails, ", "))
+					// Measure up to the expected instance's launch rather than the poll that observed it.
+					launched := bench.TransitionTime(previousPoll, pollTime, bench.NthEarliest(launchTimes, max(expectedInstances, 1)))
+					return launched.Sub(startTime), instances, nil
+			}
+			previousPoll = pollTime
+
+			// Checked after the poll so that the first instance timeout, which is shorter, fails fast before prompting.
+			if time.Since(startTime) >= timeout {
+					if reader == nil {
+							return time.Since(startTime), nil, fmt.Errorf("No instances launched within the provisioning timeout of %v, not prompting in non-interactive mode: %w", timeout, bench.ErrProvisioningTimeout)
+					}
+					if err := askToKeepWaiting(ctx, reader); err != nil {
+							return time.Since(startTime), nil, err
 					}
-					fmt.Println("Instances launched:", strings.Join(instanceDetails, ", "))
-					instanceCount = len(instances) // Update instance count
-					return time.Since(startTime), instanceCount, nil
+					startTime = time.Now()
+					previousPoll = startTime
+			}
+	}
+}
+
+// askToKeepWaiting prompts whether to keep waiting for the instances once the provisioning timeout was exceeded, until
+// it reads yes or no from reader. It returns nil for yes, and otherwise the error the monitor fails with: one wrapping
+// bench.ErrProvisioningTimeout for no, ctx's error when ctx is cancelled, or the error reading the answer.
+func askToKeepWaiting(ctx context.Context, reader *bufio.Reader) error {
+	for {
+		fmt.Fprintln(os.Stderr, "Provisioning timeout exceeded. There may be an issue (check pod for errors). Do you want to continue waiting to troubleshoot issue? [yes/no]: ")
+		answer, err := readAnswer(ctx, reader)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to read input: %w", err)
+		}
+		switch strings.TrimSpace(answer) {
+		case "no":
+			return fmt.Errorf("Exiting due to user input: %w", bench.ErrProvisioningTimeout)
+		case "yes":
+			fmt.Fprintln(os.Stderr, "Please input 'no' at next timeout instead of force closing so that cleanup steps can be run by the program...")
+			return nil
+		}
+		fmt.Fprintln(os.Stderr, "Invalid input. Please enter 'yes' or 'no'.")
+	}
+}
+
+// readAnswer reads a line from reader, or returns ctx's error as soon as ctx is cancelled. A read blocked on a terminal
+// cannot be interrupted, so it is abandoned instead; the process is shutting down when ctx is cancelled.
+func readAnswer(ctx context.Context, reader *bufio.Reader) (string, error) {
+	type line struct {
+		text string
+		err  error
+	}
+	lines := make(chan line, 1)
+	go func() {
+		text, err := reader.ReadString('\n')
+		lines <- line{text, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case l := <-lines:
+		return l.text, l.err
+	}
+}
+
+// launchedEnough reports whether provisioning is detected: with a positive expectedInstances, when at least that many
+// instances are pending or running, and otherwise as soon as an instance is pending.
+func launchedEnough(instances []*ec2.Instance, expectedInstances int) bool {
+	if expectedInstances <= 0 {
+		return len(instances) > 0 && *instances[0].State.Name == ec2.InstanceStateNamePending
+	}
+	launched := 0
+	for _, instance := range instances {
+		if state := instanceState(instance); state == ec2.InstanceStateNamePending || state == ec2.InstanceStateNameRunning {
+			launched++
+		}
+	}
+	return launched >= expectedInstances
+}
+
+// Launch template tags that EC2 sets on instances launched from a launch template.
+const (
+	launchTemplateIDTag      = "aws:ec2launchtemplate:id"
+	launchTemplateVersionTag = "aws:ec2launchtemplate:version"
+)
+
+// CountLaunchTemplates tallies the given instances by the launch template ID and version they were launched from,
+// keyed as "<id>:<version>". Instances not launched from a launch template are not counted.
+func CountLaunchTemplates(instances []*ec2.Instance) map[string]int {
+	counts := map[string]int{}
+	for _, instance := range instances {
+		var id, version string
+		for _, tag := range instance.Tags {
+			switch aws.StringValue(tag.Key) {
+			case launchTemplateIDTag:
+				id = aws.StringValue(tag.Value)
+			case launchTemplateVersionTag:
+				version = aws.StringValue(tag.Value)
+			}
+		}
+		if id != "" {
+			counts[id+":"+version]++
+		}
+	}
+	return counts
+}
+
+// CountInstanceTypes tallies the given instances by instance type, e.g. to see what Karpenter chose for a scale-up.
+func CountInstanceTypes(instances []*ec2.Instance) map[string]int {
+	counts := map[string]int{}
+	for _, instance := range instances {
+		counts[aws.StringValue(instance.InstanceType)]++
+	}
+	return counts
+}
+
+// CountAvailabilityZones tallies the given instances by the availability zone they were placed in. Instances without
+// a placement are not counted.
+func CountAvailabilityZones(instances []*ec2.Instance) map[string]int {
+	counts := map[string]int{}
+	for _, instance := range instances {
+		if instance.Placement != nil && aws.StringValue(instance.Placement.AvailabilityZone) != "" {
+			counts[aws.StringValue(instance.Placement.AvailabilityZone)]++
+		}
+	}
+	return counts
+}
+
+// CountLifecycles returns how many of the given instances are spot instances and how many are on-demand. EC2 leaves
+// InstanceLifecycle unset for on-demand instances, and the other non-spot lifecycles (scheduled, capacity-block) are
+// counted as on-demand too since they are not subject to spot interruptions.
+func CountLifecycles(instances []*ec2.Instance) (spot, onDemand int) {
+	for _, instance := range instances {
+		if aws.StringValue(instance.InstanceLifecycle) == ec2.InstanceLifecycleTypeSpot {
+			spot++
+		} else {
+			onDemand++
+		}
+	}
+	return spot, onDemand
+}
+
+// InstanceLaunches returns the launch of each instance with its instance type and the time from since until it was
+// launched. The launches are not yet classified as first launches or repeats, see bench.LaunchHistory.
+func InstanceLaunches(instances []*ec2.Instance, since time.Time) []bench.InstanceLaunch {
+	launches := make([]bench.InstanceLaunch, 0, len(instances))
+	for _, instance := range instances {
+		launch := bench.InstanceLaunch{
+			InstanceID:       aws.StringValue(instance.InstanceId),
+			InstanceType:     aws.StringValue(instance.InstanceType),
+			Spot:             aws.StringValue(instance.InstanceLifecycle) == ec2.InstanceLifecycleTypeSpot,
+			ProvisioningTime: aws.TimeValue(instance.LaunchTime).Sub(since),
+		}
+		if instance.Placement != nil {
+			launch.Region = zoneRegion(aws.StringValue(instance.Placement.AvailabilityZone))
+		}
+		launches = append(launches, launch)
+	}
+	return launches
+}
+
+// zoneRegion returns the region of an availability zone, e.g. "us-east-1" for "us-east-1a" and for the Local Zone
+// "us-east-1-bos-1a".
+func zoneRegion(zone string) string {
+	if parts := strings.Split(zone, "-"); len(parts) > 3 {
+		return strings.Join(parts[:3], "-")
+	}
+	return strings.TrimRight(zone, "abcdefghijklmnopqrstuvwxyz")
+}
+
+// MissingInstanceIDs returns the IDs of the launched instances that are no longer among the current instances.
+func MissingInstanceIDs(launched, current []*ec2.Instance) []string {
+	currentIDs := make(map[string]bool, len(current))
+	for _, instance := range current {
+		currentIDs[aws.StringValue(instance.InstanceId)] = true
+	}
+	var missing []string
+	for _, instance := range launched {
+		if id := aws.StringValue(instance.InstanceId); !currentIDs[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// spotInterruptionReason is the state reason code EC2 sets on a spot instance it reclaimed.
+const spotInterruptionReason = "Server.SpotInstanceTermination"
+
+// FindSpotInterruptions looks up the given instances, including terminated ones, in the regions of the given clients
+// and returns the IDs of those that EC2 terminated because of a spot interruption.
+func FindSpotInterruptions(ec2Svcs []*ec2.EC2, instanceIDs []string) ([]string, error) {
+	if len(instanceIDs) == 0 {
+		return nil, nil
+	}
+
+	instances, err := describeInstancesByID(aws.BackgroundContext(), ec2Svcs, instanceIDs)
+	if err != nil {
+		return nil, err
+	}
+	return spotInterruptedIDs(instances), nil
+}
+
+// spotInterruptedIDs returns the IDs of the instances whose state reason is a spot interruption.
+func spotInterruptedIDs(instances []*ec2.Instance) []string {
+	var ids []string
+	for _, instance := range instances {
+		if instance.StateReason != nil && aws.StringValue(instance.StateReason.Code) == spotInterruptionReason {
+			ids = append(ids, aws.StringValue(instance.InstanceId))
+		}
+	}
+	return ids
+}
+
+// TerminateInstances terminates the given EC2 instances.
+func TerminateInstances(ec2Svc *ec2.EC2, instanceIDs []string) error {
+	if len(instanceIDs) == 0 {
+		return nil
+	}
+	if _, err := ec2Svc.TerminateInstances(&ec2.TerminateInstancesInput{InstanceIds: aws.StringSlice(instanceIDs)}); err != nil {
+		return fmt.Errorf("Failed to terminate instances %v: %w", instanceIDs, err)
+	}
+	return nil
+}
+
+// CreateTags stamps the given EC2 instances with the tags, e.g. a run ID and cost allocation tags, in one request.
+// Existing tags with the same keys are overwritten.
+func CreateTags(ec2Svc *ec2.EC2, instanceIDs []string, tags map[string]string) error {
+	if len(instanceIDs) == 0 || len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	ec2Tags := make([]*ec2.Tag, 0, len(keys))
+	for _, key := range keys {
+		ec2Tags = append(ec2Tags, &ec2.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	if _, err := ec2Svc.CreateTags(&ec2.CreateTagsInput{Resources: aws.StringSlice(instanceIDs), Tags: ec2Tags}); err != nil {
+		return fmt.Errorf("Failed to tag instances %v: %w", instanceIDs, err)
+	}
+	return nil
+}
+
+// InstanceIDs returns the IDs of the given instances.
+func InstanceIDs(instances []*ec2.Instance) []string {
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, aws.StringValue(instance.InstanceId))
+	}
+	return ids
+}
+
+// InstanceTag returns the value of the instance's tag with the given key, and whether the instance carries it.
+func InstanceTag(instance *ec2.Instance, key string) (string, bool) {
+	for _, tag := range instance.Tags {
+		if aws.StringValue(tag.Key) == key {
+			return aws.StringValue(tag.Value), true
+		}
+	}
+	return "", false
+}
+
+// AnyInstancePresent reports whether any of the given instance IDs is among the instances.
+func AnyInstancePresent(ids []string, instances []*ec2.Instance) bool {
+	for _, instance := range instances {
+		for _, id := range ids {
+			if aws.StringValue(instance.InstanceId) == id {
+				return true
 			}
+		}
 	}
+	return false
 }
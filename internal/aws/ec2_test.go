@@ -0,0 +1,445 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package aws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testInstance returns an EC2 instance carrying the given tags, given as alternating keys and values.
+func testInstance(tags ...string) *ec2.Instance {
+	instance := &ec2.Instance{}
+	for i := 0; i+1 < len(tags); i += 2 {
+		instance.Tags = append(instance.Tags, &ec2.Tag{Key: aws.String(tags[i]), Value: aws.String(tags[i+1])})
+	}
+	return instance
+}
+
+// TestCountLaunchTemplates checks that instances are tallied by launch template ID and version.
+func TestCountLaunchTemplates(t *testing.T) {
+	instances := []*ec2.Instance{
+		testInstance(launchTemplateIDTag, "lt-0abc", launchTemplateVersionTag, "3"),
+		testInstance(launchTemplateVersionTag, "3", launchTemplateIDTag, "lt-0abc"),
+		testInstance(launchTemplateIDTag, "lt-0abc", launchTemplateVersionTag, "4"),
+		testInstance("eks:nodegroup-name", "benchmark"),
+	}
+
+	want := map[string]int{"lt-0abc:3": 2, "lt-0abc:4": 1}
+	if got := CountLaunchTemplates(instances); !reflect.DeepEqual(got, want) {
+		t.Errorf("CountLaunchTemplates() = %v, want %v", got, want)
+	}
+}
+
+// TestCountInstanceTypes checks that instances are tallied by instance type and availability zone.
+func TestCountInstanceTypes(t *testing.T) {
+	instances := []*ec2.Instance{
+		{InstanceType: aws.String("c7i.large"), Placement: &ec2.Placement{AvailabilityZone: aws.String("us-east-1a")}},
+		{InstanceType: aws.String("m7i.xlarge"), Placement: &ec2.Placement{AvailabilityZone: aws.String("us-east-1b")}},
+		{InstanceType: aws.String("c7i.large"), Placement: &ec2.Placement{AvailabilityZone: aws.String("us-east-1a")}},
+		{InstanceType: aws.String("c7i.large")},
+	}
+
+	wantTypes := map[string]int{"c7i.large": 3, "m7i.xlarge": 1}
+	if got := CountInstanceTypes(instances); !reflect.DeepEqual(got, wantTypes) {
+		t.Errorf("CountInstanceTypes() = %v, want %v", got, wantTypes)
+	}
+	wantZones := map[string]int{"us-east-1a": 2, "us-east-1b": 1}
+	if got := CountAvailabilityZones(instances); !reflect.DeepEqual(got, wantZones) {
+		t.Errorf("CountAvailabilityZones() = %v, want %v", got, wantZones)
+	}
+}
+
+// TestCountLifecycles checks that spot instances are told apart from on-demand ones, including those without a lifecycle.
+func TestCountLifecycles(t *testing.T) {
+	instances := []*ec2.Instance{
+		{InstanceLifecycle: aws.String("spot")},
+		{},
+		{InstanceLifecycle: aws.String("spot")},
+		{InstanceLifecycle: aws.String("capacity-block")},
+		{InstanceLifecycle: aws.String("spot")},
+	}
+
+	if spot, onDemand := CountLifecycles(instances); spot != 3 || onDemand != 2 {
+		t.Errorf("CountLifecycles() = %d spot, %d on-demand, want 3 and 2", spot, onDemand)
+	}
+	if spot, onDemand := CountLifecycles(nil); spot != 0 || onDemand != 0 {
+		t.Errorf("CountLifecycles(nil) = %d spot, %d on-demand, want 0 and 0", spot, onDemand)
+	}
+}
+
+// TestInstanceLaunches checks that each instance's type, region, lifecycle and launch time relative to the start are
+// returned, including the region of a Local Zone.
+func TestInstanceLaunches(t *testing.T) {
+	since := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	instances := []*ec2.Instance{
+		{InstanceId: aws.String("i-1"), InstanceType: aws.String("c7i.large"), LaunchTime: aws.Time(since.Add(12 * time.Second)), Placement: &ec2.Placement{AvailabilityZone: aws.String("us-east-1a")}},
+		{InstanceId: aws.String("i-2"), InstanceType: aws.String("m7i.large"), LaunchTime: aws.Time(since.Add(15 * time.Second)), Placement: &ec2.Placement{AvailabilityZone: aws.String("us-east-1-bos-1a")}, InstanceLifecycle: aws.String("spot")},
+	}
+
+	want := []bench.InstanceLaunch{
+		{InstanceID: "i-1", InstanceType: "c7i.large", Region: "us-east-1", ProvisioningTime: 12 * time.Second},
+		{InstanceID: "i-2", InstanceType: "m7i.large", Region: "us-east-1", Spot: true, ProvisioningTime: 15 * time.Second},
+	}
+	if got := InstanceLaunches(instances, since); !reflect.DeepEqual(got, want) {
+		t.Errorf("InstanceLaunches() = %+v, want %+v", got, want)
+	}
+}
+
+// TestMissingInstanceIDs checks that launched instances absent from the current instances are returned.
+func TestMissingInstanceIDs(t *testing.T) {
+	launched := []*ec2.Instance{{InstanceId: aws.String("i-1")}, {InstanceId: aws.String("i-2")}, {InstanceId: aws.String("i-3")}}
+	current := []*ec2.Instance{{InstanceId: aws.String("i-2")}, {InstanceId: aws.String("i-4")}}
+
+	if got := MissingInstanceIDs(launched, current); !reflect.DeepEqual(got, []string{"i-1", "i-3"}) {
+		t.Errorf("MissingInstanceIDs() = %v, want [i-1 i-3]", got)
+	}
+}
+
+// TestSpotInterruptedIDs checks that only instances terminated by a spot interruption are reported.
+func TestSpotInterruptedIDs(t *testing.T) {
+	instances := []*ec2.Instance{
+		{InstanceId: aws.String("i-1"), StateReason: &ec2.StateReason{Code: aws.String("Server.SpotInstanceTermination")}},
+		{InstanceId: aws.String("i-2"), StateReason: &ec2.StateReason{Code: aws.String("Client.UserInitiatedShutdown")}},
+		{InstanceId: aws.String("i-3")},
+	}
+
+	if got := spotInterruptedIDs(instances); !reflect.DeepEqual(got, []string{"i-1"}) {
+		t.Errorf("spotInterruptedIDs() = %v, want [i-1]", got)
+	}
+}
+
+// TestFindSpotInterruptions checks that churned instances are looked up in every region, so that an instance of
+// another region neither fails the lookup nor hides the spot interruptions of the others.
+func TestFindSpotInterruptions(t *testing.T) {
+	var clients []*ec2.EC2
+	for _, instance := range []string{
+		`<item><instanceId>i-east</instanceId><stateReason><code>Server.SpotInstanceTermination</code></stateReason></item>`,
+		`<item><instanceId>i-west</instanceId><stateReason><code>Server.SpotInstanceTermination</code></stateReason></item>`,
+	} {
+		response := `<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><reservationSet><item><instancesSet>` + instance + `</instancesSet></item></reservationSet></DescribeInstancesResponse>`
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(response))
+		}))
+		defer server.Close()
+		sess := session.Must(session.NewSession(&aws.Config{
+			Endpoint:    aws.String(server.URL),
+			Region:      aws.String("us-east-1"),
+			Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		}))
+		clients = append(clients, ec2.New(sess))
+	}
+
+	interrupted, err := FindSpotInterruptions(clients, []string{"i-east", "i-west"})
+	if err != nil {
+		t.Fatalf("FindSpotInterruptions() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(interrupted, []string{"i-east", "i-west"}) {
+		t.Errorf("FindSpotInterruptions() = %v, want [i-east i-west]", interrupted)
+	}
+}
+
+// TestAnyInstancePresent checks that presence is detected by instance ID.
+func TestAnyInstancePresent(t *testing.T) {
+	instances := []*ec2.Instance{{InstanceId: aws.String("i-1")}, {InstanceId: aws.String("i-2")}}
+
+	if !AnyInstancePresent(InstanceIDs(instances[1:]), instances) {
+		t.Errorf("AnyInstancePresent() = false for a present instance")
+	}
+	if AnyInstancePresent([]string{"i-3"}, instances) {
+		t.Errorf("AnyInstancePresent() = true for an absent instance")
+	}
+	if AnyInstancePresent([]string{"i-1"}, nil) {
+		t.Errorf("AnyInstancePresent() = true without instances")
+	}
+}
+
+// describeInstancesResponse is a DescribeInstances response with one instance launched before and one after 2024-04-01 12:00 UTC.
+const describeInstancesResponse = `<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <reservationSet><item><instancesSet>
+    <item><instanceId>i-old</instanceId><launchTime>2024-04-01T11:00:00.000Z</launchTime><instanceState><name>running</name></instanceState></item>
+    <item><instanceId>i-new</instanceId><launchTime>2024-04-01T12:30:00.000Z</launchTime><instanceState><name>pending</name></instanceState></item>
+  </instancesSet></item></reservationSet>
+</DescribeInstancesResponse>`
+
+// TestGetEC2InstancesFilter checks that tag keys with dots, slashes and colons are sent unchanged and that wildcard
+// characters in the tag value are escaped so it matches literally.
+func TestGetEC2InstancesFilter(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		w.Write([]byte(describeInstancesResponse))
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	if _, err := GetEC2Instances([]*ec2.EC2{ec2.New(sess)}, "tag:example.com/team:pool", `team.a/b:c*?\`, time.Time{}); err != nil {
+		t.Fatalf("GetEC2Instances() returned error: %v", err)
+	}
+	if name := form.Get("Filter.1.Name"); name != "tag:example.com/team:pool" {
+		t.Errorf("GetEC2Instances() filter name = %q, want %q", name, "tag:example.com/team:pool")
+	}
+	if value := form.Get("Filter.1.Value.1"); value != `team.a/b:c\*\?\\` {
+		t.Errorf("GetEC2Instances() filter value = %q, want %q", value, `team.a/b:c\*\?\\`)
+	}
+}
+
+// TestTerminateInstances checks that the instance IDs are sent in a TerminateInstances request.
+func TestTerminateInstances(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		w.Write([]byte(`<TerminateInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><requestId>1</requestId><instancesSet/></TerminateInstancesResponse>`))
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	if err := TerminateInstances(ec2.New(sess), []string{"i-1"}); err != nil {
+		t.Fatalf("TerminateInstances() returned error: %v", err)
+	}
+	if form.Get("Action") != "TerminateInstances" || form.Get("InstanceId.1") != "i-1" {
+		t.Errorf("TerminateInstances() sent %v, want Action=TerminateInstances and InstanceId.1=i-1", form)
+	}
+}
+
+// TestCreateTags checks that the instance IDs and the tags, sorted by key, are sent in a CreateTags request, and that
+// no request is sent without instances.
+func TestCreateTags(t *testing.T) {
+	var requests []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, r.PostForm)
+		w.Write([]byte(`<CreateTagsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><requestId>1</requestId><return>true</return></CreateTagsResponse>`))
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	ec2Svc := ec2.New(sess)
+	if err := CreateTags(ec2Svc, nil, map[string]string{"kab-run-id": "run-1"}); err != nil || len(requests) != 0 {
+		t.Fatalf("CreateTags() without instances = %v with %d requests, want nil and none", err, len(requests))
+	}
+	if err := CreateTags(ec2Svc, []string{"i-1", "i-2"}, map[string]string{"team": "platform", "kab-run-id": "run-1"}); err != nil {
+		t.Fatalf("CreateTags() returned error: %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("CreateTags() sent %d requests, want 1", len(requests))
+	}
+	form := requests[0]
+	want := map[string]string{
+		"Action":       "CreateTags",
+		"ResourceId.1": "i-1",
+		"ResourceId.2": "i-2",
+		"Tag.1.Key":    "kab-run-id",
+		"Tag.1.Value":  "run-1",
+		"Tag.2.Key":    "team",
+		"Tag.2.Value":  "platform",
+	}
+	for key, value := range want {
+		if form.Get(key) != value {
+			t.Errorf("CreateTags() sent %s=%q, want %q", key, form.Get(key), value)
+		}
+	}
+}
+
+// TestGetEC2InstancesSinceAndCount checks that only instances launched after since are returned and that each
+// counting client counts its own DescribeInstances calls.
+func TestGetEC2InstancesSinceAndCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(describeInstancesResponse))
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	ec2Svc := ec2.New(sess)
+	first, firstCalls := CountDescribeInstancesCalls(ec2Svc)
+	_, secondCalls := CountDescribeInstancesCalls(ec2Svc)
+
+	instances, err := GetEC2Instances([]*ec2.EC2{first}, "tag:karpenter.sh/nodepool", "default", time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetEC2Instances() returned error: %v", err)
+	}
+	if ids := InstanceIDs(instances); !reflect.DeepEqual(ids, []string{"i-new"}) {
+		t.Errorf("GetEC2Instances() = %v, want [i-new]", ids)
+	}
+	if firstCalls.Load() != 1 || secondCalls.Load() != 0 {
+		t.Errorf("DescribeInstances calls = %d and %d, want 1 and 0", firstCalls.Load(), secondCalls.Load())
+	}
+}
+
+// TestLaunchedEnough checks that without an expected count the first pending instance is enough, and that with one
+// the pending and running instances are counted.
+func TestLaunchedEnough(t *testing.T) {
+	launch := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	instances := []*ec2.Instance{
+		stateInstance("i-1", ec2.InstanceStateNamePending, launch),
+		stateInstance("i-2", ec2.InstanceStateNameRunning, launch),
+		stateInstance("i-3", ec2.InstanceStateNameShuttingDown, launch),
+	}
+
+	for _, tc := range []struct {
+		expected int
+		want     bool
+	}{
+		{0, true},
+		{2, true},
+		{3, false},
+	} {
+		if got := launchedEnough(instances, tc.expected); got != tc.want {
+			t.Errorf("launchedEnough(%d) = %v, want %v", tc.expected, got, tc.want)
+		}
+	}
+	if launchedEnough(nil, 0) {
+		t.Errorf("launchedEnough() = true without instances")
+	}
+}
+
+// timedReader answers "no" to a prompt and records when it was first read.
+type timedReader struct {
+	readAt time.Time
+}
+
+// Read records the time of the first read and returns "no".
+func (r *timedReader) Read(p []byte) (int, error) {
+	if r.readAt.IsZero() {
+		r.readAt = time.Now()
+	}
+	return copy(p, "no\n"), nil
+}
+
+// TestMonitorInstanceProvisioningTimeout checks that the prompt fires once the given provisioning timeout passed
+// without any instance, that answering "no" fails with ErrProvisioningTimeout, that without a prompt reader the
+// timeout fails right away, and that the first instance timeout fails fast before prompting.
+func TestMonitorInstanceProvisioningTimeout(t *testing.T) {
+	reader := &timedReader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><reservationSet/></DescribeInstancesResponse>`))
+	}))
+	defer server.Close()
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+
+	start := time.Now()
+	_, _, err := MonitorInstanceProvisioning(context.Background(), fake.NewSimpleClientset(), []*ec2.EC2{ec2.New(sess)}, "karpenter.sh/nodepool", "default", "inflate", "default", start, 0, 0, 2*time.Second, reader, nil)
+	if !errors.Is(err, bench.ErrProvisioningTimeout) {
+		t.Fatalf("MonitorInstanceProvisioning() returned %v, want ErrProvisioningTimeout", err)
+	}
+	if prompted := reader.readAt.Sub(start); prompted < 2*time.Second || prompted > 4*time.Second {
+		t.Errorf("MonitorInstanceProvisioning() prompted after %v, want about 2s", prompted)
+	}
+
+	start = time.Now()
+	_, _, err = MonitorInstanceProvisioning(context.Background(), fake.NewSimpleClientset(), []*ec2.EC2{ec2.New(sess)}, "karpenter.sh/nodepool", "default", "inflate", "default", start, 0, 0, time.Second, nil, nil)
+	if !errors.Is(err, bench.ErrProvisioningTimeout) || time.Since(start) > 3*time.Second {
+		t.Errorf("MonitorInstanceProvisioning() without a prompt reader returned %v after %v, want ErrProvisioningTimeout after about 1s", err, time.Since(start))
+	}
+
+	reader = &timedReader{}
+	_, _, err = MonitorInstanceProvisioning(context.Background(), fake.NewSimpleClientset(), []*ec2.EC2{ec2.New(sess)}, "karpenter.sh/nodepool", "default", "inflate", "default", time.Now(), 0, time.Second, time.Second, reader, nil)
+	if !errors.Is(err, bench.ErrProvisioningTimeout) || !strings.Contains(err.Error(), "first instance timeout") || !reader.readAt.IsZero() {
+		t.Errorf("MonitorInstanceProvisioning() past both timeouts returned %v, want the first instance timeout without prompting", err)
+	}
+}
+
+// TestGetEC2InstancesRegions checks that the instances of every region are merged in the order of the clients, and
+// that the regions' DescribeInstances calls are counted in one shared counter.
+func TestGetEC2InstancesRegions(t *testing.T) {
+	var clients []*ec2.EC2
+	counter := &atomic.Int64{}
+	for _, id := range []string{"i-east", "i-west"} {
+		response := strings.ReplaceAll(describeInstancesResponse, "i-new", id)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(response))
+		}))
+		defer server.Close()
+		sess := session.Must(session.NewSession(&aws.Config{
+			Endpoint:    aws.String(server.URL),
+			Region:      aws.String("us-east-1"),
+			Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		}))
+		clients = append(clients, CountDescribeInstancesCallsIn(ec2.New(sess), counter))
+	}
+
+	instances, err := GetEC2Instances(clients, "tag:karpenter.sh/nodepool", "default", time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetEC2Instances() returned error: %v", err)
+	}
+	if ids := InstanceIDs(instances); !reflect.DeepEqual(ids, []string{"i-east", "i-west"}) {
+		t.Errorf("GetEC2Instances() = %v, want [i-east i-west]", ids)
+	}
+	if counter.Load() != 2 {
+		t.Errorf("DescribeInstances calls = %d, want 2", counter.Load())
+	}
+}
+
+// TestMonitorInstanceProvisioningCancel checks that cancelling the context stops both the polling and the wait for an
+// answer to the timeout prompt, returning the context's error.
+func TestMonitorInstanceProvisioningCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><reservationSet/></DescribeInstancesResponse>`))
+	}))
+	defer server.Close()
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	ec2Svcs := []*ec2.EC2{ec2.New(sess)}
+
+	for _, tc := range []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"mid-poll", time.Minute},
+		{"at the prompt", time.Nanosecond},
+	} {
+		promptReader, promptWriter := io.Pipe()
+		defer promptWriter.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(1500*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, _, err := MonitorInstanceProvisioning(ctx, fake.NewSimpleClientset(), ec2Svcs, "karpenter.sh/nodepool", "default", "inflate", "default", start, 0, 0, tc.timeout, promptReader, nil)
+		if !errors.Is(err, context.Canceled) || time.Since(start) > 3*time.Second {
+			t.Errorf("MonitorInstanceProvisioning() cancelled %s returned %v after %v, want context.Canceled right after cancelling", tc.name, err, time.Since(start))
+		}
+	}
+}
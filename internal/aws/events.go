@@ -0,0 +1,195 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// instanceStateChangeDetailType is the EventBridge detail-type of EC2 instance state-change notifications.
+const instanceStateChangeDetailType = "EC2 Instance State-change Notification"
+
+// eventRetryInterval is how long WatchInstanceStateEvents waits before receiving again after a failure.
+var eventRetryInterval = 10 * time.Second
+
+// instanceEventRetention is how long InstanceEvents keeps the events of an instance after its last one.
+const instanceEventRetention = 24 * time.Hour
+
+// InstanceStateEvent is an EC2 instance state-change notification.
+type InstanceStateEvent struct {
+	InstanceID string
+	State      string
+	Time       time.Time
+}
+
+// InstanceEvents records the EC2 instance state-change notifications consumed from a queue by instance, so that the
+// monitors can time a state change by its event rather than by the poll that observed it. Each run looks up only its
+// own instances, and a single InstanceEvents is shared by all runs consuming the same queue, so that concurrent runs
+// do not take each other's messages. A nil *InstanceEvents has no events.
+type InstanceEvents struct {
+	mu     sync.Mutex
+	states map[string]map[string]time.Time
+	last   map[string]time.Time
+}
+
+// NewInstanceEvents returns an empty InstanceEvents.
+func NewInstanceEvents() *InstanceEvents {
+	return &InstanceEvents{states: map[string]map[string]time.Time{}, last: map[string]time.Time{}}
+}
+
+// record remembers the earliest event of the instance for each state, and forgets the instances without an event in
+// instanceEventRetention.
+func (e *InstanceEvents) record(event InstanceStateEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for id, last := range e.last {
+		if event.Time.Sub(last) > instanceEventRetention {
+			delete(e.states, id)
+			delete(e.last, id)
+		}
+	}
+	states := e.states[event.InstanceID]
+	if states == nil {
+		states = map[string]time.Time{}
+		e.states[event.InstanceID] = states
+	}
+	if at, ok := states[event.State]; !ok || event.Time.Before(at) {
+		states[event.State] = event.Time
+	}
+	if event.Time.After(e.last[event.InstanceID]) {
+		e.last[event.InstanceID] = event.Time
+	}
+}
+
+// StateTime returns when the instance entered the state according to its state-change event, and false when no such
+// event was received.
+func (e *InstanceEvents) StateTime(instanceID, state string) (time.Time, bool) {
+	if e == nil {
+		return time.Time{}, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	at, ok := e.states[instanceID][state]
+	return at, ok
+}
+
+// LastStateTime returns when the last of the instances entered the state according to their state-change events, and
+// false unless an event was received for each of them.
+func (e *InstanceEvents) LastStateTime(instanceIDs []string, state string) (time.Time, bool) {
+	var last time.Time
+	for _, id := range instanceIDs {
+		at, ok := e.StateTime(id, state)
+		if !ok {
+			return time.Time{}, false
+		}
+		if at.After(last) {
+			last = at
+		}
+	}
+	return last, len(instanceIDs) > 0
+}
+
+// NewSQS returns an SQS client for the queue at queueURL using the same credentials as the EC2 client. It uses the
+// queue's region taken from its URL, e.g. https://sqs.us-west-2.amazonaws.com/123456789012/queue, so that the queue
+// may live in any of the --region regions, and the EC2 client's region for URLs without one, e.g. of LocalStack.
+func NewSQS(ec2Svc *ec2.EC2, queueURL string) (*sqs.SQS, error) {
+	config := ec2Svc.Config.Copy()
+	if region := queueRegion(queueURL); region != "" {
+		config.Region = aws.String(region)
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create SQS session: %w", err)
+	}
+	return sqs.New(sess), nil
+}
+
+// queueRegion returns the region of an SQS queue URL in either the sqs.<region>.amazonaws.com or the legacy
+// <region>.queue.amazonaws.com form, or "" for other URLs.
+func queueRegion(queueURL string) string {
+	parsed, err := url.Parse(queueURL)
+	if err != nil {
+		return ""
+	}
+	labels := strings.Split(parsed.Hostname(), ".")
+	if len(labels) < 4 {
+		return ""
+	}
+	switch {
+	case labels[0] == "sqs":
+		return labels[1]
+	case labels[1] == "queue":
+		return labels[0]
+	}
+	return ""
+}
+
+// ParseInstanceStateEvent decodes an EventBridge EC2 instance state-change notification delivered through SQS. It
+// reports false for messages that are not such notifications.
+func ParseInstanceStateEvent(body string) (InstanceStateEvent, bool) {
+	var message struct {
+		DetailType string    `json:"detail-type"`
+		Time       time.Time `json:"time"`
+		Detail     struct {
+			InstanceID string `json:"instance-id"`
+			State      string `json:"state"`
+		} `json:"detail"`
+	}
+	if err := json.Unmarshal([]byte(body), &message); err != nil || message.DetailType != instanceStateChangeDetailType || message.Detail.InstanceID == "" {
+		return InstanceStateEvent{}, false
+	}
+	return InstanceStateEvent{InstanceID: message.Detail.InstanceID, State: message.Detail.State, Time: message.Time}, true
+}
+
+// WatchInstanceStateEvents consumes the EC2 instance state-change notifications EventBridge delivers to the SQS queue
+// and records them in events until stop is closed. Received messages are deleted from the queue, so it should be
+// dedicated to one benchmark process, which should watch it once for all its runs. Receive errors are logged and
+// retried, since the monitors keep polling DescribeInstances every second either way.
+func WatchInstanceStateEvents(sqsSvc sqsiface.SQSAPI, queueURL string, events *InstanceEvents, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		output, err := sqsSvc.ReceiveMessage(&sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(queueURL),
+			MaxNumberOfMessages: aws.Int64(10),
+			WaitTimeSeconds:     aws.Int64(1),
+		})
+		if err != nil {
+			logging.Warn("Failed to receive EC2 instance state-change events, timing by the polls", "error", err)
+			time.Sleep(eventRetryInterval)
+			continue
+		}
+
+		var entries []*sqs.DeleteMessageBatchRequestEntry
+		for i, message := range output.Messages {
+			if event, ok := ParseInstanceStateEvent(aws.StringValue(message.Body)); ok {
+				events.record(event)
+			}
+			entries = append(entries, &sqs.DeleteMessageBatchRequestEntry{Id: aws.String(fmt.Sprint(i)), ReceiptHandle: message.ReceiptHandle})
+		}
+		if len(entries) > 0 {
+			if _, err := sqsSvc.DeleteMessageBatch(&sqs.DeleteMessageBatchInput{QueueUrl: aws.String(queueURL), Entries: entries}); err != nil {
+				logging.Warn("Failed to delete EC2 instance state-change events from the queue", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// stateChangeMessage is an EventBridge EC2 instance state-change notification as delivered through SQS.
+const stateChangeMessage = `{
+  "version": "0",
+  "detail-type": "EC2 Instance State-change Notification",
+  "source": "aws.ec2",
+  "time": "2024-04-01T12:00:05Z",
+  "detail": {"instance-id": "i-0abc", "state": "pending"}
+}`
+
+// TestParseInstanceStateEvent checks that state-change notifications are decoded and other messages ignored.
+func TestParseInstanceStateEvent(t *testing.T) {
+	event, ok := ParseInstanceStateEvent(stateChangeMessage)
+	want := InstanceStateEvent{InstanceID: "i-0abc", State: "pending", Time: time.Date(2024, 4, 1, 12, 0, 5, 0, time.UTC)}
+	if !ok || event != want {
+		t.Errorf("ParseInstanceStateEvent() = %+v, %v, want %+v, true", event, ok, want)
+	}
+
+	for _, body := range []string{`{"detail-type": "EC2 Spot Instance Interruption Warning", "detail": {"instance-id": "i-0abc"}}`, "not json"} {
+		if _, ok := ParseInstanceStateEvent(body); ok {
+			t.Errorf("ParseInstanceStateEvent(%q) = true, want false", body)
+		}
+	}
+}
+
+// fakeSQS serves a fixed batch of messages once and records the deleted receipt handles.
+type fakeSQS struct {
+	sqsiface.SQSAPI
+	messages []*sqs.Message
+	deleted  chan string
+}
+
+// ReceiveMessage returns the pending messages, or none once they were served.
+func (f *fakeSQS) ReceiveMessage(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	messages := f.messages
+	f.messages = nil
+	if len(messages) == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return &sqs.ReceiveMessageOutput{Messages: messages}, nil
+}
+
+// DeleteMessageBatch records the receipt handles of the deleted messages.
+func (f *fakeSQS) DeleteMessageBatch(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+	for _, entry := range input.Entries {
+		f.deleted <- aws.StringValue(entry.ReceiptHandle)
+	}
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
+
+// TestQueueRegion checks that the region is taken from both forms of SQS queue URLs and that other URLs have none.
+func TestQueueRegion(t *testing.T) {
+	testCases := map[string]string{
+		"https://sqs.us-west-2.amazonaws.com/123456789012/events":   "us-west-2",
+		"https://eu-west-1.queue.amazonaws.com/123456789012/events": "eu-west-1",
+		"http://localhost:4566/000000000000/events":                 "",
+		"not a url\x7f": "",
+	}
+	for queueURL, want := range testCases {
+		if got := queueRegion(queueURL); got != want {
+			t.Errorf("queueRegion(%q) = %q, want %q", queueURL, got, want)
+		}
+	}
+}
+
+// TestWatchInstanceStateEvents checks that state-change notifications are recorded and every received message,
+// including unrelated ones, is deleted from the queue.
+func TestWatchInstanceStateEvents(t *testing.T) {
+	fake := &fakeSQS{
+		messages: []*sqs.Message{
+			{Body: aws.String(stateChangeMessage), ReceiptHandle: aws.String("r-1")},
+			{Body: aws.String(`{"detail-type": "Other"}`), ReceiptHandle: aws.String("r-2")},
+		},
+		deleted: make(chan string, 2),
+	}
+	events := NewInstanceEvents()
+	stop := make(chan struct{})
+	defer close(stop)
+	go WatchInstanceStateEvents(fake, "https://sqs.us-east-1.amazonaws.com/123456789012/benchmark", events, stop)
+
+	for _, want := range []string{"r-1", "r-2"} {
+		select {
+		case got := <-fake.deleted:
+			if got != want {
+				t.Errorf("WatchInstanceStateEvents() deleted %s, want %s", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("WatchInstanceStateEvents() deleted no message")
+		}
+	}
+	if at, ok := events.StateTime("i-0abc", "pending"); !ok || !at.Equal(time.Date(2024, 4, 1, 12, 0, 5, 0, time.UTC)) {
+		t.Errorf("StateTime() = %v, %v, want the event's time", at, ok)
+	}
+}
+
+// TestInstanceEvents checks that the earliest event per instance and state is kept, that the last state time needs an
+// event of every instance, that old instances are forgotten and that a nil InstanceEvents has no events.
+func TestInstanceEvents(t *testing.T) {
+	at := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	events := NewInstanceEvents()
+	events.record(InstanceStateEvent{InstanceID: "i-1", State: "terminated", Time: at.Add(5 * time.Second)})
+	events.record(InstanceStateEvent{InstanceID: "i-1", State: "terminated", Time: at.Add(3 * time.Second)})
+	events.record(InstanceStateEvent{InstanceID: "i-2", State: "terminated", Time: at.Add(8 * time.Second)})
+	events.record(InstanceStateEvent{InstanceID: "i-3", State: "shutting-down", Time: at})
+
+	if got, ok := events.StateTime("i-1", "terminated"); !ok || !got.Equal(at.Add(3*time.Second)) {
+		t.Errorf("StateTime() = %v, %v, want the earliest event", got, ok)
+	}
+	if got, ok := events.LastStateTime([]string{"i-1", "i-2"}, "terminated"); !ok || !got.Equal(at.Add(8*time.Second)) {
+		t.Errorf("LastStateTime() = %v, %v, want the event of i-2", got, ok)
+	}
+	if _, ok := events.LastStateTime([]string{"i-1", "i-3"}, "terminated"); ok {
+		t.Errorf("LastStateTime() without an event of i-3 reported a time")
+	}
+	if _, ok := events.LastStateTime(nil, "terminated"); ok {
+		t.Errorf("LastStateTime() without instances reported a time")
+	}
+
+	events.record(InstanceStateEvent{InstanceID: "i-4", State: "pending", Time: at.Add(instanceEventRetention + time.Hour)})
+	if _, ok := events.StateTime("i-1", "terminated"); ok {
+		t.Errorf("StateTime() of an instance without events in the retention still reported a time")
+	}
+
+	var none *InstanceEvents
+	if _, ok := none.StateTime("i-1", "terminated"); ok {
+		t.Errorf("StateTime() of a nil InstanceEvents reported a time")
+	}
+}
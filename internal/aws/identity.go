@@ -0,0 +1,28 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+// GetAccountID returns the ID of the AWS account the credentials of the session belong to, using STS GetCallerIdentity.
+func GetAccountID(stsSvc stsiface.STSAPI) (string, error) {
+	identity, err := stsSvc.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("Failed to get AWS caller identity: %w", err)
+	}
+	return aws.StringValue(identity.Account), nil
+}
+
+// GetRegion returns the region the EC2 client's session resolved to.
+func GetRegion(ec2Svc *ec2.EC2) string {
+	return aws.StringValue(ec2Svc.Config.Region)
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+// fakeSTS returns a fixed caller identity.
+type fakeSTS struct {
+	stsiface.STSAPI
+	account string
+}
+
+// GetCallerIdentity returns the configured account.
+func (f *fakeSTS) GetCallerIdentity(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{Account: aws.String(f.account)}, nil
+}
+
+// TestGetAccountID checks that the account of the caller identity is returned.
+func TestGetAccountID(t *testing.T) {
+	accountID, err := GetAccountID(&fakeSTS{account: "123456789012"})
+	if err != nil {
+		t.Fatalf("GetAccountID() returned error: %v", err)
+	}
+	if accountID != "123456789012" {
+		t.Errorf("GetAccountID() = %q, want 123456789012", accountID)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package aws
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// nodeClaimProvisioningPollInterval is how often MonitorNodeClaimProvisioning lists the NodeClaims.
+var nodeClaimProvisioningPollInterval = 5 * time.Second
+
+// MonitorNodeClaimProvisioning tracks the provisioning of a Karpenter node pool through its NodeClaims instead of the
+// EC2 tags, so it does not depend on how a Karpenter version tags the instances it launches. The NodeClaims are listed
+// from gvr with the node pool label selector, and only those created after since (the start of the benchmark run) are
+// considered. Once enough of them carry the ID of their instance in status.providerID (see launchedEnough for the
+// meaning of expectedInstances), the instances are described by ID in the regions of the given clients and the time
+// until the last expected one launched is returned along with them. When firstInstanceTimeout is positive and no
+// NodeClaim launched an instance within it, it fails fast with an error wrapping bench.ErrProvisioningTimeout. Each
+// time provisioning exceeds timeout, it prompts whether to keep waiting like MonitorInstanceProvisioning, or fails with
+// an error wrapping bench.ErrProvisioningTimeout when prompt is nil. When ctx is cancelled, e.g. on SIGINT, it stops
+// polling and returns ctx.Err().
+func MonitorNodeClaimProvisioning(ctx context.Context, client dynamic.Interface, gvr schema.GroupVersionResource, ec2Svcs []*ec2.EC2, nodePoolSelector string, since time.Time, expectedInstances int, firstInstanceTimeout, timeout time.Duration, prompt io.Reader) (time.Duration, []*ec2.Instance, error) {
+	logging.Progress("Monitoring EC2 instance provisioning through Karpenter NodeClaims")
+	startTime := time.Now()
+	monitorStart := startTime
+	var reader *bufio.Reader
+	if prompt != nil {
+		reader = bufio.NewReader(prompt)
+	}
+	previousPoll := startTime
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return time.Since(startTime), nil, err
+		}
+		pollTime := time.Now()
+		list, err := client.Resource(gvr).List(ctx, metav1.ListOptions{LabelSelector: nodePoolSelector})
+		if err != nil {
+			return time.Since(startTime), nil, fmt.Errorf("Failed to list NodeClaims: %w", err)
+		}
+		ids := nodeClaimInstanceIDs(list.Items, since)
+
+		if len(ids) > 0 && len(ids) >= expectedInstances {
+			instances, err := describeInstancesByID(ctx, ec2Svcs, ids)
+			if err != nil {
+				return time.Since(startTime), nil, err
+			}
+			if launchedEnough(instances, expectedInstances) {
+				var launchTimes []time.Time
+				for _, instance := range instances {
+					launchTimes = append(launchTimes, aws.TimeValue(instance.LaunchTime))
+				}
+				logging.Progress("Instances launched", "instances", strings.Join(InstanceIDs(instances), ", "))
+				launched := bench.TransitionTime(previousPoll, pollTime, bench.NthEarliest(launchTimes, max(expectedInstances, 1)))
+				return launched.Sub(startTime), instances, nil
+			}
+		}
+
+		if len(ids) == 0 && firstInstanceTimeout > 0 && time.Since(monitorStart) >= firstInstanceTimeout {
+			return time.Since(startTime), nil, fmt.Errorf("No NodeClaim launched an instance within the first instance timeout of %v, which usually means a misconfiguration such as a wrong node pool or unschedulable pods: %w", firstInstanceTimeout, bench.ErrProvisioningTimeout)
+		}
+		if time.Since(startTime) >= timeout {
+			if reader == nil {
+				return time.Since(startTime), nil, fmt.Errorf("Only %d NodeClaims launched an instance within the provisioning timeout of %v, not prompting in non-interactive mode: %w", len(ids), timeout, bench.ErrProvisioningTimeout)
+			}
+			if err := askToKeepWaiting(ctx, reader); err != nil {
+				return time.Since(startTime), nil, err
+			}
+			startTime = time.Now()
+			pollTime = startTime
+		}
+		previousPoll = pollTime
+		select {
+		case <-ctx.Done():
+		case <-time.After(nodeClaimProvisioningPollInterval):
+		}
+	}
+}
+
+// nodeClaimInstanceIDs returns the EC2 instance IDs of the NodeClaims created after since that already launched an
+// instance, taken from their provider ID, e.g. "aws:///us-east-1a/i-0123456789abcdef0".
+func nodeClaimInstanceIDs(nodeClaims []unstructured.Unstructured, since time.Time) []string {
+	var ids []string
+	for _, nodeClaim := range nodeClaims {
+		if nodeClaim.GetCreationTimestamp().Time.Before(since.Truncate(time.Second)) {
+			continue
+		}
+		providerID, _, _ := unstructured.NestedString(nodeClaim.Object, "status", "providerID")
+		if id := providerID[strings.LastIndex(providerID, "/")+1:]; strings.HasPrefix(id, "i-") {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// describeInstancesByID returns the given instances from the regions of the given clients. They are filtered by ID
+// rather than requested by ID, so that an instance living in another region, or one that EC2 does not know yet as its
+// API is eventually consistent, is simply missing from the result and the caller polls again.
+func describeInstancesByID(ctx context.Context, ec2Svcs []*ec2.EC2, instanceIDs []string) ([]*ec2.Instance, error) {
+	var instances []*ec2.Instance
+	input := &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("instance-id"), Values: aws.StringSlice(instanceIDs)}},
+	}
+	for _, ec2Svc := range ec2Svcs {
+		err := ec2Svc.DescribeInstancesPagesWithContext(ctx, input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, reservation := range page.Reservations {
+				instances = append(instances, reservation.Instances...)
+			}
+			return !lastPage
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to describe instances in %s: %w", aws.StringValue(ec2Svc.Config.Region), err)
+		}
+	}
+	return instances, nil
+}
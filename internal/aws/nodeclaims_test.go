@@ -0,0 +1,115 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package aws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// nodeClaimGVR is the Karpenter v1 NodeClaim resource the tests list.
+var nodeClaimGVR = schema.GroupVersionResource{Group: "karpenter.sh", Version: "v1", Resource: "nodeclaims"}
+
+// testNodeClaim returns a v1 NodeClaim of the default node pool created at created, with the given provider ID unless
+// it is empty.
+func testNodeClaim(name string, created time.Time, providerID string) *unstructured.Unstructured {
+	nodeClaim := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "karpenter.sh/v1",
+		"kind":       "NodeClaim",
+		"metadata": map[string]interface{}{
+			"name":              name,
+			"creationTimestamp": created.Format(time.RFC3339),
+			"labels":            map[string]interface{}{"karpenter.sh/nodepool": "default"},
+		},
+	}}
+	if providerID != "" {
+		unstructured.SetNestedField(nodeClaim.Object, providerID, "status", "providerID")
+	}
+	return nodeClaim
+}
+
+// TestNodeClaimInstanceIDs checks that the instance IDs are derived from the provider IDs of the NodeClaims created
+// since the start, skipping those that have not launched an instance yet.
+func TestNodeClaimInstanceIDs(t *testing.T) {
+	since := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	nodeClaims := []unstructured.Unstructured{
+		*testNodeClaim("default-a", since.Add(time.Second), "aws:///us-east-1a/i-new"),
+		*testNodeClaim("default-b", since.Add(time.Second), ""),
+		*testNodeClaim("default-old", since.Add(-time.Hour), "aws:///us-east-1a/i-old"),
+	}
+	if ids := nodeClaimInstanceIDs(nodeClaims, since); !reflect.DeepEqual(ids, []string{"i-new"}) {
+		t.Errorf("nodeClaimInstanceIDs() = %v, want [i-new]", ids)
+	}
+}
+
+// TestMonitorNodeClaimProvisioning checks that the launched instances are looked up by the IDs of the run's
+// NodeClaims, that a node pool whose NodeClaims never launch fails fast, that answering no at the timeout prompt
+// fails, and that a cancelled context stops it.
+func TestMonitorNodeClaimProvisioning(t *testing.T) {
+	defer func(interval time.Duration) { nodeClaimProvisioningPollInterval = interval }(nodeClaimProvisioningPollInterval)
+	nodeClaimProvisioningPollInterval = time.Millisecond
+
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requested = append(requested, r.PostForm.Get("Filter.1.Value.1"), r.PostForm.Get("Filter.1.Value.2"))
+		w.Write([]byte(describeInstancesResponse))
+	}))
+	defer server.Close()
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	ec2Svcs := []*ec2.EC2{ec2.New(sess)}
+
+	since := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	gvrs := map[schema.GroupVersionResource]string{nodeClaimGVR: "NodeClaimList"}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrs,
+		testNodeClaim("default-a", since.Add(time.Second), "aws:///us-east-1a/i-old"),
+		testNodeClaim("default-b", since.Add(2*time.Second), "aws:///us-east-1a/i-new"))
+	_, instances, err := MonitorNodeClaimProvisioning(context.Background(), client, nodeClaimGVR, ec2Svcs, "karpenter.sh/nodepool=default", since, 2, 0, time.Minute, nil)
+	if err != nil {
+		t.Fatalf("MonitorNodeClaimProvisioning() returned error: %v", err)
+	}
+	if len(instances) != 2 || !reflect.DeepEqual(requested, []string{"i-old", "i-new"}) {
+		t.Errorf("MonitorNodeClaimProvisioning() = %d instances after describing %v, want 2 after describing [i-old i-new]", len(instances), requested)
+	}
+
+	client = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrs, testNodeClaim("default-c", since.Add(time.Second), ""))
+	_, _, err = MonitorNodeClaimProvisioning(context.Background(), client, nodeClaimGVR, ec2Svcs, "karpenter.sh/nodepool=default", since, 0, 10*time.Millisecond, time.Minute, nil)
+	if !errors.Is(err, bench.ErrProvisioningTimeout) {
+		t.Errorf("MonitorNodeClaimProvisioning() without launched NodeClaims returned %v, want ErrProvisioningTimeout", err)
+	}
+
+	_, _, err = MonitorNodeClaimProvisioning(context.Background(), client, nodeClaimGVR, ec2Svcs, "karpenter.sh/nodepool=default", since, 0, 0, 10*time.Millisecond, strings.NewReader("maybe\nno\n"))
+	if !errors.Is(err, bench.ErrProvisioningTimeout) {
+		t.Errorf("MonitorNodeClaimProvisioning() answered no at the timeout prompt returned %v, want ErrProvisioningTimeout", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = MonitorNodeClaimProvisioning(ctx, client, nodeClaimGVR, ec2Svcs, "karpenter.sh/nodepool=default", since, 0, 0, time.Minute, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("MonitorNodeClaimProvisioning() with a cancelled context returned %v, want context.Canceled", err)
+	}
+}
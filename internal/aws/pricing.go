@@ -0,0 +1,118 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package aws
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// defaultPriceRegion is the region whose prices defaultHourlyPrices holds.
+const defaultPriceRegion = "us-east-1"
+
+// defaultHourlyPrices are approximate us-east-1 on-demand Linux prices in USD per hour of common instance types, for
+// a rough estimate without pricing permissions. They only price on-demand instances in us-east-1, and can be
+// overridden and extended with --pricing-map.
+var defaultHourlyPrices = map[string]float64{
+	"t3.micro": 0.0104, "t3.small": 0.0208, "t3.medium": 0.0416, "t3.large": 0.0832, "t3.xlarge": 0.1664,
+	"m5.large": 0.096, "m5.xlarge": 0.192, "m5.2xlarge": 0.384,
+	"m6i.large": 0.096, "m6i.xlarge": 0.192, "m6i.2xlarge": 0.384,
+	"m7i.large": 0.1008, "m7i.xlarge": 0.2016, "m7i.2xlarge": 0.4032,
+	"m6g.large": 0.077, "m6g.xlarge": 0.154, "m7g.large": 0.0816, "m7g.xlarge": 0.1632,
+	"c5.large": 0.085, "c5.xlarge": 0.17, "c5.2xlarge": 0.34,
+	"c6i.large": 0.085, "c6i.xlarge": 0.17, "c6i.2xlarge": 0.34,
+	"c7i.large": 0.08925, "c7i.xlarge": 0.1785, "c7i.2xlarge": 0.357,
+	"c6g.large": 0.068, "c6g.xlarge": 0.136, "c7g.large": 0.0725, "c7g.xlarge": 0.145,
+	"r5.large": 0.126, "r5.xlarge": 0.252, "r6i.large": 0.126, "r6i.xlarge": 0.252, "r7i.large": 0.1323, "r7i.xlarge": 0.2646,
+}
+
+// PriceMap maps instance types to their price in USD per hour. A key is an instance type, optionally prefixed with a
+// region and suffixed with ":spot" for spot instances, e.g. "c7i.large", "eu-west-1/c7i.large" or
+// "eu-west-1/c7i.large:spot". See priceKeys for how an instance's price is looked up.
+type PriceMap map[string]float64
+
+// LoadPriceMap returns the default prices overridden and extended by the prices of the YAML or JSON file at path,
+// which maps price keys to USD per hour, e.g. "c7i.large: 0.0893". An empty path returns the default prices.
+func LoadPriceMap(path string) (PriceMap, error) {
+	prices := PriceMap{}
+	for instanceType, price := range defaultHourlyPrices {
+		prices[defaultPriceRegion+"/"+instanceType] = price
+	}
+	if path == "" {
+		return prices, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read pricing map: %w", err)
+	}
+	var overrides PriceMap
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("Failed to parse pricing map %s: %w", path, err)
+	}
+	for instanceType, price := range overrides {
+		if price < 0 {
+			return nil, fmt.Errorf("Price of %s in pricing map %s must not be negative, got %g", instanceType, path, price)
+		}
+		// An entry without a region replaces the built-in price too, which would otherwise take precedence in us-east-1.
+		if !strings.Contains(instanceType, "/") {
+			delete(prices, defaultPriceRegion+"/"+instanceType)
+		}
+		prices[instanceType] = price
+	}
+	return prices, nil
+}
+
+// priceKeys returns the keys a launch's price is looked up by, most specific first: its region and instance type,
+// then its instance type alone, both suffixed with ":spot" for a spot instance. So the built-in us-east-1 on-demand
+// prices never price spot instances or other regions, while a --pricing-map entry without a region applies to all.
+func priceKeys(launch bench.InstanceLaunch) []string {
+	key := launch.InstanceType
+	if launch.Spot {
+		key += ":spot"
+	}
+	if launch.Region == "" {
+		return []string{key}
+	}
+	return []string{launch.Region + "/" + key, key}
+}
+
+// EstimateCost returns the cost in USD of the launched instances when each was alive from its launch until end, both
+// relative to the start of the run. The most specific price keys of the instances without a price are returned
+// sorted and do not count towards the estimate.
+func (p PriceMap) EstimateCost(launches []bench.InstanceLaunch, end time.Duration) (float64, []string) {
+	cost := 0.0
+	unpriced := map[string]bool{}
+	for _, launch := range launches {
+		keys := priceKeys(launch)
+		price, ok := 0.0, false
+		for _, key := range keys {
+			if price, ok = p[key]; ok {
+				break
+			}
+		}
+		if !ok {
+			unpriced[keys[0]] = true
+			continue
+		}
+		if alive := end - launch.ProvisioningTime; alive > 0 {
+			cost += price * alive.Hours()
+		}
+	}
+
+	missing := make([]string, 0, len(unpriced))
+	for key := range unpriced {
+		missing = append(missing, key)
+	}
+	sort.Strings(missing)
+	return cost, missing
+}
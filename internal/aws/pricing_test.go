@@ -0,0 +1,73 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package aws
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// TestEstimateCost checks that each instance is charged from its launch until the end at the price of its region and
+// lifecycle, and that instances without a price are reported by their most specific key instead of counted.
+func TestEstimateCost(t *testing.T) {
+	prices := PriceMap{"us-east-1/c7i.large": 0.09, "m7i.xlarge": 0.2, "eu-west-1/c7i.large:spot": 0.04}
+	launches := []bench.InstanceLaunch{
+		{InstanceID: "i-1", InstanceType: "c7i.large", Region: "us-east-1", ProvisioningTime: 0},
+		{InstanceID: "i-2", InstanceType: "m7i.xlarge", Region: "eu-west-1", ProvisioningTime: 30 * time.Minute},
+		{InstanceID: "i-3", InstanceType: "x2idn.metal", Region: "us-east-1", ProvisioningTime: 0},
+		{InstanceID: "i-4", InstanceType: "c7i.large", Region: "us-east-1", ProvisioningTime: 2 * time.Hour},
+		{InstanceID: "i-5", InstanceType: "c7i.large", Region: "eu-west-1", Spot: true, ProvisioningTime: 0},
+		{InstanceID: "i-6", InstanceType: "c7i.large", Region: "us-east-1", Spot: true, ProvisioningTime: 0},
+		{InstanceID: "i-7", InstanceType: "c7i.large", Region: "eu-west-1", ProvisioningTime: 0},
+	}
+
+	cost, missing := prices.EstimateCost(launches, time.Hour)
+	if want := 0.09 + 0.2/2 + 0.04; math.Abs(cost-want) > 1e-9 {
+		t.Errorf("EstimateCost() = %g, want %g", cost, want)
+	}
+	if want := []string{"eu-west-1/c7i.large", "us-east-1/c7i.large:spot", "us-east-1/x2idn.metal"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("EstimateCost() missing prices = %v, want %v", missing, want)
+	}
+}
+
+// TestLoadPriceMap checks that a pricing map overrides and extends the default prices, also in us-east-1 for entries
+// without a region, and that invalid maps are rejected.
+func TestLoadPriceMap(t *testing.T) {
+	defaults, err := LoadPriceMap("")
+	if err != nil || defaults["us-east-1/c7i.large"] == 0 || defaults["c7i.large"] != 0 {
+		t.Fatalf("LoadPriceMap(\"\") = %v, %v, want the default prices keyed by us-east-1", defaults, err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prices.yaml")
+	if err := os.WriteFile(path, []byte("c7i.large: 0.05\ng5.xlarge: 1.006\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prices, err := LoadPriceMap(path)
+	if err != nil {
+		t.Fatalf("LoadPriceMap() returned error: %v", err)
+	}
+	if prices["c7i.large"] != 0.05 || prices["g5.xlarge"] != 1.006 || prices["us-east-1/m7i.large"] != defaults["us-east-1/m7i.large"] {
+		t.Errorf("LoadPriceMap() = %v, want the overrides on top of the defaults", prices)
+	}
+	if cost, _ := prices.EstimateCost([]bench.InstanceLaunch{{InstanceType: "c7i.large", Region: "us-east-1"}}, time.Hour); cost != 0.05 {
+		t.Errorf("EstimateCost() of an overridden us-east-1 instance = %g, want 0.05", cost)
+	}
+
+	negative := filepath.Join(dir, "negative.yaml")
+	if err := os.WriteFile(negative, []byte("c7i.large: -1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{negative, filepath.Join(dir, "missing.yaml")} {
+		if _, err := LoadPriceMap(path); err == nil {
+			t.Errorf("LoadPriceMap(%s) returned nil error", path)
+		}
+	}
+}
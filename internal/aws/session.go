@@ -0,0 +1,30 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// NewSession creates an AWS session for the given shared config profile. When endpoint is not empty, every client
+// created from the session, including the Auto Scaling and SQS clients derived from the EC2 client, sends its
+// requests to it instead of the AWS endpoints, e.g. to LocalStack.
+func NewSession(profile, endpoint string) (*session.Session, error) {
+	opts := session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+		Profile:           profile,
+	}
+	if endpoint != "" {
+		opts.Config.Endpoint = aws.String(endpoint)
+	}
+	sess, err := session.NewSessionWithOptions(opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create AWS session: %w", err)
+	}
+	return sess, nil
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// TestNewSessionEndpoint checks that an endpoint override reaches the EC2 client and the clients derived from it, and
+// that the AWS endpoints are used without an override.
+func TestNewSessionEndpoint(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	sess, err := NewSession("", "http://localhost:4566")
+	if err != nil {
+		t.Fatalf("NewSession() returned error: %v", err)
+	}
+	ec2Svc := ec2.New(sess)
+	if ec2Svc.Endpoint != "http://localhost:4566" {
+		t.Errorf("EC2 endpoint = %q, want the override", ec2Svc.Endpoint)
+	}
+	asgSvc, err := NewAutoScaling(ec2Svc)
+	if err != nil {
+		t.Fatalf("NewAutoScaling() returned error: %v", err)
+	}
+	if asgSvc.Endpoint != "http://localhost:4566" {
+		t.Errorf("Auto Scaling endpoint = %q, want the override", asgSvc.Endpoint)
+	}
+
+	sess, err = NewSession("", "")
+	if err != nil {
+		t.Fatalf("NewSession() returned error: %v", err)
+	}
+	if endpoint := ec2.New(sess).Endpoint; endpoint != "https://ec2.us-east-1.amazonaws.com" {
+		t.Errorf("EC2 endpoint without an override = %q, want the AWS endpoint", endpoint)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// instanceStateTimeout bounds how long TrackInstanceStates samples the instance states.
+const instanceStateTimeout = 10 * time.Minute
+
+// stateTracker records the state each instance is in and since when, and the time every instance spent in each state
+// it left.
+type stateTracker struct {
+	current   map[string]string
+	since     map[string]time.Time
+	durations map[string][]time.Duration
+}
+
+// newStateTracker starts tracking the given instances from their current state. Pending instances are considered
+// pending since their launch time.
+func newStateTracker(instances []*ec2.Instance, now time.Time) *stateTracker {
+	tracker := &stateTracker{
+		current:   make(map[string]string, len(instances)),
+		since:     make(map[string]time.Time, len(instances)),
+		durations: map[string][]time.Duration{},
+	}
+	for _, instance := range instances {
+		id := aws.StringValue(instance.InstanceId)
+		tracker.current[id] = instanceState(instance)
+		tracker.since[id] = now
+		if tracker.current[id] == ec2.InstanceStateNamePending && instance.LaunchTime != nil {
+			tracker.since[id] = aws.TimeValue(instance.LaunchTime)
+		}
+	}
+	return tracker
+}
+
+// observe records the states of the instances sampled at pollTime. An instance that changed state is counted as having
+// left its previous state at pollTime. It reports whether no tracked instance is pending anymore.
+func (t *stateTracker) observe(instances []*ec2.Instance, pollTime time.Time) bool {
+	for _, instance := range instances {
+		id := aws.StringValue(instance.InstanceId)
+		previous, ok := t.current[id]
+		state := instanceState(instance)
+		if !ok || state == previous {
+			continue
+		}
+		t.durations[previous] = append(t.durations[previous], pollTime.Sub(t.since[id]))
+		t.current[id] = state
+		t.since[id] = pollTime
+	}
+	for _, state := range t.current {
+		if state == ec2.InstanceStateNamePending {
+			return false
+		}
+	}
+	return true
+}
+
+// instanceState returns the name of the instance's state.
+func instanceState(instance *ec2.Instance) string {
+	if instance.State == nil {
+		return ""
+	}
+	return aws.StringValue(instance.State.Name)
+}
+
+// TrackInstanceStates samples the states of the given instances every second until none of them is pending anymore,
+// and returns the time each instance spent in each state it left, keyed by state name, e.g. the time from launch to
+// running under "pending". This separates EC2 capacity delays (time in pending) from the node's boot. On a sampling
+// error or timeout the durations recorded so far are returned along with the error.
+func TrackInstanceStates(ec2Svc *ec2.EC2, instances []*ec2.Instance) (map[string][]time.Duration, error) {
+	startTime := time.Now()
+	tracker := newStateTracker(instances, startTime)
+	input := &ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice(InstanceIDs(instances))}
+
+	for {
+		pollTime := time.Now()
+		var current []*ec2.Instance
+		err := ec2Svc.DescribeInstancesPagesWithContext(aws.BackgroundContext(), input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, reservation := range page.Reservations {
+				current = append(current, reservation.Instances...)
+			}
+			return !lastPage
+		})
+		if err != nil {
+			return tracker.durations, fmt.Errorf("Failed to describe instances: %w", err)
+		}
+		if tracker.observe(current, pollTime) {
+			return tracker.durations, nil
+		}
+		if time.Since(startTime) >= instanceStateTimeout {
+			return tracker.durations, fmt.Errorf("Timed out waiting for the instances to leave the pending state")
+		}
+		time.Sleep(1 * time.Second)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package aws
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// stateInstance returns an instance with the given ID, state and launch time.
+func stateInstance(id, state string, launchTime time.Time) *ec2.Instance {
+	return &ec2.Instance{
+		InstanceId: aws.String(id),
+		State:      &ec2.InstanceState{Name: aws.String(state)},
+		LaunchTime: aws.Time(launchTime),
+	}
+}
+
+// TestStateTracker checks that the time in pending is measured from each instance's launch to the poll observing it
+// running, and that tracking completes only once no instance is pending.
+func TestStateTracker(t *testing.T) {
+	launch := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newStateTracker([]*ec2.Instance{
+		stateInstance("i-1", ec2.InstanceStateNamePending, launch),
+		stateInstance("i-2", ec2.InstanceStateNamePending, launch.Add(2*time.Second)),
+	}, launch.Add(3*time.Second))
+
+	if tracker.observe([]*ec2.Instance{
+		stateInstance("i-1", ec2.InstanceStateNameRunning, launch),
+		stateInstance("i-2", ec2.InstanceStateNamePending, launch.Add(2*time.Second)),
+	}, launch.Add(10*time.Second)) {
+		t.Errorf("observe() = true while i-2 is still pending")
+	}
+	if !tracker.observe([]*ec2.Instance{
+		stateInstance("i-1", ec2.InstanceStateNameRunning, launch),
+		stateInstance("i-2", ec2.InstanceStateNameRunning, launch.Add(2*time.Second)),
+		stateInstance("i-3", ec2.InstanceStateNamePending, launch),
+	}, launch.Add(14*time.Second)) {
+		t.Errorf("observe() = false after every tracked instance is running")
+	}
+
+	want := map[string][]time.Duration{ec2.InstanceStateNamePending: {10 * time.Second, 12 * time.Second}}
+	if !reflect.DeepEqual(tracker.durations, want) {
+		t.Errorf("durations = %v, want %v", tracker.durations, want)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package aws
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// instanceTrackInterval is the interval at which InstanceTracker lists the instances. pendingTrackInterval is the
+// shorter one used while an instance is pending, so the time it started running is known to about a second.
+var (
+	instanceTrackInterval = 10 * time.Second
+	pendingTrackInterval  = 1 * time.Second
+)
+
+// InstanceTracker lists the instances carrying a tag in the background and remembers every instance it saw, so that
+// instances launched and terminated between two checks of the scale-up are not missed, and when each instance it saw
+// pending was first seen running.
+type InstanceTracker struct {
+	mu       sync.Mutex
+	seen     []*ec2.Instance
+	seenIDs  map[string]bool
+	states   map[string]string
+	running  map[string]time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// StartInstanceTracker starts tracking the instances launched after since that carry the tag in the regions of the
+// given clients, starting with the initial instances, e.g. those the provisioning monitor returned. Failed listings
+// are skipped.
+func StartInstanceTracker(ec2Svcs []*ec2.EC2, tagKey, tagValue string, since time.Time, initial []*ec2.Instance) *InstanceTracker {
+	tracker := &InstanceTracker{
+		seenIDs: map[string]bool{},
+		states:  map[string]string{},
+		running: map[string]time.Time{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	tracker.add(initial, time.Now())
+	go func() {
+		defer close(tracker.done)
+		for {
+			interval := instanceTrackInterval
+			if tracker.anyPending() {
+				interval = pendingTrackInterval
+			}
+			select {
+			case <-tracker.stop:
+				return
+			case <-time.After(interval):
+			}
+			pollTime := time.Now()
+			if instances, err := GetEC2Instances(ec2Svcs, "tag:"+tagKey, tagValue, since); err == nil {
+				tracker.add(instances, pollTime)
+			}
+		}
+	}()
+	return tracker
+}
+
+// add remembers the instances that were not seen before and the states of all of them as listed at pollTime. An
+// instance seen pending before and running now is taken to have started running at pollTime.
+func (t *InstanceTracker) add(instances []*ec2.Instance, pollTime time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, instance := range instances {
+		id := aws.StringValue(instance.InstanceId)
+		if !t.seenIDs[id] {
+			t.seenIDs[id] = true
+			t.seen = append(t.seen, instance)
+		}
+		state := instanceState(instance)
+		if state == ec2.InstanceStateNameRunning && t.states[id] == ec2.InstanceStateNamePending {
+			t.running[id] = pollTime
+		}
+		t.states[id] = state
+	}
+}
+
+// anyPending reports whether an instance was pending when last seen.
+func (t *InstanceTracker) anyPending() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, state := range t.states {
+		if state == ec2.InstanceStateNamePending {
+			return true
+		}
+	}
+	return false
+}
+
+// Seen returns every instance seen so far, in the order they were first seen, along with the current instances, which
+// are seen too. The tracking goes on.
+func (t *InstanceTracker) Seen(current []*ec2.Instance) []*ec2.Instance {
+	t.add(current, time.Now())
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*ec2.Instance(nil), t.seen...)
+}
+
+// Stop stops the tracking and returns every instance seen, like Seen. It is safe to call more than once.
+func (t *InstanceTracker) Stop(current []*ec2.Instance) []*ec2.Instance {
+	t.stopOnce.Do(func() { close(t.stop) })
+	<-t.done
+	return t.Seen(current)
+}
+
+// RunningTimes maps the ID of each instance seen pending and then running to the time it was first seen running, so
+// the EC2 boot up to the running state can be told apart from what follows. Instances first seen already running are
+// left out, as the time they started running is unknown.
+func (t *InstanceTracker) RunningTimes() map[string]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	running := make(map[string]time.Time, len(t.running))
+	for id, at := range t.running {
+		running[id] = at
+	}
+	return running
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package aws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// TestInstanceTracker checks that the tracker remembers the initial instances, those listed in the background and the
+// current ones passed to Stop, each once, that Seen and Stop are safe to repeat, and that only instances seen pending before
+// get a running time.
+func TestInstanceTracker(t *testing.T) {
+	defer func(interval, pending time.Duration) {
+		instanceTrackInterval, pendingTrackInterval = interval, pending
+	}(instanceTrackInterval, pendingTrackInterval)
+	instanceTrackInterval, pendingTrackInterval = 10*time.Millisecond, 10*time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(describeInstancesResponse))
+	}))
+	defer server.Close()
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+
+	since := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	tracker := StartInstanceTracker([]*ec2.EC2{ec2.New(sess)}, "karpenter.sh/nodepool", "default", since, []*ec2.Instance{{InstanceId: aws.String("i-gone")}})
+	time.Sleep(50 * time.Millisecond)
+
+	if seen := InstanceIDs(tracker.Seen(nil)); !reflect.DeepEqual(seen, []string{"i-gone", "i-new"}) {
+		t.Errorf("Seen() = %v, want [i-gone i-new]", seen)
+	}
+	running := &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}
+	current := []*ec2.Instance{{InstanceId: aws.String("i-new"), State: running}, {InstanceId: aws.String("i-current"), State: running}}
+	stopTime := time.Now()
+	if seen := InstanceIDs(tracker.Stop(current)); !reflect.DeepEqual(seen, []string{"i-gone", "i-new", "i-current"}) {
+		t.Errorf("Stop() = %v, want [i-gone i-new i-current]", seen)
+	}
+	if runningTimes := tracker.RunningTimes(); len(runningTimes) != 1 || runningTimes["i-new"].Before(stopTime) {
+		t.Errorf("RunningTimes() = %v, want only i-new, running since Stop", runningTimes)
+	}
+	if seen := InstanceIDs(tracker.Stop(nil)); len(seen) != 3 {
+		t.Errorf("second Stop() = %v, want the same 3 instances", seen)
+	}
+}
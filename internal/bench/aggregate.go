@@ -0,0 +1,112 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// PhaseStats summarizes the durations of one phase across the iterations of a benchmark.
+type PhaseStats struct {
+	// Phase is the phase name from PhaseNames.
+	Phase string
+	// Samples is the number of iterations the statistics were computed from.
+	Samples int
+	Min     time.Duration
+	Max     time.Duration
+	Mean    time.Duration
+	Median  time.Duration
+	P95     time.Duration
+	// StdDev is the population standard deviation.
+	StdDev time.Duration
+}
+
+// AggregatePhases computes the statistics of every phase in PhaseNames over the given results, which are expected to
+// be iterations of the same benchmark. Phases that were not measured in any iteration, e.g. instance provisioning for
+// Fargate, are left out. It returns nil when there are no results.
+func AggregatePhases(results []*BenchmarkResult) []PhaseStats {
+	if len(results) == 0 {
+		return nil
+	}
+	var stats []PhaseStats
+	for _, phase := range PhaseNames {
+		durations := make([]time.Duration, 0, len(results))
+		measured := false
+		for _, result := range results {
+			duration := result.PhaseDuration(phase)
+			measured = measured || duration > 0
+			durations = append(durations, duration)
+		}
+		if !measured {
+			continue
+		}
+		stats = append(stats, phaseStats(phase, durations))
+	}
+	return stats
+}
+
+// phaseStats computes the statistics of a non-empty set of durations of one phase.
+func phaseStats(phase string, durations []time.Duration) PhaseStats {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum float64
+	for _, d := range sorted {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(sorted))
+	var squares float64
+	for _, d := range sorted {
+		squares += (float64(d) - mean) * (float64(d) - mean)
+	}
+
+	return PhaseStats{
+		Phase:   phase,
+		Samples: len(sorted),
+		Min:     sorted[0],
+		Max:     sorted[len(sorted)-1],
+		Mean:    time.Duration(mean),
+		Median:  Percentile(sorted, 50),
+		P95:     Percentile(sorted, 95),
+		StdDev:  time.Duration(math.Sqrt(squares / float64(len(sorted)))),
+	}
+}
+
+// Percentile returns the p-th percentile (0-100) of the durations, which must be sorted in ascending order, linearly
+// interpolating between the two closest ranks. It returns 0 for an empty slice.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower < 0 {
+		return sorted[0]
+	}
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + time.Duration(math.Round((rank-float64(lower))*float64(sorted[upper]-sorted[lower])))
+}
+
+// ScaleUpStability returns the mean and the coefficient of variation, i.e. the population standard deviation divided
+// by the mean, of the total scale-up times of the last window results, which are expected to be runs of the same
+// benchmark. ok is false when there are fewer than window results.
+func ScaleUpStability(results []*BenchmarkResult, window int) (mean time.Duration, cv float64, ok bool) {
+	if window < 1 || len(results) < window {
+		return 0, 0, false
+	}
+	durations := make([]time.Duration, 0, window)
+	for _, result := range results[len(results)-window:] {
+		durations = append(durations, result.ScaleUpTime())
+	}
+	stats := phaseStats("scale-up", durations)
+	if stats.Mean > 0 {
+		cv = float64(stats.StdDev) / float64(stats.Mean)
+	}
+	return stats.Mean, cv, true
+}
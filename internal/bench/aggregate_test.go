@@ -0,0 +1,93 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPercentile checks the interpolated percentiles of a known set of durations.
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 10; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Second)
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, 1 * time.Second},
+		{50, 5500 * time.Millisecond},
+		{95, 9550 * time.Millisecond},
+		{100, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := Percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := Percentile([]time.Duration{7 * time.Second}, 95); got != 7*time.Second {
+		t.Errorf("Percentile() of a single duration = %v, want 7s", got)
+	}
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile() of no durations = %v, want 0", got)
+	}
+}
+
+// TestAggregatePhases checks the statistics of a phase across iterations and that unmeasured phases are left out.
+func TestAggregatePhases(t *testing.T) {
+	var results []*BenchmarkResult
+	for _, seconds := range []int{40, 10, 30, 20} {
+		results = append(results, &BenchmarkResult{
+			InstanceProvisioningTime: time.Duration(seconds) * time.Second,
+			PodReadinessTime:         5 * time.Second,
+		})
+	}
+
+	stats := AggregatePhases(results)
+	if len(stats) != 2 || stats[0].Phase != "provision" || stats[1].Phase != "ready" {
+		t.Fatalf("AggregatePhases() = %+v, want the provision and ready phases", stats)
+	}
+	want := PhaseStats{
+		Phase:   "provision",
+		Samples: 4,
+		Min:     10 * time.Second,
+		Max:     40 * time.Second,
+		Mean:    25 * time.Second,
+		Median:  25 * time.Second,
+		P95:     38500 * time.Millisecond,
+		StdDev:  time.Duration(11180339887),
+	}
+	if stats[0] != want {
+		t.Errorf("AggregatePhases()[0] = %+v, want %+v", stats[0], want)
+	}
+	if stats[1].StdDev != 0 || stats[1].Mean != 5*time.Second {
+		t.Errorf("AggregatePhases()[1] = %+v, want a constant 5s", stats[1])
+	}
+	if AggregatePhases(nil) != nil {
+		t.Errorf("AggregatePhases(nil) should be nil")
+	}
+}
+
+// TestScaleUpStability checks that only the last window results are considered and that too few results are not.
+func TestScaleUpStability(t *testing.T) {
+	run := func(seconds int) *BenchmarkResult {
+		return &BenchmarkResult{InstanceProvisioningTime: time.Duration(seconds) * time.Second}
+	}
+	results := []*BenchmarkResult{run(300), run(90), run(110), run(100)}
+
+	mean, cv, ok := ScaleUpStability(results, 3)
+	if !ok || mean != 100*time.Second {
+		t.Fatalf("ScaleUpStability() = %v, %v, %v, want a mean of 100s", mean, cv, ok)
+	}
+	// The population standard deviation of 90, 110 and 100 is sqrt(200/3).
+	if want := 0.0816; cv < want-0.0001 || cv > want+0.0001 {
+		t.Errorf("ScaleUpStability() coefficient of variation = %.4f, want %.4f", cv, want)
+	}
+	if _, _, ok := ScaleUpStability(results[:2], 3); ok {
+		t.Errorf("ScaleUpStability() of 2 results with a window of 3 reported ok")
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"fmt"
+	"strings"
+)
+
+// overprovisionedPercent is the bin-packing efficiency below which a scale-up is reported as overprovisioned.
+const overprovisionedPercent = 50.0
+
+// DetectAnomalies returns human-readable warnings about the health signals recorded on the result that might
+// invalidate it, e.g. churn, evictions, spot interruptions, controller restarts or overprovisioning. It is empty for a clean run.
+func (r *BenchmarkResult) DetectAnomalies() []string {
+	var anomalies []string
+	if r.StabilizationTimedOut {
+		anomalies = append(anomalies, "The cluster was not quiet before the scale-up, so prior node or pod activity may be included")
+	}
+	if r.RegisteredNodes < r.InstanceCount {
+		anomalies = append(anomalies, fmt.Sprintf("Only %d of the %d launched instances registered as nodes", r.RegisteredNodes, r.InstanceCount))
+	}
+	if r.ReadyReplicas < r.ExpectedReplicas {
+		anomalies = append(anomalies, fmt.Sprintf("Only %d of the %d pods became ready", r.ReadyReplicas, r.ExpectedReplicas))
+	}
+	if r.AllocatableCPUMillis > 0 && r.BinPackingEfficiencyPercent() < overprovisionedPercent {
+		anomalies = append(anomalies, fmt.Sprintf("Overprovisioned: the pods request only %.1f%% of the registered nodes' allocatable CPU", r.BinPackingEfficiencyPercent()))
+	}
+	if r.ChurnedInstances > 0 {
+		anomalies = append(anomalies, fmt.Sprintf("%d launched instances were terminated or replaced before the scale-down: %s", r.ChurnedInstances, strings.Join(r.ChurnedInstanceIDs, ", ")))
+	}
+	if r.SpotInterrupted() {
+		anomalies = append(anomalies, fmt.Sprintf("Spot interruptions reclaimed %s", strings.Join(r.SpotInterruptedInstances, ", ")))
+	}
+	if r.Evictions > 0 {
+		anomalies = append(anomalies, fmt.Sprintf("%d pods were evicted during the scale-up", r.Evictions))
+	}
+	if r.ControllerRestarts > 0 {
+		anomalies = append(anomalies, fmt.Sprintf("The autoscaler's controller restarted %d times during the run", r.ControllerRestarts))
+	}
+	if r.TransientTerminationErrors > 0 {
+		anomalies = append(anomalies, fmt.Sprintf("%d transient AWS errors occurred while monitoring the termination", r.TransientTerminationErrors))
+	}
+	return anomalies
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDetectAnomalies checks that a clean run has no anomalies and that each unhealthy signal is reported.
+func TestDetectAnomalies(t *testing.T) {
+	clean := &BenchmarkResult{InstanceCount: 3, RegisteredNodes: 3, ExpectedReplicas: 6, ReadyReplicas: 6, AllocatableCPUMillis: 7820, RequestedCPUMillis: 6000}
+	if anomalies := clean.DetectAnomalies(); len(anomalies) != 0 {
+		t.Errorf("DetectAnomalies() of a clean run = %q, want none", anomalies)
+	}
+
+	unhealthy := &BenchmarkResult{
+		StabilizationTimedOut:      true,
+		InstanceCount:              3,
+		RegisteredNodes:            2,
+		ExpectedReplicas:           6,
+		ReadyReplicas:              5,
+		AllocatableCPUMillis:       8000,
+		RequestedCPUMillis:         2000,
+		ChurnedInstances:           1,
+		ChurnedInstanceIDs:         []string{"i-churned"},
+		SpotInterruptedInstances:   []string{"i-churned"},
+		TransientTerminationErrors: 2,
+		Evictions:                  4,
+		ControllerRestarts:         1,
+	}
+	anomalies := unhealthy.DetectAnomalies()
+	for _, expected := range []string{"not quiet", "2 of the 3 launched instances", "5 of the 6 pods", "Overprovisioned: the pods request only 25.0%", "1 launched instances", "Spot interruptions reclaimed i-churned", "2 transient AWS errors", "4 pods were evicted", "restarted 1 times"} {
+		found := false
+		for _, anomaly := range anomalies {
+			found = found || strings.Contains(anomaly, expected)
+		}
+		if !found {
+			t.Errorf("DetectAnomalies() = %q, want an anomaly containing %q", anomalies, expected)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"sort"
+	"time"
+)
+
+// FunctionTotals holds the cumulative sum (in seconds) and count of one function's
+// cluster_autoscaler_function_duration_seconds histogram at the time of a scrape.
+type FunctionTotals struct {
+	Sum   float64
+	Count float64
+}
+
+// CAFunctionLatency is Cluster Autoscaler's self-reported latency of one of its functions (e.g. main or scaleUp)
+// during the benchmark window.
+type CAFunctionLatency struct {
+	Function string
+	// Calls is the number of times the function ran during the window.
+	Calls int
+	// Mean is the average duration of those runs.
+	Mean time.Duration
+}
+
+// CAFunctionLatencies returns the per-function latency during the window between two scrapes of Cluster Autoscaler's
+// function duration histograms, sorted by function name. Functions that did not run during the window are omitted,
+// as are functions whose counters went backwards, e.g. because Cluster Autoscaler restarted.
+func CAFunctionLatencies(before, after map[string]FunctionTotals) []CAFunctionLatency {
+	var latencies []CAFunctionLatency
+	for function, end := range after {
+		start := before[function]
+		calls := end.Count - start.Count
+		sum := end.Sum - start.Sum
+		if calls <= 0 || sum < 0 {
+			continue
+		}
+		latencies = append(latencies, CAFunctionLatency{
+			Function: function,
+			Calls:    int(calls),
+			Mean:     time.Duration(sum / calls * float64(time.Second)),
+		})
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i].Function < latencies[j].Function })
+	return latencies
+}
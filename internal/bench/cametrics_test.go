@@ -0,0 +1,37 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestCAFunctionLatencies checks that the latencies are the mean of the histogram deltas between the scrapes, and
+// that idle functions and reset counters are skipped.
+func TestCAFunctionLatencies(t *testing.T) {
+	before := map[string]FunctionTotals{
+		"main":      {Sum: 100, Count: 50},
+		"scaleUp":   {Sum: 2, Count: 1},
+		"scaleDown": {Sum: 10, Count: 5},
+		"restarted": {Sum: 40, Count: 20},
+	}
+	after := map[string]FunctionTotals{
+		"main":      {Sum: 106, Count: 56},
+		"scaleUp":   {Sum: 5, Count: 3},
+		"scaleDown": {Sum: 10, Count: 5},
+		"restarted": {Sum: 1, Count: 1},
+		"new":       {Sum: 0.5, Count: 2},
+	}
+
+	want := []CAFunctionLatency{
+		{Function: "main", Calls: 6, Mean: time.Second},
+		{Function: "new", Calls: 2, Mean: 250 * time.Millisecond},
+		{Function: "scaleUp", Calls: 2, Mean: 1500 * time.Millisecond},
+	}
+	if got := CAFunctionLatencies(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("CAFunctionLatencies() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Sentinel errors classifying why a benchmark failed. They are matched with errors.Is against
+// the errors returned by the benchmark phases.
+var (
+	// ErrInvalidConfig indicates that the supplied flags or manifest cannot be benchmarked.
+	ErrInvalidConfig = errors.New("Invalid benchmark configuration")
+	// ErrCredentials indicates that AWS or Kubernetes rejected the tool's credentials or permissions.
+	ErrCredentials = errors.New("Credentials or permissions rejected")
+	// ErrProvisioningTimeout indicates that no EC2 instances were launched within the provisioning timeout.
+	ErrProvisioningTimeout = errors.New("Instance provisioning timed out")
+	// ErrRegistrationTimeout indicates that the launched instances did not register as ready nodes in time.
+	ErrRegistrationTimeout = errors.New("Node registration timed out")
+	// ErrSchedulingFailed indicates that the deployment's pods were not scheduled and ready in time.
+	ErrSchedulingFailed = errors.New("Pods failed to schedule or become ready")
+	// ErrChurnExceeded indicates that more launched instances churned before the scale-down than --max-churn allows.
+	ErrChurnExceeded = errors.New("Instance churn exceeded the maximum")
+	// ErrUnexpectedNodeCount indicates that more instances were launched than the run required, e.g. more than one
+	// per pod with --one-pod-per-node.
+	ErrUnexpectedNodeCount = errors.New("More instances launched than expected")
+	// ErrIncompleteScaleUp indicates that fewer pods were running after readiness than the run scaled to, e.g. because
+	// the deployment's ready count briefly included pods that then failed.
+	ErrIncompleteScaleUp = errors.New("Fewer pods running than expected")
+)
+
+// awsCredentialErrorCodes are the AWS error codes returned for missing, invalid or insufficient credentials.
+var awsCredentialErrorCodes = map[string]bool{
+	"AuthFailure":                 true,
+	"UnauthorizedOperation":       true,
+	"InvalidClientTokenId":        true,
+	"ExpiredToken":                true,
+	"RequestExpired":              true,
+	"SignatureDoesNotMatch":       true,
+	"NoCredentialProviders":       true,
+	"AccessDenied":                true,
+	"AccessDeniedException":       true,
+	"UnrecognizedClientException": true,
+}
+
+// PhaseError is returned when a benchmark phase fails. It records the phase and, when the cause is
+// recognised, the sentinel error it is classified as, while still unwrapping to the original error.
+type PhaseError struct {
+	Phase string
+	Kind  error
+	Err   error
+}
+
+// Error implements the error interface.
+func (e *PhaseError) Error() string {
+	return fmt.Sprintf("Error during %s: %v", e.Phase, e.Err)
+}
+
+// Unwrap returns the underlying error so errors.Is/As can match it and anything it wraps.
+func (e *PhaseError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error was classified as the target sentinel error.
+func (e *PhaseError) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// NewPhaseError wraps err as a failure of the given phase. AWS and Kubernetes authentication and authorization
+// errors are classified as ErrCredentials; other sentinel errors wrapped by err are matched through Unwrap.
+func NewPhaseError(phase string, err error) error {
+	phaseErr := &PhaseError{Phase: phase, Err: err}
+	if isCredentialError(err) {
+		phaseErr.Kind = ErrCredentials
+	}
+	return phaseErr
+}
+
+// Retryable reports whether a benchmark failed because a phase timed out, e.g. pods that did not become ready in a
+// flaky environment, so that retrying the run may succeed. Configuration and credential errors are not retryable.
+func Retryable(err error) bool {
+	return errors.Is(err, ErrProvisioningTimeout) || errors.Is(err, ErrRegistrationTimeout) || errors.Is(err, ErrSchedulingFailed)
+}
+
+// isCredentialError reports whether err was caused by AWS or Kubernetes rejecting the tool's credentials or permissions.
+func isCredentialError(err error) bool {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsCredentialErrorCodes[awsErr.Code()] {
+		return true
+	}
+	return apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err)
+}
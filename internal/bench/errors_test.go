@@ -0,0 +1,103 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// TestNewPhaseErrorClassification checks that each failure cause surfaces as the matching sentinel error.
+func TestNewPhaseErrorClassification(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "aws auth failure",
+			err:  fmt.Errorf("Error retrieving EC2 instances: %w", awserr.New("AuthFailure", "not authorized", nil)),
+			want: ErrCredentials,
+		},
+		{
+			name: "kubernetes forbidden",
+			err:  apierrors.NewForbidden(schema.GroupResource{Resource: "nodes"}, "", errors.New("no list")),
+			want: ErrCredentials,
+		},
+		{
+			name: "kubernetes unauthorized",
+			err:  fmt.Errorf("Failed to get deployment: %w", apierrors.NewUnauthorized("expired token")),
+			want: ErrCredentials,
+		},
+		{
+			name: "provisioning timeout",
+			err:  fmt.Errorf("Exiting due to user input: %w", ErrProvisioningTimeout),
+			want: ErrProvisioningTimeout,
+		},
+		{
+			name: "registration timeout",
+			err:  fmt.Errorf("Timed out waiting for 2 nodes to become ready: %w", ErrRegistrationTimeout),
+			want: ErrRegistrationTimeout,
+		},
+		{
+			name: "scheduling failed",
+			err:  fmt.Errorf("Timed out waiting for 2 pods to become ready: %w", ErrSchedulingFailed),
+			want: ErrSchedulingFailed,
+		},
+	}
+
+	sentinels := []error{ErrInvalidConfig, ErrCredentials, ErrProvisioningTimeout, ErrRegistrationTimeout, ErrSchedulingFailed}
+	for _, tt := range tests {
+		err := NewPhaseError("test phase", tt.err)
+		for _, sentinel := range sentinels {
+			if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+				t.Errorf("%s: errors.Is(err, %q) = %v, want %v", tt.name, sentinel, got, sentinel == tt.want)
+			}
+		}
+
+		var phaseErr *PhaseError
+		if !errors.As(err, &phaseErr) || phaseErr.Phase != "test phase" {
+			t.Errorf("%s: errors.As did not surface the *PhaseError for the phase", tt.name)
+		}
+	}
+}
+
+// TestPhaseErrorUnwrapsAWSError checks that the original AWS error stays reachable with errors.As.
+func TestPhaseErrorUnwrapsAWSError(t *testing.T) {
+	err := NewPhaseError("instance provisioning", awserr.New("Throttling", "rate exceeded", nil))
+
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) || awsErr.Code() != "Throttling" {
+		t.Errorf("errors.As(err, awserr.Error) did not return the original AWS error")
+	}
+	if errors.Is(err, ErrCredentials) {
+		t.Errorf("throttling error was classified as ErrCredentials")
+	}
+}
+
+// TestRetryable checks that phase timeouts are retryable and configuration, credential and unclassified errors are not.
+func TestRetryable(t *testing.T) {
+	testCases := []struct {
+		err  error
+		want bool
+	}{
+		{NewPhaseError("pod readiness", fmt.Errorf("Timed out: %w", ErrSchedulingFailed)), true},
+		{NewPhaseError("instance provisioning", ErrProvisioningTimeout), true},
+		{NewPhaseError("instance registration", ErrRegistrationTimeout), true},
+		{NewPhaseError("configuration", ErrInvalidConfig), false},
+		{NewPhaseError("churn check", ErrChurnExceeded), false},
+		{errors.New("boom"), false},
+	}
+	for _, tc := range testCases {
+		if got := Retryable(tc.err); got != tc.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"sync"
+	"time"
+)
+
+// InstanceLaunch is one EC2 instance launched during the scale-up.
+type InstanceLaunch struct {
+	InstanceID   string
+	InstanceType string
+	// Region is the AWS region of the instance's availability zone, e.g. "us-east-1".
+	Region string
+	// Spot reports a spot instance, which is priced differently from an on-demand one.
+	Spot bool
+	// ProvisioningTime is the time from the start of the run until the instance was launched.
+	ProvisioningTime time.Duration
+	// Repeat reports that an instance of the same type was already launched by an earlier benchmark sharing the
+	// LaunchHistory, so caches such as the AMI snapshot are likely warm.
+	Repeat bool
+}
+
+// LaunchHistory remembers the instance types launched by a series of benchmarks, e.g. those of one command, to tell
+// first launches of an instance type, e.g. from a cold AMI snapshot, from repeats. It is safe for concurrent use.
+type LaunchHistory struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// NewLaunchHistory returns an empty launch history.
+func NewLaunchHistory() *LaunchHistory {
+	return &LaunchHistory{seen: make(map[string]bool)}
+}
+
+// Classify marks the launches of one benchmark whose instance type was launched by an earlier benchmark as repeats,
+// then records their types. Launches of the same type within one benchmark are all first launches.
+func (h *LaunchHistory) Classify(launches []InstanceLaunch) []InstanceLaunch {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	classified := make([]InstanceLaunch, len(launches))
+	for i, launch := range launches {
+		launch.Repeat = h.seen[launch.InstanceType]
+		classified[i] = launch
+	}
+	for _, launch := range launches {
+		h.seen[launch.InstanceType] = true
+	}
+	return classified
+}
+
+// MeanLaunchProvisioningTime returns the mean provisioning time of the repeat launches, or of the first launches when
+// repeat is false, and how many launches it averages.
+func (r *BenchmarkResult) MeanLaunchProvisioningTime(repeat bool) (time.Duration, int) {
+	var total time.Duration
+	count := 0
+	for _, launch := range r.InstanceLaunches {
+		if launch.Repeat == repeat {
+			total += launch.ProvisioningTime
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return total / time.Duration(count), count
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLaunchHistory checks that instance types launched by an earlier benchmark are classified as repeats, while
+// several launches of a new type within one benchmark are all first launches.
+func TestLaunchHistory(t *testing.T) {
+	history := NewLaunchHistory()
+	first := history.Classify([]InstanceLaunch{
+		{InstanceID: "i-1", InstanceType: "c7i.large"},
+		{InstanceID: "i-2", InstanceType: "c7i.large"},
+	})
+	for _, launch := range first {
+		if launch.Repeat {
+			t.Errorf("Classify() of the first benchmark marked %s as a repeat", launch.InstanceID)
+		}
+	}
+
+	second := history.Classify([]InstanceLaunch{
+		{InstanceID: "i-3", InstanceType: "c7i.large"},
+		{InstanceID: "i-4", InstanceType: "m7i.large"},
+	})
+	if !second[0].Repeat || second[1].Repeat {
+		t.Errorf("Classify() of the second benchmark = %+v, want only the c7i.large launch as a repeat", second)
+	}
+}
+
+// TestMeanLaunchProvisioningTime checks that first and repeat launches are averaged separately.
+func TestMeanLaunchProvisioningTime(t *testing.T) {
+	r := &BenchmarkResult{InstanceLaunches: []InstanceLaunch{
+		{InstanceType: "c7i.large", ProvisioningTime: 20 * time.Second},
+		{InstanceType: "m7i.large", ProvisioningTime: 30 * time.Second},
+		{InstanceType: "c7i.large", ProvisioningTime: 10 * time.Second, Repeat: true},
+	}}
+
+	if mean, count := r.MeanLaunchProvisioningTime(false); mean != 25*time.Second || count != 2 {
+		t.Errorf("MeanLaunchProvisioningTime(false) = %v, %d, want 25s, 2", mean, count)
+	}
+	if mean, count := r.MeanLaunchProvisioningTime(true); mean != 10*time.Second || count != 1 {
+		t.Errorf("MeanLaunchProvisioningTime(true) = %v, %d, want 10s, 1", mean, count)
+	}
+	if mean, count := (&BenchmarkResult{}).MeanLaunchProvisioningTime(true); mean != 0 || count != 0 {
+		t.Errorf("MeanLaunchProvisioningTime() without launches = %v, %d, want 0, 0", mean, count)
+	}
+}
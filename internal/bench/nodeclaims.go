@@ -0,0 +1,34 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import "time"
+
+// NodeClaimTiming brackets the provisioning of one Karpenter NodeClaim using the transition times of its conditions.
+type NodeClaimTiming struct {
+	Name string
+	// LaunchedToRegistered is the time from the Launched condition until the Registered condition became true, i.e.
+	// from the cloud provider launching the instance until its node joined the cluster.
+	LaunchedToRegistered time.Duration
+	// RegisteredToInitialized is the time from the Registered condition until the Initialized condition became true,
+	// i.e. until the node was ready with its startup taints removed and resources registered.
+	RegisteredToInitialized time.Duration
+}
+
+// NodeClaimStats summarizes NodeClaimTimings per phase, "launched-registered" before "registered-initialized". It is
+// empty when no NodeClaims were measured.
+func (r *BenchmarkResult) NodeClaimStats() []StateStats {
+	if len(r.NodeClaimTimings) == 0 {
+		return nil
+	}
+	var registered, initialized []time.Duration
+	for _, timing := range r.NodeClaimTimings {
+		registered = append(registered, timing.LaunchedToRegistered)
+		initialized = append(initialized, timing.RegisteredToInitialized)
+	}
+	return []StateStats{
+		durationStats("launched-registered", registered),
+		durationStats("registered-initialized", initialized),
+	}
+}
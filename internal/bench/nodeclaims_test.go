@@ -0,0 +1,32 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestNodeClaimStats checks that the NodeClaim timings are summarized per phase and that no stats are returned
+// without timings.
+func TestNodeClaimStats(t *testing.T) {
+	r := &BenchmarkResult{NodeClaimTimings: []NodeClaimTiming{
+		{Name: "default-a", LaunchedToRegistered: 30 * time.Second, RegisteredToInitialized: 4 * time.Second},
+		{Name: "default-b", LaunchedToRegistered: 25 * time.Second, RegisteredToInitialized: 9 * time.Second},
+		{Name: "default-c", LaunchedToRegistered: 40 * time.Second, RegisteredToInitialized: 5 * time.Second},
+	}}
+
+	want := []StateStats{
+		{State: "launched-registered", Instances: 3, Min: 25 * time.Second, Median: 30 * time.Second, Max: 40 * time.Second},
+		{State: "registered-initialized", Instances: 3, Min: 4 * time.Second, Median: 5 * time.Second, Max: 9 * time.Second},
+	}
+	if got := r.NodeClaimStats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("NodeClaimStats() = %v, want %v", got, want)
+	}
+
+	if got := (&BenchmarkResult{}).NodeClaimStats(); got != nil {
+		t.Errorf("NodeClaimStats() without timings = %v, want nil", got)
+	}
+}
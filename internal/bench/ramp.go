@@ -0,0 +1,76 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RampStep holds the measurements of a single step of a replica ramp.
+type RampStep struct {
+	// Step is the zero-based index of the step in the schedule.
+	Step int
+	// Replicas is the replica count the deployment was scaled to at this step.
+	Replicas int
+	// InstanceProvisioningTime is the time from the scale command until the first new EC2 instance
+	// of the step was launched. It is zero when the step did not require new instances.
+	InstanceProvisioningTime time.Duration
+	// PodReadinessTime is the time from the scale command until all replicas of the step were ready.
+	PodReadinessTime time.Duration
+	// NewInstances is the number of EC2 instances launched during the step.
+	NewInstances int
+}
+
+// ExponentialSchedule returns the replica counts of an exponential ramp where step i scales to base * growth^i,
+// rounded to the nearest integer. It returns an error if the parameters cannot produce a growing schedule.
+func ExponentialSchedule(base int, growth float64, steps int) ([]int, error) {
+	if base < 1 {
+		return nil, fmt.Errorf("Ramp base must be at least 1, got %d", base)
+	}
+	if growth <= 1 {
+		return nil, fmt.Errorf("Ramp growth must be greater than 1, got %g", growth)
+	}
+	if steps < 1 {
+		return nil, fmt.Errorf("Ramp steps must be at least 1, got %d", steps)
+	}
+
+	schedule := make([]int, 0, steps)
+	for i := 0; i < steps; i++ {
+		replicas := int(math.Round(float64(base) * math.Pow(growth, float64(i))))
+		if i > 0 && replicas <= schedule[i-1] {
+			replicas = schedule[i-1] + 1
+		}
+		schedule = append(schedule, replicas)
+	}
+
+	return schedule, nil
+}
+
+// ParseExponentialRamp parses an exponential ramp specification of the form "base,growth,steps" (e.g. "2,2,4")
+// and returns the resulting replica schedule.
+func ParseExponentialRamp(spec string) ([]int, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("Exponential ramp must have the form base,growth,steps, got %q", spec)
+	}
+
+	base, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ramp base %q: %w", parts[0], err)
+	}
+	growth, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ramp growth %q: %w", parts[1], err)
+	}
+	steps, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ramp steps %q: %w", parts[2], err)
+	}
+
+	return ExponentialSchedule(base, growth, steps)
+}
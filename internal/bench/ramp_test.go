@@ -0,0 +1,37 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseExponentialRamp checks that ramp specifications produce base * growth^i schedules and that invalid ones are rejected.
+func TestParseExponentialRamp(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []int
+		wantErr bool
+	}{
+		{spec: "2,2,4", want: []int{2, 4, 8, 16}},
+		{spec: "1,1.5,4", want: []int{1, 2, 3, 4}},
+		{spec: "3, 3, 3", want: []int{3, 9, 27}},
+		{spec: "2,1,4", wantErr: true},
+		{spec: "0,2,4", wantErr: true},
+		{spec: "2,2", wantErr: true},
+		{spec: "a,2,4", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseExponentialRamp(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseExponentialRamp(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseExponentialRamp(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
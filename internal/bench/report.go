@@ -0,0 +1,55 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// BenchmarkReport is the JSON document a benchmark run is archived as: the result together with the autoscaler type
+// and the configuration (flag name to value) it ran with.
+type BenchmarkReport struct {
+	AutoscalerType string            `json:"autoscaler_type"`
+	Config         map[string]string `json:"config,omitempty"`
+	Result         *BenchmarkResult  `json:"result"`
+}
+
+// SaveBenchmarkReport writes the report of a benchmark run as indented JSON to path, or to standard output when path
+// is "-".
+func SaveBenchmarkReport(path, autoscalerType string, config map[string]string, result *BenchmarkResult) error {
+	data, err := json.MarshalIndent(BenchmarkReport{AutoscalerType: autoscalerType, Config: config, Result: result}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode benchmark report: %w", err)
+	}
+	data = append(data, '\n')
+
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write benchmark report: %w", err)
+	}
+	return nil
+}
+
+// LoadBenchmarkReport reads a report written by SaveBenchmarkReport.
+func LoadBenchmarkReport(path string) (*BenchmarkReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read benchmark report: %w", err)
+	}
+
+	var report BenchmarkReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("Failed to decode benchmark report %s: %w", path, err)
+	}
+	if report.Result == nil {
+		return nil, fmt.Errorf("Benchmark report %s has no result", path)
+	}
+	return &report, nil
+}
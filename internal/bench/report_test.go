@@ -0,0 +1,46 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestSaveAndLoadBenchmarkReport checks that a saved report loads back unchanged and that files without a result are
+// rejected.
+func TestSaveAndLoadBenchmarkReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	result := &BenchmarkResult{
+		AutoscalerType:           "Karpenter",
+		Target:                   "default",
+		InstanceProvisioningTime: 12 * time.Second,
+		PodReadinessTime:         3500 * time.Millisecond,
+		LaunchTemplates:          map[string]int{"lt-0abc:3": 2},
+	}
+	config := map[string]string{"nodepool": "default", "replicas": "2"}
+
+	if err := SaveBenchmarkReport(path, "Karpenter", config, result); err != nil {
+		t.Fatalf("SaveBenchmarkReport() returned error: %v", err)
+	}
+	report, err := LoadBenchmarkReport(path)
+	if err != nil {
+		t.Fatalf("LoadBenchmarkReport() returned error: %v", err)
+	}
+	want := &BenchmarkReport{AutoscalerType: "Karpenter", Config: config, Result: result}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("LoadBenchmarkReport() = %+v, want %+v", report, want)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.json")
+	if err := os.WriteFile(empty, []byte(`{"autoscaler_type": "Karpenter"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBenchmarkReport(empty); err == nil {
+		t.Errorf("LoadBenchmarkReport() of a report without a result returned nil error")
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// OutputFormats are the formats the results of a run can be written in. "text" is the colored summary, printed by
+// the utilities package, and the others are machine-readable formats written by WriteResults.
+var OutputFormats = []string{"text", "json", "csv"}
+
+// ScaleUpTime returns the total time of the scale-up phases: instance provisioning, registration and pod readiness.
+func (r *BenchmarkResult) ScaleUpTime() time.Duration {
+	return r.InstanceProvisioningTime + r.InstanceRegistrationTime + r.PodReadinessTime
+}
+
+// ScaleDownTime returns the total time of the scale-down phases: node deregistration and instance termination.
+func (r *BenchmarkResult) ScaleDownTime() time.Duration {
+	return r.NodeDeregistrationTime + r.InstanceTerminationTime
+}
+
+// WriteResults writes the results to w in a machine-readable output format: "json" writes them as an indented JSON
+// array and "csv" as a header row followed by one row per result, see CSVReporter.
+func WriteResults(w io.Writer, format string, results []*BenchmarkResult) error {
+	switch format {
+	case "json":
+		if results == nil {
+			results = []*BenchmarkResult{}
+		}
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("Failed to encode results: %w", err)
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("Failed to write results: %w", err)
+		}
+		return nil
+	case "csv":
+		return writeCSV(w, results)
+	}
+	return fmt.Errorf("Unsupported output format %q", format)
+}
+
+// writeCSV writes a header row and one row per result, see CSVReporter.
+func writeCSV(w io.Writer, results []*BenchmarkResult) error {
+	reporter := NewCSVReporter(w, true)
+	if err := reporter.writeHeader(); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if err := reporter.Write(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CSVReporter writes results as CSV rows one at a time, so each run can be reported as soon as it completes. A row
+// holds the result's 1-based position, autoscaler, target, the duration of every phase in PhaseNames, the scale-up and
+// scale-down totals and the provisioning time per node and readiness time per pod, all in seconds as plain numbers,
+// followed by the calibrated scale-up time and its coefficient of variation, which are empty unless recorded.
+type CSVReporter struct {
+	writer *csv.Writer
+	// header reports that the header row still has to be written before the next row.
+	header bool
+	rows   int
+}
+
+// NewCSVReporter returns a CSVReporter writing to w. With header, the header row is written before the first row,
+// otherwise the rows are expected to be appended to a CSV that already has one.
+func NewCSVReporter(w io.Writer, header bool) *CSVReporter {
+	return &CSVReporter{writer: csv.NewWriter(w), header: header}
+}
+
+// AppendCSVReporter returns a CSVReporter appending to w the rows of a CSV whose current content is read from existing.
+// The header row is written only when existing is empty, and the run numbers continue from its last row.
+func AppendCSVReporter(w io.Writer, existing io.Reader) (*CSVReporter, error) {
+	reader := csv.NewReader(existing)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read existing CSV: %w", err)
+	}
+	reporter := NewCSVReporter(w, len(rows) == 0)
+	reporter.rows = LastRun(rows)
+	return reporter, nil
+}
+
+// Write writes the row of a result, preceded by the header row if it was not written yet, and flushes it.
+func (r *CSVReporter) Write(result *BenchmarkResult) error {
+	if err := r.writeHeader(); err != nil {
+		return err
+	}
+	r.rows++
+	if err := r.writer.Write(CSVRow(r.rows, result)); err != nil {
+		return fmt.Errorf("Failed to write CSV row: %w", err)
+	}
+	return r.flush()
+}
+
+// writeHeader writes the header row if it was not written yet.
+func (r *CSVReporter) writeHeader() error {
+	if !r.header {
+		return nil
+	}
+	r.header = false
+	if err := r.writer.Write(CSVHeader()); err != nil {
+		return fmt.Errorf("Failed to write CSV header: %w", err)
+	}
+	return r.flush()
+}
+
+// CSVHeader returns the column names of the rows returned by CSVRow.
+func CSVHeader() []string {
+	header := []string{"run", "autoscaler", "target"}
+	for _, phase := range PhaseNames {
+		header = append(header, phase+"_s")
+	}
+	return append(header, "scale_up_s", "scale_down_s", "provision_per_node_s", "ready_per_pod_s", "calibrated_scale_up_s", "calibration_cv")
+}
+
+// CSVRow returns the row of a result reported as the given 1-based run, see CSVReporter.
+func CSVRow(run int, result *BenchmarkResult) []string {
+	row := []string{strconv.Itoa(run), result.AutoscalerType, result.Target}
+	for _, phase := range PhaseNames {
+		row = append(row, csvSeconds(result.PhaseDuration(phase)))
+	}
+	row = append(row, csvSeconds(result.ScaleUpTime()), csvSeconds(result.ScaleDownTime()),
+		strconv.FormatFloat(result.ProvisioningSecondsPerNode(), 'f', 3, 64), strconv.FormatFloat(result.ReadinessSecondsPerPod(), 'f', 3, 64))
+	if result.CalibratedScaleUpTime == 0 {
+		return append(row, "", "")
+	}
+	return append(row, csvSeconds(result.CalibratedScaleUpTime), strconv.FormatFloat(result.CalibrationCV, 'f', 3, 64))
+}
+
+// LastRun returns the run number of the last row of CSV rows read back from an earlier output, so rows appended to it
+// continue its numbering. It returns 0 when there are no rows besides the header or the run column is not a number.
+func LastRun(rows [][]string) int {
+	if len(rows) == 0 || len(rows[len(rows)-1]) == 0 {
+		return 0
+	}
+	run, err := strconv.Atoi(rows[len(rows)-1][0])
+	if err != nil {
+		return 0
+	}
+	return run
+}
+
+// flush writes the buffered rows to the underlying writer.
+func (r *CSVReporter) flush() error {
+	r.writer.Flush()
+	if err := r.writer.Error(); err != nil {
+		return fmt.Errorf("Failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// csvSeconds formats a duration as seconds with millisecond precision.
+func csvSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
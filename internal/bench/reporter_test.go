@@ -0,0 +1,119 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWriteResultsCSV checks that the CSV output parses and has a header plus one row per result with plain seconds,
+// and that the calibration columns are only filled in when recorded.
+func TestWriteResultsCSV(t *testing.T) {
+	results := []*BenchmarkResult{
+		{AutoscalerType: "Karpenter", Target: "default", InstanceProvisioningTime: 12300 * time.Millisecond, InstanceRegistrationTime: 30 * time.Second, PodReadinessTime: 5 * time.Second, NodeDeregistrationTime: 20 * time.Second, InstanceTerminationTime: 40 * time.Second},
+		{AutoscalerType: "Cluster Autoscaler", Target: "ng, 1", InstanceProvisioningTime: time.Second, InstanceCount: 2, CalibratedScaleUpTime: 1500 * time.Millisecond, CalibrationCV: 0.05},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, "csv", results); err != nil {
+		t.Fatalf("WriteResults() returned an error: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV output does not parse: %v", err)
+	}
+
+	want := [][]string{
+		{"run", "autoscaler", "target", "provision_s", "register_s", "ready_s", "dereg_s", "terminate_s", "scale_up_s", "scale_down_s", "provision_per_node_s", "ready_per_pod_s", "calibrated_scale_up_s", "calibration_cv"},
+		{"1", "Karpenter", "default", "12.300", "30.000", "5.000", "20.000", "40.000", "47.300", "60.000", "0.000", "0.000", "", ""},
+		{"2", "Cluster Autoscaler", "ng, 1", "1.000", "0.000", "0.000", "0.000", "0.000", "1.000", "0.000", "0.500", "0.000", "1.500", "0.050"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("CSV output = %v, want %v", records, want)
+	}
+}
+
+// TestWriteResultsJSON checks that the JSON output is an array of the results and that unknown formats are rejected.
+func TestWriteResultsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, "json", []*BenchmarkResult{{AutoscalerType: "Karpenter", Target: "default"}}); err != nil {
+		t.Fatalf("WriteResults() returned an error: %v", err)
+	}
+	var decoded []BenchmarkResult
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSON output does not parse: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].Target != "default" {
+		t.Errorf("JSON output = %+v, want the single result", decoded)
+	}
+
+	if err := WriteResults(&buf, "xml", nil); err == nil {
+		t.Errorf("WriteResults() accepted an unknown format")
+	}
+}
+
+// TestCSVReporter checks that every row is flushed as soon as it is written and that appending skips the header.
+func TestCSVReporter(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewCSVReporter(&buf, true)
+	if err := reporter.Write(&BenchmarkResult{AutoscalerType: "Karpenter", Target: "default"}); err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Fatalf("After the first Write() the output has %d lines, want the header and one row:\n%s", lines, buf.String())
+	}
+	if err := reporter.Write(&BenchmarkResult{AutoscalerType: "Karpenter", Target: "default"}); err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "\n2,Karpenter,default,") {
+		t.Errorf("The second row is not numbered 2:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := NewCSVReporter(&buf, false).Write(&BenchmarkResult{AutoscalerType: "Karpenter", Target: "default"}); err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "1,Karpenter,default,") {
+		t.Errorf("Appended output should start with the row, got:\n%s", buf.String())
+	}
+}
+
+// TestAppendCSVReporter checks that appending to an existing CSV skips the header and continues its run numbering, and
+// that appending to an empty one writes the header first.
+func TestAppendCSVReporter(t *testing.T) {
+	var existing bytes.Buffer
+	if err := writeCSV(&existing, []*BenchmarkResult{{AutoscalerType: "Karpenter"}, {AutoscalerType: "Karpenter"}}); err != nil {
+		t.Fatalf("writeCSV() returned an error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	reporter, err := AppendCSVReporter(&buf, strings.NewReader(existing.String()))
+	if err != nil {
+		t.Fatalf("AppendCSVReporter() returned an error: %v", err)
+	}
+	if err := reporter.Write(&BenchmarkResult{AutoscalerType: "Karpenter", Target: "default"}); err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "3,Karpenter,default,") {
+		t.Errorf("Appended output should start with run 3, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	reporter, err = AppendCSVReporter(&buf, strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("AppendCSVReporter() returned an error: %v", err)
+	}
+	if err := reporter.Write(&BenchmarkResult{AutoscalerType: "Karpenter", Target: "default"}); err != nil {
+		t.Fatalf("Write() returned an error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "run,") || !strings.Contains(buf.String(), "\n1,Karpenter,default,") {
+		t.Errorf("Output for an empty CSV should be the header and run 1, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+// Package bench holds the benchmark result types shared by every output of the k8s-autoscaler-benchmarker application.
+package bench
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BenchmarkResult is the single source of truth for the measurements of a benchmark run.
+// Every summary printer and report consumes it, so a new measurement only needs to be added here
+// (and populated by the orchestration) to become available to all outputs.
+type BenchmarkResult struct {
+	// AutoscalerType is "Karpenter", "Cluster Autoscaler" or "Fargate".
+	AutoscalerType string
+	// Target is the node pool or node group that was benchmarked. It is empty for Fargate.
+	Target string
+	// Namespace is the namespace of the benchmark workload. It is empty for --observe-only.
+	Namespace string
+	// AWSAccountID and AWSRegion identify where the benchmark ran. They are empty for Fargate.
+	AWSAccountID string
+	AWSRegion    string
+	// Metadata holds the arbitrary key/value pairs the run was tagged with via --metadata, e.g. a git SHA or
+	// environment, for filtering archived results.
+	Metadata map[string]string
+	// TraceID is the ID of the trace the run belongs to, given with --trace-id or taken from TRACEPARENT, which the
+	// OpenMetrics exposition of serve mode attaches as an exemplar. It is empty when the run is not traced.
+	TraceID string
+	// RunID is the kab-run-id tag the run's instances were stamped with by --tag-instances, by which they can be found
+	// in the EC2 console and Cost Explorer. It is empty when the instances were not tagged.
+	RunID string
+	// Seed is the --seed the run's random choices, e.g. the instance terminated by --chaos-terminate-one, were made
+	// with. Rerunning with it repeats those choices.
+	Seed int64
+	// StabilizationTimedOut reports that the cluster did not become quiet within --pre-run-stable-timeout before the
+	// scale-up, so the measurements may include node or pod activity from before the benchmark.
+	StabilizationTimedOut bool
+	// Anomalies lists human-readable warnings about signals detected during the run that might invalidate the result,
+	// see DetectAnomalies.
+	Anomalies []string
+	// PhaseAttempts maps each phase that failed at least once and was retried with --phase-retries to the number of
+	// attempts it needed, i.e. its failures plus the final attempt. It is empty when no retry happened.
+	PhaseAttempts map[string]int
+	// CalibratedScaleUpTime is the mean total scale-up time of the last --window successful runs of the target, and
+	// CalibrationCV their coefficient of variation, as recorded by --repeat-until-stable on the target's result of the
+	// last run it made, whether the scale-up time stabilized or not. They are zero otherwise.
+	CalibratedScaleUpTime time.Duration
+	CalibrationCV         float64
+
+	// InstanceProvisioningTime is the time until EC2 instances started their boot process. Unused for Fargate.
+	InstanceProvisioningTime time.Duration
+	// InstanceRegistrationTime is the time until the nodes registered to the k8s API and became ready.
+	// For Fargate this is the pod provisioning time, i.e. the time until one Fargate node per replica registered.
+	InstanceRegistrationTime time.Duration
+	// KubeletRegistrationLag is the average time from an instance entering the running state until its Node object was
+	// created, i.e. the OS boot and the kubelet joining the cluster, over the nodes at pod readiness correlated with the
+	// instances by provider ID. It has about second precision and is zero when no node could be correlated. Unused for
+	// Fargate.
+	KubeletRegistrationLag time.Duration
+	// PodReadinessTime is the time until all pods of the deployment were ready.
+	PodReadinessTime time.Duration
+	// PodTerminationTime is the time from scaling to 0 until the last pod of the workload was gone, including its
+	// graceful shutdown and evictions delayed by a PodDisruptionBudget. Unused for Fargate and --tenants.
+	PodTerminationTime time.Duration
+	// NodeDeregistrationTime is the time until the nodes deregistered from the k8s API after scaling to 0.
+	NodeDeregistrationTime time.Duration
+	// InstanceTerminationTime is the time until the EC2 instances terminated after scaling to 0. Unused for Fargate.
+	InstanceTerminationTime time.Duration
+	// TimeToFirstSchedule is the time from the scale-up until the first pod transitioned to PodScheduled=True, i.e. the
+	// scheduler and autoscaler latency before any container work. It has second precision.
+	TimeToFirstSchedule time.Duration
+
+	// BaselineNodes is the number of nodes running when the scale-up started after the --baseline-deployment became
+	// ready. The measurements only cover the instances launched on top of them. It is 0 without a baseline.
+	BaselineNodes int
+	// InstanceCount is the number of EC2 instances launched during the scale-up.
+	InstanceCount int
+	// InstanceLaunches holds the instances observed launching during the scale-up, each classified as the first launch
+	// of its instance type by this process or a repeat, to surface warm-cache effects across benchmarks.
+	InstanceLaunches []InstanceLaunch
+	// InstanceStateDurations holds, per EC2 instance state, the time each launched instance spent in it before moving
+	// on, e.g. from launch to running under "pending". It is only recorded with --instance-states.
+	InstanceStateDurations map[string][]time.Duration
+	// NodeClaimTimings holds the Launched, Registered and Initialized condition transitions of each Karpenter
+	// NodeClaim of the scale-up. It is only recorded with --use-nodeclaims.
+	NodeClaimTimings []NodeClaimTiming
+	// LaunchTemplates counts the launched instances by the launch template they were launched from, keyed as "<id>:<version>".
+	LaunchTemplates map[string]int
+	// InstanceTypes and AvailabilityZones count the launched instances by instance type and availability zone, to
+	// explain differences between runs, e.g. a slower scale-up that launched larger instances.
+	InstanceTypes     map[string]int
+	AvailabilityZones map[string]int
+	// SpotInstances and OnDemandInstances split the launched instances by capacity type, since spot provisioning
+	// latency differs from on-demand.
+	SpotInstances     int
+	OnDemandInstances int
+	// CostEstimateUSD is the approximate cost in USD of the launched instances from their launch until they terminated,
+	// based on the prices of their region and lifecycle. It leaves out instance types without a known price.
+	CostEstimateUSD float64
+	// DescribeInstancesCalls is the number of EC2 DescribeInstances requests the run sent.
+	DescribeInstancesCalls int64
+	// K8sRequests is the number of requests the benchmark sent to the Kubernetes API server during the run, and
+	// K8sRequestsPerSecond their average rate, to gauge the load the run put on the control plane.
+	K8sRequests          int64
+	K8sRequestsPerSecond float64
+	// TransientTerminationErrors is the number of DescribeInstances failures tolerated while monitoring termination.
+	TransientTerminationErrors int
+	// TerminationSeries records the number of instances still running each time it changed during scale-down.
+	TerminationSeries []TerminationSample
+
+	// ExpectedReplicas is the number of pods the benchmark scaled to, and ReadyReplicas how many of them became ready.
+	ExpectedReplicas int
+	ReadyReplicas    int
+	// PodNodes is the number of distinct nodes the Running pods of the deployment were counted on after readiness.
+	PodNodes int
+	// RegisteredNodes is the number of launched instances that registered to the k8s API as ready nodes.
+	RegisteredNodes int
+	// ChurnedInstances is the number of launched instances that were terminated or replaced before the scale-down
+	// started, e.g. by consolidation or a spot interruption, and ChurnedInstanceIDs their IDs.
+	ChurnedInstances   int
+	ChurnedInstanceIDs []string
+	// Evictions is the number of the workload's pods evicted during the scale-up, e.g. by consolidation or node
+	// pressure, and ControllerRestarts how often the autoscaler's controller restarted during the run.
+	Evictions          int
+	ControllerRestarts int
+	// SpotInterruptedInstances lists the instances EC2 reclaimed through a spot interruption: the churned instances at
+	// pod readiness, and every instance seen during the run once they terminated. When it is not empty the measurements
+	// were disrupted by spot churn and should not be treated as a genuine result.
+	SpotInterruptedInstances []string
+
+	// ChaosTerminatedInstance is the instance terminated by --chaos-terminate-one. ChaosReplacementTime is the time from
+	// its termination until the autoscaler launched a replacement, and ChaosRecoveryTime the time until all pods were
+	// ready again. They are zero when no instance was terminated.
+	ChaosTerminatedInstance string
+	ChaosReplacementTime    time.Duration
+	ChaosRecoveryTime       time.Duration
+
+	// AllocatableCPUMillis and AllocatableMemoryBytes are the totals of node.Status.Allocatable across the
+	// registered nodes, and RequestedCPUMillis is replicas * the pod CPU request of the deployment.
+	AllocatableCPUMillis   int64
+	AllocatableMemoryBytes int64
+	RequestedCPUMillis     int64
+
+	// RampSteps holds the per-step measurements when the scale-up followed a replica ramp schedule.
+	RampSteps []RampStep
+	// TenantReadiness holds the pod readiness time of each namespace when identical workloads were deployed into
+	// several tenant namespaces. PodReadinessTime is then the slowest tenant's.
+	TenantReadiness []TenantReadiness
+	// NodeCountSeries samples the number of nodes matching the autoscaler's label selector at a fixed interval from the
+	// scale-up until the instances terminated.
+	NodeCountSeries []NodeCountSample
+	// CAFunctionLatencies is Cluster Autoscaler's self-reported latency per internal function (e.g. main, scaleUp)
+	// between the scrapes before the scale-up and once the pods are ready. It is only recorded with --ca-metrics-url.
+	CAFunctionLatencies []CAFunctionLatency
+}
+
+// NodeCountSample is the number of nodes counted at a given time after the scale-up started.
+type NodeCountSample struct {
+	Elapsed time.Duration
+	Nodes   int
+}
+
+// TenantReadiness is the pod readiness time of the workload in one tenant namespace.
+type TenantReadiness struct {
+	Namespace        string
+	PodReadinessTime time.Duration
+}
+
+// SpotInterrupted reports whether any instance was reclaimed through a spot interruption during the benchmark.
+func (r *BenchmarkResult) SpotInterrupted() bool {
+	return len(r.SpotInterruptedInstances) > 0
+}
+
+// CheckChurn returns an error wrapping ErrChurnExceeded and listing the churned instances when more than maxChurn
+// launched instances churned. A negative maxChurn disables the check.
+func (r *BenchmarkResult) CheckChurn(maxChurn int) error {
+	if maxChurn < 0 || r.ChurnedInstances <= maxChurn {
+		return nil
+	}
+	return fmt.Errorf("%d instances churned before the scale-down (%s), more than the %d allowed: %w", r.ChurnedInstances, strings.Join(r.ChurnedInstanceIDs, ", "), maxChurn, ErrChurnExceeded)
+}
+
+// BinPackingEfficiencyPercent returns the share of the registered nodes' allocatable CPU that is requested by the
+// benchmark pods, as a percentage. It returns 0 when no allocatable CPU was recorded.
+func (r *BenchmarkResult) BinPackingEfficiencyPercent() float64 {
+	if r.AllocatableCPUMillis == 0 {
+		return 0
+	}
+	return float64(r.RequestedCPUMillis) / float64(r.AllocatableCPUMillis) * 100
+}
+
+// ProvisioningSecondsPerNode returns the time in seconds until the last instance of the scale-up launched divided by
+// the number of launched instances, so scale-ups of different sizes can be compared. The instance provisioning time
+// alone would not do, as it usually only lasts until the first instance. Without recorded launches it falls back to
+// the instance provisioning time and returns 0 when no instance was launched.
+func (r *BenchmarkResult) ProvisioningSecondsPerNode() float64 {
+	if len(r.InstanceLaunches) > 0 {
+		var last time.Duration
+		for _, launch := range r.InstanceLaunches {
+			last = max(last, launch.ProvisioningTime)
+		}
+		return last.Seconds() / float64(len(r.InstanceLaunches))
+	}
+	if r.InstanceCount == 0 {
+		return 0
+	}
+	return r.InstanceProvisioningTime.Seconds() / float64(r.InstanceCount)
+}
+
+// ReadinessSecondsPerPod returns the pod readiness time in seconds divided by the number of pods that became ready,
+// so scale-ups of different sizes can be compared. It returns 0 when no pod became ready.
+func (r *BenchmarkResult) ReadinessSecondsPerPod() float64 {
+	if r.ReadyReplicas == 0 {
+		return 0
+	}
+	return r.PodReadinessTime.Seconds() / float64(r.ReadyReplicas)
+}
+
+// Weights of the signals that make up the completeness score. They sum to 100.
+const (
+	completenessNodesWeight = 30
+	completenessPodsWeight  = 40
+	completenessChurnWeight = 30
+)
+
+// CompletenessScore summarizes the health of a scale-up as a score from 0 to 100: the share of launched instances
+// that registered as nodes, the share of expected pods that became ready, and the share of launched instances that
+// survived until the scale-down. A run that hit a phase timeout fails before a result is produced, so a score of 100
+// means every expected node and pod arrived within the timeouts without churn.
+func (r *BenchmarkResult) CompletenessScore() int {
+	score := completenessNodesWeight*ratio(r.RegisteredNodes, r.InstanceCount) +
+		completenessPodsWeight*ratio(r.ReadyReplicas, r.ExpectedReplicas) +
+		completenessChurnWeight*ratio(r.InstanceCount-r.ChurnedInstances, r.InstanceCount)
+	return int(score + 0.5)
+}
+
+// ratio returns part/whole clamped to [0, 1], treating an empty whole as complete.
+func ratio(part, whole int) float64 {
+	if whole <= 0 {
+		return 1
+	}
+	if part <= 0 {
+		return 0
+	}
+	if part >= whole {
+		return 1
+	}
+	return float64(part) / float64(whole)
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBinPackingEfficiencyPercent checks the requested-to-allocatable CPU ratio, including the no-data case.
+func TestBinPackingEfficiencyPercent(t *testing.T) {
+	result := BenchmarkResult{AllocatableCPUMillis: 7820, RequestedCPUMillis: 6000}
+	if got := result.BinPackingEfficiencyPercent(); got < 76.72 || got > 76.73 {
+		t.Errorf("BinPackingEfficiencyPercent() = %.3f, want 76.726", got)
+	}
+
+	empty := BenchmarkResult{RequestedCPUMillis: 6000}
+	if got := empty.BinPackingEfficiencyPercent(); got != 0 {
+		t.Errorf("BinPackingEfficiencyPercent() without allocatable CPU = %.3f, want 0", got)
+	}
+}
+
+// TestPerInstanceNormalization checks the provisioning time per node, from the provisioning time and from the last
+// instance launch, and the readiness time per pod, including the no-data case.
+func TestPerInstanceNormalization(t *testing.T) {
+	result := BenchmarkResult{InstanceProvisioningTime: 50 * time.Second, InstanceCount: 4, PodReadinessTime: 30 * time.Second, ReadyReplicas: 20}
+	if got := result.ProvisioningSecondsPerNode(); got != 12.5 {
+		t.Errorf("ProvisioningSecondsPerNode() = %g, want 12.5", got)
+	}
+	result.InstanceLaunches = []InstanceLaunch{{ProvisioningTime: 40 * time.Second}, {ProvisioningTime: 90 * time.Second}, {ProvisioningTime: 60 * time.Second}}
+	if got := result.ProvisioningSecondsPerNode(); got != 30 {
+		t.Errorf("ProvisioningSecondsPerNode() with launches = %g, want 30", got)
+	}
+	if got := result.ReadinessSecondsPerPod(); got != 1.5 {
+		t.Errorf("ReadinessSecondsPerPod() = %g, want 1.5", got)
+	}
+
+	empty := BenchmarkResult{InstanceProvisioningTime: 50 * time.Second, PodReadinessTime: 30 * time.Second}
+	if empty.ProvisioningSecondsPerNode() != 0 || empty.ReadinessSecondsPerPod() != 0 {
+		t.Errorf("Normalized times without instances or pods = %g, %g, want 0", empty.ProvisioningSecondsPerNode(), empty.ReadinessSecondsPerPod())
+	}
+}
+
+// TestCompletenessScore checks the weighting of the node, pod and churn signals of the completeness score.
+func TestCompletenessScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		result BenchmarkResult
+		want   int
+	}{
+		{"complete", BenchmarkResult{InstanceCount: 3, RegisteredNodes: 3, ExpectedReplicas: 6, ReadyReplicas: 6}, 100},
+		{"missing node", BenchmarkResult{InstanceCount: 3, RegisteredNodes: 2, ExpectedReplicas: 6, ReadyReplicas: 6}, 90},
+		{"half the pods ready", BenchmarkResult{InstanceCount: 3, RegisteredNodes: 3, ExpectedReplicas: 6, ReadyReplicas: 3}, 80},
+		{"churned instance", BenchmarkResult{InstanceCount: 3, RegisteredNodes: 3, ExpectedReplicas: 6, ReadyReplicas: 6, ChurnedInstances: 1}, 90},
+		{"nothing arrived", BenchmarkResult{InstanceCount: 3, ExpectedReplicas: 6}, 30},
+	}
+
+	for _, tt := range tests {
+		if got := tt.result.CompletenessScore(); got != tt.want {
+			t.Errorf("%s: CompletenessScore() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestCheckChurn checks that churn above the maximum fails with the churned instances listed, and that a negative
+// maximum disables the check.
+func TestCheckChurn(t *testing.T) {
+	result := BenchmarkResult{ChurnedInstances: 2, ChurnedInstanceIDs: []string{"i-1", "i-2"}}
+
+	if err := result.CheckChurn(2); err != nil {
+		t.Errorf("CheckChurn(2) = %v, want nil", err)
+	}
+	if err := result.CheckChurn(-1); err != nil {
+		t.Errorf("CheckChurn(-1) = %v, want nil", err)
+	}
+	err := result.CheckChurn(1)
+	if !errors.Is(err, ErrChurnExceeded) || !strings.Contains(err.Error(), "i-1, i-2") {
+		t.Errorf("CheckChurn(1) = %v, want ErrChurnExceeded listing i-1, i-2", err)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"sort"
+	"time"
+)
+
+// StateStats summarizes the time the launched instances spent in one EC2 instance state.
+type StateStats struct {
+	State     string
+	Instances int
+	Min       time.Duration
+	Median    time.Duration
+	Max       time.Duration
+}
+
+// InstanceStateStats summarizes InstanceStateDurations per state, sorted by state name.
+func (r *BenchmarkResult) InstanceStateStats() []StateStats {
+	var stats []StateStats
+	for state, durations := range r.InstanceStateDurations {
+		if len(durations) == 0 {
+			continue
+		}
+		stats = append(stats, durationStats(state, durations))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].State < stats[j].State })
+	return stats
+}
+
+// durationStats returns the minimum, median and maximum of a non-empty set of durations observed in one state.
+func durationStats(state string, durations []time.Duration) StateStats {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return StateStats{
+		State:     state,
+		Instances: len(sorted),
+		Min:       sorted[0],
+		Median:    sorted[len(sorted)/2],
+		Max:       sorted[len(sorted)-1],
+	}
+}
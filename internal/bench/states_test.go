@@ -0,0 +1,27 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestInstanceStateStats checks that the durations of each state are summarized into min, median and max, sorted by state.
+func TestInstanceStateStats(t *testing.T) {
+	r := &BenchmarkResult{InstanceStateDurations: map[string][]time.Duration{
+		"pending":  {12 * time.Second, 8 * time.Second, 30 * time.Second},
+		"stopping": {5 * time.Second},
+		"running":  {},
+	}}
+
+	want := []StateStats{
+		{State: "pending", Instances: 3, Min: 8 * time.Second, Median: 12 * time.Second, Max: 30 * time.Second},
+		{State: "stopping", Instances: 1, Min: 5 * time.Second, Median: 5 * time.Second, Max: 5 * time.Second},
+	}
+	if got := r.InstanceStateStats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("InstanceStateStats() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import "time"
+
+// terminationBatchWindow is the longest gap between two observed terminations that still counts as the same batch.
+const terminationBatchWindow = 5 * time.Second
+
+// TerminationSample is a point of the termination series: the number of instances still running at a given
+// time after the scale-down started.
+type TerminationSample struct {
+	Elapsed time.Duration
+	Running int
+}
+
+// TerminationBatch is a group of instances that terminated close together, e.g. within one disruption budget window.
+type TerminationBatch struct {
+	// Start is the time after the scale-down started at which the first instance of the batch was seen terminated.
+	Start time.Duration
+	// Instances is the number of instances terminated in the batch.
+	Instances int
+}
+
+// TerminationBatches groups the drops of the termination series into batches. Drops seen within
+// terminationBatchWindow of the previous drop are merged into the same batch.
+func (r *BenchmarkResult) TerminationBatches() []TerminationBatch {
+	var batches []TerminationBatch
+	var lastDrop time.Duration
+	for i := 1; i < len(r.TerminationSeries); i++ {
+		prev, cur := r.TerminationSeries[i-1], r.TerminationSeries[i]
+		terminated := prev.Running - cur.Running
+		if terminated <= 0 {
+			continue
+		}
+		if len(batches) > 0 && cur.Elapsed-lastDrop <= terminationBatchWindow {
+			batches[len(batches)-1].Instances += terminated
+		} else {
+			batches = append(batches, TerminationBatch{Start: cur.Elapsed, Instances: terminated})
+		}
+		lastDrop = cur.Elapsed
+	}
+	return batches
+}
+
+// MaxTerminationBatch returns the size of the largest batch, i.e. the most instances terminated concurrently.
+func MaxTerminationBatch(batches []TerminationBatch) int {
+	max := 0
+	for _, batch := range batches {
+		if batch.Instances > max {
+			max = batch.Instances
+		}
+	}
+	return max
+}
+
+// MeanTerminationBatchInterval returns the average time between the starts of consecutive batches,
+// or 0 when there are fewer than two batches.
+func MeanTerminationBatchInterval(batches []TerminationBatch) time.Duration {
+	if len(batches) < 2 {
+		return 0
+	}
+	return (batches[len(batches)-1].Start - batches[0].Start) / time.Duration(len(batches)-1)
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestTerminationBatches checks that drops close together are merged and the batch statistics derived from them.
+func TestTerminationBatches(t *testing.T) {
+	result := BenchmarkResult{TerminationSeries: []TerminationSample{
+		{Elapsed: 0, Running: 6},
+		{Elapsed: 30 * time.Second, Running: 4},
+		{Elapsed: 33 * time.Second, Running: 3},
+		{Elapsed: 90 * time.Second, Running: 1},
+		{Elapsed: 150 * time.Second, Running: 0},
+	}}
+
+	batches := result.TerminationBatches()
+	want := []TerminationBatch{
+		{Start: 30 * time.Second, Instances: 3},
+		{Start: 90 * time.Second, Instances: 2},
+		{Start: 150 * time.Second, Instances: 1},
+	}
+	if !reflect.DeepEqual(batches, want) {
+		t.Fatalf("TerminationBatches() = %v, want %v", batches, want)
+	}
+	if got := MaxTerminationBatch(batches); got != 3 {
+		t.Errorf("MaxTerminationBatch() = %d, want 3", got)
+	}
+	if got := MeanTerminationBatchInterval(batches); got != 60*time.Second {
+		t.Errorf("MeanTerminationBatchInterval() = %v, want 1m0s", got)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"sort"
+	"time"
+)
+
+// TransitionTime estimates when a polled condition became true, so phase durations are not quantized to the poll
+// interval. The condition was not met at previousPoll and met at currentPoll (the start of the poll that observed it),
+// so it became true in between. reported is the time the API recorded for the transition, e.g. a condition's
+// LastTransitionTime; it is clamped into the poll window to absorb its second precision and clock skew. When reported
+// is zero, currentPoll is returned. The result keeps the monotonic clock reading of the poll times.
+func TransitionTime(previousPoll, currentPoll, reported time.Time) time.Time {
+	if reported.IsZero() || !reported.Before(currentPoll) {
+		return currentPoll
+	}
+	if !reported.After(previousPoll) {
+		return previousPoll
+	}
+	return previousPoll.Add(reported.Sub(previousPoll))
+}
+
+// NthEarliest returns the n-th earliest (1-based) of the given times, i.e. the time at which n of them had passed.
+// It returns the zero time if there are fewer than n times.
+func NthEarliest(times []time.Time, n int) time.Time {
+	if n < 1 || len(times) < n {
+		return time.Time{}
+	}
+	sorted := append([]time.Time(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+	return sorted[n-1]
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTransitionTime checks that the reported transition time is used within the poll window and clamped outside it.
+func TestTransitionTime(t *testing.T) {
+	previous := time.Now()
+	current := previous.Add(5 * time.Second)
+
+	tests := []struct {
+		name     string
+		reported time.Time
+		want     time.Duration
+	}{
+		{"no reported time", time.Time{}, 5 * time.Second},
+		{"within window", previous.Add(2 * time.Second), 2 * time.Second},
+		{"before window", previous.Add(-time.Second), 0},
+		{"after window", current.Add(time.Second), 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := TransitionTime(previous, current, tt.reported).Sub(previous); got != tt.want {
+			t.Errorf("%s: TransitionTime() = previous + %v, want previous + %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestNthEarliest checks that the n-th earliest time is returned regardless of order.
+func TestNthEarliest(t *testing.T) {
+	base := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	times := []time.Time{base.Add(3 * time.Second), base, base.Add(time.Second)}
+
+	if got := NthEarliest(times, 2); !got.Equal(base.Add(time.Second)) {
+		t.Errorf("NthEarliest(2) = %v, want %v", got, base.Add(time.Second))
+	}
+	if got := NthEarliest(times, 4); !got.IsZero() {
+		t.Errorf("NthEarliest(4) = %v, want the zero time", got)
+	}
+}
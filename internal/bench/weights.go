@@ -0,0 +1,68 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PhaseNames are the phase names accepted by ParsePhaseWeights, in the order the phases run.
+var PhaseNames = []string{"provision", "register", "ready", "dereg", "terminate"}
+
+// PhaseDuration returns the duration of the phase with the given name from PhaseNames, or 0 for an unknown name.
+func (r *BenchmarkResult) PhaseDuration(phase string) time.Duration {
+	switch phase {
+	case "provision":
+		return r.InstanceProvisioningTime
+	case "register":
+		return r.InstanceRegistrationTime
+	case "ready":
+		return r.PodReadinessTime
+	case "dereg":
+		return r.NodeDeregistrationTime
+	case "terminate":
+		return r.InstanceTerminationTime
+	}
+	return 0
+}
+
+// ParsePhaseWeights parses a phase weight specification of the form "provision=2,dereg=1" into a weight per phase.
+// Phases that are not listed get a weight of 0 and do not count towards the weighted score.
+func ParsePhaseWeights(spec string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(spec, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("Phase weight must have the form phase=weight, got %q", part)
+		}
+		known := false
+		for _, phase := range PhaseNames {
+			known = known || phase == name
+		}
+		if !known {
+			return nil, fmt.Errorf("Unknown phase %q, expected one of %s", name, strings.Join(PhaseNames, ", "))
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("Phase weight must be a non-negative number, got %q", value)
+		}
+		weights[name] = weight
+	}
+	return weights, nil
+}
+
+// WeightedScore returns the weighted sum of the result's phase durations in seconds, and the contribution of each
+// phase to it. A lower score is better.
+func (r *BenchmarkResult) WeightedScore(weights map[string]float64) (float64, map[string]float64) {
+	total := 0.0
+	contributions := make(map[string]float64, len(weights))
+	for phase, weight := range weights {
+		contributions[phase] = weight * r.PhaseDuration(phase).Seconds()
+		total += contributions[phase]
+	}
+	return total, contributions
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package bench
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestParsePhaseWeights checks that valid specifications are parsed and invalid ones rejected.
+func TestParsePhaseWeights(t *testing.T) {
+	weights, err := ParsePhaseWeights("provision=2, dereg=0.5")
+	if err != nil {
+		t.Fatalf("ParsePhaseWeights() returned error: %v", err)
+	}
+	if want := map[string]float64{"provision": 2, "dereg": 0.5}; !reflect.DeepEqual(weights, want) {
+		t.Errorf("ParsePhaseWeights() = %v, want %v", weights, want)
+	}
+
+	for _, spec := range []string{"provision", "boot=1", "ready=-1", "ready=fast"} {
+		if _, err := ParsePhaseWeights(spec); err == nil {
+			t.Errorf("ParsePhaseWeights(%q) returned nil error", spec)
+		}
+	}
+}
+
+// TestWeightedScore checks that the score is the weighted sum of the phase durations in seconds.
+func TestWeightedScore(t *testing.T) {
+	result := BenchmarkResult{InstanceProvisioningTime: 10 * time.Second, NodeDeregistrationTime: 30 * time.Second}
+	total, contributions := result.WeightedScore(map[string]float64{"provision": 2, "dereg": 1})
+	if total != 50 {
+		t.Errorf("WeightedScore() total = %g, want 50", total)
+	}
+	if want := map[string]float64{"provision": 20, "dereg": 30}; !reflect.DeepEqual(contributions, want) {
+		t.Errorf("WeightedScore() contributions = %v, want %v", contributions, want)
+	}
+}
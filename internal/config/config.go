@@ -1,5 +0,0 @@
-package config
-
-import "time"
-
-var ProgramStartTime = time.Now()
@@ -9,21 +9,35 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/utilities"
 	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/aws"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
+// FargateLabelSelector matches the nodes EKS registers for pods scheduled onto Fargate.
+const FargateLabelSelector = "eks.amazonaws.com/compute-type=fargate"
+
+// InstanceFamilyLabel is the well-known label Karpenter sets to the instance family (e.g. c7i) of the nodes it launches.
+const InstanceFamilyLabel = "karpenter.k8s.aws/instance-family"
+
 // CheckNodeGroupEmpty checks whether a specified node group within a Kubernetes cluster
 // has any nodes. It returns true if the node group is empty, and false otherwise.
 // This check is useful for ensuring that a node group can be safely manipulated without
@@ -39,6 +53,118 @@ func CheckNodeGroupEmpty(clientset kubernetes.Interface, labelSelector string) (
 	return len(nodes.Items) == 0, nil
 }
 
+// CountNodes returns the number of nodes currently registered to the cluster that match the given label selector.
+func CountNodes(clientset kubernetes.Interface, labelSelector string) (int, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Failed to list nodes with selector %s: %w", labelSelector, err)
+	}
+
+	return len(nodes.Items), nil
+}
+
+// FindNodesWithHeadroom returns the names of the nodes matching the label selector that still have enough unrequested
+// CPU to host a pod requesting cpuRequest. If any are found, a benchmark scale-up may be absorbed by existing
+// capacity instead of triggering the autoscaler, making the measurement meaningless.
+func FindNodesWithHeadroom(clientset kubernetes.Interface, labelSelector, cpuRequest string) ([]string, error) {
+	request, err := resource.ParseQuantity(cpuRequest)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid CPU request %q: %w", cpuRequest, err)
+	}
+
+	nodes, err := clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list nodes with selector %s: %w", labelSelector, err)
+	}
+	if len(nodes.Items) == 0 {
+		return nil, nil
+	}
+
+	pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list pods: %w", err)
+	}
+
+	requested := map[string]int64{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			requested[pod.Spec.NodeName] += container.Resources.Requests.Cpu().MilliValue()
+		}
+	}
+
+	var nodeNames []string
+	for _, node := range nodes.Items {
+		free := node.Status.Allocatable.Cpu().MilliValue() - requested[node.Name]
+		if free >= request.MilliValue() {
+			nodeNames = append(nodeNames, node.Name)
+		}
+	}
+
+	return nodeNames, nil
+}
+
+// SumAllocatable returns the total allocatable CPU (in millicores) and memory (in bytes) across the nodes
+// matching the label selector, as reported by each node's status.
+func SumAllocatable(clientset kubernetes.Interface, labelSelector string) (int64, int64, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("Failed to list nodes with selector %s: %w", labelSelector, err)
+	}
+
+	var cpuMillis, memoryBytes int64
+	for _, node := range nodes.Items {
+		cpuMillis += node.Status.Allocatable.Cpu().MilliValue()
+		memoryBytes += node.Status.Allocatable.Memory().Value()
+	}
+
+	return cpuMillis, memoryBytes, nil
+}
+
+// GetPodCPURequest returns the total CPU request (in millicores) of a single pod of the given deployment,
+// summed across the containers of its pod template.
+func GetPodCPURequest(clientset kubernetes.Interface, deploymentName, namespace string) (int64, error) {
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get deployment: %w", err)
+	}
+
+	var cpuMillis int64
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		cpuMillis += container.Resources.Requests.Cpu().MilliValue()
+	}
+
+	return cpuMillis, nil
+}
+
+// ReplicasForTotalCPU returns the number of pods requesting cpuRequest each that together request at least totalCPU,
+// and whether they request more than totalCPU because it is not a multiple of cpuRequest.
+func ReplicasForTotalCPU(totalCPU, cpuRequest string) (int, bool, error) {
+	total, err := resource.ParseQuantity(totalCPU)
+	if err != nil {
+		return 0, false, fmt.Errorf("Invalid total CPU %q: %w", totalCPU, err)
+	}
+	perPod, err := resource.ParseQuantity(cpuRequest)
+	if err != nil {
+		return 0, false, fmt.Errorf("Invalid CPU request %q: %w", cpuRequest, err)
+	}
+	totalMillis, perPodMillis := total.MilliValue(), perPod.MilliValue()
+	if totalMillis <= 0 || perPodMillis <= 0 {
+		return 0, false, fmt.Errorf("Total CPU %q and CPU request %q must both be positive", totalCPU, cpuRequest)
+	}
+
+	replicas := (totalMillis + perPodMillis - 1) / perPodMillis
+	return int(replicas), totalMillis%perPodMillis != 0, nil
+}
+
 // ScaleDeployment updates the number of replicas for a specified deployment within a given namespace.
 // It first retrieves the current deployment settings, then updates the replica count based on the input parameter.
 // The function logs whether the deployment was scaled up, down, or remained unchanged.
@@ -58,110 +184,461 @@ func ScaleDeployment(clientset kubernetes.Interface, deploymentName, namespace s
 	}
 
 	if int32(replicas) > *currentReplicas {
-		fmt.Printf("Deployment '%s' scaled up to %d replicas successfully.\n", deploymentName, replicas)
+		logging.Progress("Deployment scaled up", "deployment", deploymentName, "replicas", replicas)
 	} else if int32(replicas) < *currentReplicas {
-		fmt.Printf("Deployment '%s' scaled down to %d replicas successfully.\n", deploymentName, replicas)
+		logging.Progress("Deployment scaled down", "deployment", deploymentName, "replicas", replicas)
 	} else {
-		fmt.Printf("Deployment '%s' already has %d replicas. No scaling performed.\n", deploymentName, replicas)
+		logging.Progress("Deployment already has the replicas, no scaling performed", "deployment", deploymentName, "replicas", replicas)
 	}
 
 	return nil
 }
 
+// deploymentSettleTimeout bounds how long WaitForDeploymentSettled waits for the controller to observe the deployment.
+var deploymentSettleTimeout = 1 * time.Minute
+
+// WaitForDeploymentSettled waits until the deployment controller has observed the latest generation of the deployment,
+// so a pre-created deployment has been fully processed (including admission webhooks) before the timed scale-up.
+func WaitForDeploymentSettled(clientset kubernetes.Interface, deploymentName, namespace string) error {
+	startTime := time.Now()
+	for {
+		deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("Failed to get deployment: %w", err)
+		}
+		if deployment.Status.ObservedGeneration >= deployment.Generation {
+			logging.Progress("Deployment settled", "deployment", deploymentName)
+			return nil
+		}
+		if time.Since(startTime) >= deploymentSettleTimeout {
+			return fmt.Errorf("Timed out waiting for deployment '%s' to be observed by the deployment controller", deploymentName)
+		}
+		time.Sleep(1 * time.Second)
+	}
+}
+
+// GetReadyReplicas returns the number of ready replicas currently reported by the status of the given deployment.
+func GetReadyReplicas(clientset kubernetes.Interface, deploymentName, namespace string) (int, error) {
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get deployment: %w", err)
+	}
+
+	return int(deployment.Status.ReadyReplicas), nil
+}
+
+// DeploymentPodSelector returns the label selector of the given deployment's pods in its string form.
+func DeploymentPodSelector(clientset kubernetes.Interface, deploymentName, namespace string) (string, error) {
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("Failed to get deployment: %w", err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return "", fmt.Errorf("Invalid deployment selector: %w", err)
+	}
+	return selector.String(), nil
+}
+
+// FirstPodScheduledTime returns the earliest time at which a pod matching podSelector transitioned to PodScheduled=True
+// at or after since. It returns the zero time if no such pod was scheduled.
+func FirstPodScheduledTime(clientset kubernetes.Interface, namespace, podSelector string, since time.Time) (time.Time, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: podSelector})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Failed to list pods: %w", err)
+	}
+
+	var first time.Time
+	// Condition timestamps have second precision, so compare against the start of the second the scale-up began.
+	since = since.Truncate(time.Second)
+	for _, pod := range pods.Items {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type != corev1.PodScheduled || condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			scheduled := condition.LastTransitionTime.Time
+			if !scheduled.Before(since) && (first.IsZero() || scheduled.Before(first)) {
+				first = scheduled
+			}
+		}
+	}
+	return first, nil
+}
+
+// PodsOutsideNodes returns the scheduled pods matching podSelector whose node is not backed by one of the given
+// instances, going by the instance ID in its provider ID, as "<pod> (<node>)" sorted by pod name. Given the instances
+// launched since the run started, these are pods that landed on a pre-existing node, even one of the autoscaler's own
+// node pool, instead of one the run provisioned, e.g. because of a node selector or toleration mismatch.
+func PodsOutsideNodes(clientset kubernetes.Interface, namespace, podSelector string, instanceIDs []string) ([]string, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list nodes: %w", err)
+	}
+	launched := make(map[string]bool, len(instanceIDs))
+	for _, id := range instanceIDs {
+		launched[id] = true
+	}
+	expected := make(map[string]bool, len(nodes.Items))
+	for _, node := range nodes.Items {
+		if id, ok := instanceIDFromProviderID(node.Spec.ProviderID); ok && launched[id] {
+			expected[node.Name] = true
+		}
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: podSelector})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list pods: %w", err)
+	}
+	var outside []string
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" && !expected[pod.Spec.NodeName] {
+			outside = append(outside, fmt.Sprintf("%s (%s)", pod.Name, pod.Spec.NodeName))
+		}
+	}
+	sort.Strings(outside)
+	return outside, nil
+}
+
+// CountRunningPods returns the number of Running pods matching podSelector that are not being deleted, the number of
+// distinct nodes they run on, and the other matching pods as "<pod> (<phase>)" sorted by pod name. It confirms a
+// scale-up completed, as a deployment's ready count can briefly include pods that fail right after.
+func CountRunningPods(clientset kubernetes.Interface, namespace, podSelector string) (int, int, []string, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: podSelector})
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("Failed to list pods: %w", err)
+	}
+	running := 0
+	nodes := make(map[string]bool)
+	var others []string
+	for _, pod := range pods.Items {
+		switch {
+		case pod.DeletionTimestamp != nil:
+			others = append(others, fmt.Sprintf("%s (Terminating)", pod.Name))
+		case pod.Status.Phase == corev1.PodRunning && pod.Spec.NodeName != "":
+			running++
+			nodes[pod.Spec.NodeName] = true
+		default:
+			others = append(others, fmt.Sprintf("%s (%s)", pod.Name, pod.Status.Phase))
+		}
+	}
+	sort.Strings(others)
+	return running, len(nodes), others, nil
+}
+
+// PendingPodReasons returns why the pending pods matching podSelector are not scheduled, as the distinct reasons and
+// messages of their PodScheduled=False conditions with the number of pods affected, e.g.
+// "Unschedulable: 0/3 nodes are available: ... (4 pods)". Pods without such a condition are reported as unscheduled.
+func PendingPodReasons(clientset kubernetes.Interface, namespace, podSelector string) ([]string, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: podSelector})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list pods: %w", err)
+	}
+
+	counts := make(map[string]int)
+	var reasons []string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+		reason := "not scheduled yet"
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+				reason = fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+			}
+		}
+		if counts[reason] == 0 {
+			reasons = append(reasons, reason)
+		}
+		counts[reason]++
+	}
+
+	sort.Strings(reasons)
+	for i, reason := range reasons {
+		reasons[i] = fmt.Sprintf("%s (%d pods)", reason, counts[reason])
+	}
+	return reasons, nil
+}
+
 // MonitorInstanceRegistration monitors the registration of instances as nodes in the Kubernetes API.
 // It waits until nodes with the specified tag key and value appear in the Kubernetes cluster and become ready.
-// The function returns the duration it took for the nodes to become ready for scheduling pods.
-func MonitorInstanceRegistration(clientset *kubernetes.Clientset, labelSelector string, expectedNodeCount int) (time.Duration, error) {
-	fmt.Println("Monitoring instance registration to k8s API...")
+// The function returns the duration it took for the nodes to become ready for scheduling pods, and the number of ready
+// nodes the final poll counted, which can exceed the expected count when more nodes joined in the meantime.
+// A node counts as ready once its NodeReady condition is true and it meets the additional readiness requirements,
+// from the time the first poll saw it meet them all, refined by its condition transitions unless taints are required to
+// be absent, whose removal is not timestamped.
+func MonitorInstanceRegistration(clientset kubernetes.Interface, labelSelector string, expectedNodeCount int, readiness NodeReadiness) (time.Duration, int, error) {
+	logging.Progress("Monitoring instance registration to k8s API")
 	startTime := time.Now()
 
 	// Setup a timeout mechanism
 	timeout := time.After(10 * time.Minute) // Adjust the timeout duration as needed
 	ticker := time.NewTicker(5 * time.Second) // Check status every 5 seconds
 	defer ticker.Stop()
+	previousPoll := startTime
+	readyAt := map[string]time.Time{}
 
 	for {
 			select {
 			case <-timeout:
-					return time.Since(startTime), fmt.Errorf("Timed out waiting for %d nodes to become ready", expectedNodeCount)
+					return time.Since(startTime), 0, fmt.Errorf("Timed out waiting for %d nodes to become ready: %w", expectedNodeCount, bench.ErrRegistrationTimeout)
 			case <-ticker.C:
+					pollTime := time.Now()
 					nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
 							LabelSelector: labelSelector,
 					})
 					if err != nil {
-							return 0, fmt.Errorf("Failed to list nodes with selector %s: %w", labelSelector, err)
+							return 0, 0, fmt.Errorf("Failed to list nodes with selector %s: %w", labelSelector, err)
 					}
 
 					readyNodes := 0
+					var readyTimes []time.Time
 					for _, node := range nodes.Items {
-							for _, condition := range node.Status.Conditions {
-									if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
-											readyNodes++
-											break // Only count each node once
+							since, ready := readiness.readySince(node)
+							if !ready {
+									continue
+							}
+							if _, seen := readyAt[node.Name]; !seen {
+									if len(readiness.AbsentTaints) > 0 {
+											since = time.Time{}
 									}
+									readyAt[node.Name] = bench.TransitionTime(previousPoll, pollTime, since)
 							}
+							readyNodes++
+							readyTimes = append(readyTimes, readyAt[node.Name])
 					}
 
 					if readyNodes >= expectedNodeCount {
-							fmt.Printf("%d nodes registered to k8s API.\n", readyNodes)
-							return time.Since(startTime), nil
+							logging.Progress("Nodes registered to k8s API", "nodes", readyNodes)
+							// Measure up to the moment the expected node became ready rather than the tick that observed it.
+							registered := bench.NthEarliest(readyTimes, expectedNodeCount)
+							return registered.Sub(startTime), readyNodes, nil
 					}
+					previousPoll = pollTime
 					// Continues loop until timeout or condition met
 			}
 	}
 }
 
+// Verbosity controls whether the monitors print their periodic status lines, e.g. the nodes still registered.
+type Verbosity int
+
+const (
+	// Normal prints the periodic status lines.
+	Normal Verbosity = iota
+	// Quiet suppresses the periodic status lines, e.g. when several benchmarks are scripted. The messages marking
+	// the start and end of each phase are still printed.
+	Quiet
+)
+
+// status logs a periodic status line, with the fields given as alternating keys and values, unless v is Quiet.
+func (v Verbosity) status(msg string, args ...any) {
+	if v != Quiet {
+		logging.Progress(msg, args...)
+	}
+}
+
+// podStatusInterval and nodeStatusInterval are how often the monitors print the status of the pods, and of the nodes
+// and instances, while they wait.
+var (
+	podStatusInterval  = 20 * time.Second
+	nodeStatusInterval = 15 * time.Second
+)
+
 // WaitForPodsReady waits until all pods in a deployment reach a 'Ready' state.
-// It periodically checks the deployment's status and logs the current count of ready pods against the total number of replicas until all pods are ready.
-func WaitForPodsReady(clientset kubernetes.Interface, deploymentName, namespace string, replicas int) (time.Duration, error) {
-	fmt.Println("Waiting for pods to become ready...")
+// It periodically checks the deployment's status and, unless verbosity is Quiet, logs the current count of ready pods against the total number of replicas until all pods are ready.
+// It returns an error wrapping bench.ErrSchedulingFailed if the pods are not all ready within timeout.
+func WaitForPodsReady(clientset kubernetes.Interface, deploymentName, namespace string, replicas int, timeout time.Duration, verbosity Verbosity) (time.Duration, error) {
+	logging.Progress("Waiting for pods to become ready")
 	startTime := time.Now()
-	logTicker := time.NewTicker(20 * time.Second)
+	logTicker := time.NewTicker(podStatusInterval)
 	defer logTicker.Stop()
+	previousPoll := startTime
 
 	for {
+		if time.Since(startTime) >= timeout {
+			return time.Since(startTime), fmt.Errorf("Timed out waiting for %d pods to become ready within %v: %w", replicas, timeout, bench.ErrSchedulingFailed)
+		}
+
+		pollTime := time.Now()
 		deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
 		if err != nil {
 			return 0, fmt.Errorf("Failed to get updated deployment: %w", err)
 		}
 
 		if deployment.Status.ReadyReplicas == int32(replicas) {
-			fmt.Println("All pods are ready.")
-			break
+			logging.Progress("All pods are ready")
+			// Measure up to the moment the last pod became ready rather than the poll that observed it.
+			ready := bench.TransitionTime(previousPoll, pollTime, podsReadyTime(clientset, deployment, replicas))
+			return ready.Sub(startTime), nil
 		}
 
+		if err := checkCrashingPods(clientset, deployment); err != nil {
+			return time.Since(startTime), err
+		}
+		previousPoll = pollTime
+
 		select {
 		case <-logTicker.C:
-			fmt.Printf("Waiting... %d/%d pods are ready.\n", deployment.Status.ReadyReplicas, replicas)
+			verbosity.status("Waiting for pods to become ready", "ready", deployment.Status.ReadyReplicas, "replicas", replicas)
 		default:
 			time.Sleep(1 * time.Second)
 		}
 	}
+}
+
+// podsReadyTime returns the time at which replicas of the deployment's pods had become ready, from the pods' Ready
+// condition transitions. It returns the zero time if the pods cannot be listed.
+func podsReadyTime(clientset kubernetes.Interface, deployment *appsv1.Deployment, replicas int) time.Time {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return time.Time{}
+	}
+	pods, err := clientset.CoreV1().Pods(deployment.Namespace).List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return time.Time{}
+	}
+
+	var readyTimes []time.Time
+	for _, pod := range pods.Items {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				readyTimes = append(readyTimes, condition.LastTransitionTime.Time)
+			}
+		}
+	}
+	return bench.NthEarliest(readyTimes, replicas)
+}
+
+// crashRestartThreshold is the number of restarts after which a container is considered crash looping even before
+// the kubelet reports CrashLoopBackOff.
+const crashRestartThreshold = 3
+
+// checkCrashingPods returns an error wrapping bench.ErrSchedulingFailed if a container of the deployment's pods is in
+// CrashLoopBackOff or has restarted crashRestartThreshold times, with the reason and exit code of its last termination,
+// so a bad image or command fails the readiness phase immediately instead of waiting for the timeout.
+func checkCrashingPods(clientset kubernetes.Interface, deployment *appsv1.Deployment) error {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("Invalid deployment selector: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods(deployment.Namespace).List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fmt.Errorf("Failed to list pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			crashLooping := status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff"
+			if !crashLooping && status.RestartCount < crashRestartThreshold {
+				continue
+			}
+			lastTermination := "unknown reason"
+			if terminated := status.LastTerminationState.Terminated; terminated != nil {
+				lastTermination = fmt.Sprintf("%s, exit code %d", terminated.Reason, terminated.ExitCode)
+			}
+			return fmt.Errorf("Container %s of pod %s is crash looping after %d restarts (last termination: %s): %w",
+				status.Name, pod.Name, status.RestartCount, lastTermination, bench.ErrSchedulingFailed)
+		}
+	}
+	return nil
+}
+
+// stabilityPollInterval is how often WaitForPodsStable checks the ready replica count during the stabilization window.
+var stabilityPollInterval = 1 * time.Second
+
+// maxStabilityFlaps is the number of times the pods may flap during stabilization before WaitForPodsStable gives up.
+const maxStabilityFlaps = 3
+
+// WaitForPodsStable confirms that all replicas of the deployment stay ready for the whole stabilization window.
+// If a pod flaps, it waits for readiness again via WaitForPodsReady and restarts the window. It returns an error
+// wrapping bench.ErrSchedulingFailed if the pods keep flapping or do not become ready again within readinessTimeout.
+func WaitForPodsStable(clientset kubernetes.Interface, deploymentName, namespace string, replicas int, window, readinessTimeout time.Duration, verbosity Verbosity) error {
+	logging.Progress("Confirming pods stay ready before scale-down", "window", window)
+	flaps := 0
+	windowStart := time.Now()
+
+	for time.Since(windowStart) < window {
+		readyReplicas, err := GetReadyReplicas(clientset, deploymentName, namespace)
+		if err != nil {
+			return err
+		}
+
+		if readyReplicas < replicas {
+			flaps++
+			if flaps > maxStabilityFlaps {
+				return fmt.Errorf("Pods flapped %d times during the stabilization window: %w", flaps, bench.ErrSchedulingFailed)
+			}
+			logging.Warn("Pods not ready during the stabilization window, waiting for readiness again", "ready", readyReplicas, "replicas", replicas)
+			if _, err := WaitForPodsReady(clientset, deploymentName, namespace, replicas, readinessTimeout, verbosity); err != nil {
+				return err
+			}
+			windowStart = time.Now()
+			continue
+		}
+
+		time.Sleep(stabilityPollInterval)
+	}
 
-	return time.Since(startTime), nil
+	logging.Progress("Pods are stable")
+	return nil
 }
 
-// MonitorNodeDeregistration observes the deregistration of nodes from the Kubernetes API based on label selectors.
-// It continuously checks and logs the registered nodes until none are left, signaling complete deregistration.
-func MonitorNodeDeregistration(clientset *kubernetes.Clientset, nodeSelectorKey, nodeSelectorValue string, deregChan chan<- time.Duration, deregErrChan chan<- error) {
+// podTerminationPollInterval is how often MonitorPodTermination lists the remaining pods.
+var podTerminationPollInterval = 1 * time.Second
+
+// MonitorPodTermination observes the benchmark pods matching podSelector disappear after the scale-down, and sends
+// the time until the last of them was gone to podTermChan. It isolates the graceful termination of the pods, including
+// their termination grace period and evictions held back by a PodDisruptionBudget, from node deregistration.
+func MonitorPodTermination(clientset kubernetes.Interface, namespace, podSelector string, podTermChan chan<- time.Duration, errChan chan<- error) {
 	startTime := time.Now()
-	logTicker := time.NewTicker(15 * time.Second)
+	logging.Progress("Monitoring pod termination")
+
+	for {
+		// Deleted pods carry no transition time, so the start of the poll that observed them gone is used.
+		pollTime := time.Now()
+		pods, err := clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: podSelector})
+		if err != nil {
+			errChan <- fmt.Errorf("Failed to list pods during termination: %w", err)
+			return
+		}
+
+		if len(pods.Items) == 0 {
+			logging.Progress("All pods have terminated")
+			podTermChan <- pollTime.Sub(startTime)
+			return
+		}
+		time.Sleep(podTerminationPollInterval)
+	}
+}
+
+// MonitorNodeDeregistration observes the deregistration of nodes from the Kubernetes API based on a label selector.
+// It continuously checks and, unless verbosity is Quiet, logs the registered nodes until no more than remainingNodes are left, signaling complete deregistration.
+// remainingNodes is normally 0 and only differs when nodes outside the benchmark share the selector (e.g. Fargate).
+// When instanceIDs is not nil, only the nodes of those EC2 instances, e.g. the ones tagged with the run ID, are counted.
+func MonitorNodeDeregistration(clientset kubernetes.Interface, labelSelector string, instanceIDs []string, remainingNodes int, verbosity Verbosity, deregChan chan<- time.Duration, deregErrChan chan<- error) {
+	startTime := time.Now()
+	logTicker := time.NewTicker(nodeStatusInterval)
 	defer logTicker.Stop()
 
-	fmt.Println("Monitoring node deregistration from k8s API...")
+	logging.Progress("Monitoring node deregistration from k8s API")
 
 	for {
+		// Nodes that are gone carry no transition time, so the start of the poll that observed it is used.
+		pollTime := time.Now()
 		nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
-			LabelSelector: fmt.Sprintf("%s=%s", nodeSelectorKey, nodeSelectorValue),
+			LabelSelector: labelSelector,
 		})
 		if err != nil {
 			deregErrChan <- fmt.Errorf("Failed to list nodes during deregistration: %w", err)
 			return
 		}
+		if instanceIDs != nil {
+			nodes.Items = instanceNodes(nodes.Items, instanceIDs)
+		}
 
-		if len(nodes.Items) == 0 {
-			fmt.Println("All nodes have been deregistered from k8s API.")
-			deregChan <- time.Since(startTime)
+		if len(nodes.Items) <= remainingNodes {
+			logging.Progress("All nodes have been deregistered from k8s API")
+			deregChan <- pollTime.Sub(startTime)
 			return
 		}
 
@@ -171,33 +648,111 @@ func MonitorNodeDeregistration(clientset *kubernetes.Clientset, nodeSelectorKey,
 			for _, node := range nodes.Items {
 				nodeNames = append(nodeNames, node.Name)
 			}
-			fmt.Printf("Nodes still registered to the cluster: %s\n", strings.Join(nodeNames, ", "))
+			verbosity.status("Nodes still registered to the cluster", "nodes", strings.Join(nodeNames, ", "))
 		default:
 			time.Sleep(1 * time.Second)
 		}
 	}
 }
 
+// instanceNodes returns the nodes whose spec.providerID names one of the EC2 instances.
+func instanceNodes(nodes []corev1.Node, instanceIDs []string) []corev1.Node {
+	ids := make(map[string]bool, len(instanceIDs))
+	for _, id := range instanceIDs {
+		ids[id] = true
+	}
+	var matched []corev1.Node
+	for _, node := range nodes {
+		if id, ok := instanceIDFromProviderID(node.Spec.ProviderID); ok && ids[id] {
+			matched = append(matched, node)
+		}
+	}
+	return matched
+}
+
+// TerminationStats collects what MonitorNodeTermination observed besides the termination time.
+type TerminationStats struct {
+	// TransientErrors is the number of DescribeInstances failures that were tolerated.
+	TransientErrors int
+	// Series records the number of instances still running each time it changed, starting with the first poll.
+	Series []bench.TerminationSample
+}
+
+// terminationStartupGrace is how long MonitorNodeTermination keeps polling when it sees no instances before any of the
+// launched instances has been observed, in case they are not yet visible through DescribeInstances.
+var terminationStartupGrace = 30 * time.Second
+
 // MonitorNodeTermination keeps an eye on the termination process of EC2 instances, ensuring all tagged instances are terminated.
-// It logs the status of running instances and waits until no tagged instances are left running.
-func MonitorNodeTermination(ec2Svc *ec2.EC2, tagKey, tagValue string, termChan chan<- time.Duration, termErrChan chan<- error) {
-	fmt.Println("Monitoring EC2 instance termination...")
+// It logs the status of running instances unless verbosity is Quiet, and waits until no tagged instances are left running. To avoid a false instant
+// success when it races the provisioning phase, no instances are only accepted as termination once one of launchedIDs has
+// been seen, or after terminationStartupGrace has passed. The termination is timed by the first poll that saw no
+// instances after the last one that did, so the grace is not added to it.
+// Up to maxTransientErrors consecutive DescribeInstances failures are tolerated with a warning before giving up, so a flaky
+// API does not abort a nearly-complete measurement. Tolerated failures and the timestamped series of running instance
+// counts are recorded in stats, which is final once a value has been sent on termChan or termErrChan.
+// Only instances launched after since (the start of the benchmark run) are monitored, across the regions of the clients.
+// When events is not nil, the termination is timed by the terminated state-change events of the instances the last
+// poll still saw, when one was received for each of them.
+func MonitorNodeTermination(ec2Svcs []*ec2.EC2, tagKey, tagValue string, since time.Time, launchedIDs []string, maxTransientErrors int, verbosity Verbosity, events *aws.InstanceEvents, stats *TerminationStats, termChan chan<- time.Duration, termErrChan chan<- error) {
+	logging.Progress("Monitoring EC2 instance termination")
 	startTime := time.Now()
-	logTicker := time.NewTicker(15 * time.Second)
+	logTicker := time.NewTicker(nodeStatusInterval)
 	defer logTicker.Stop()
+	consecutiveErrors := 0
+	launchedSeen := len(launchedIDs) == 0
+	previousPoll := startTime
+	var previousIDs []string
+	// firstEmptyPoll is the first poll that saw no instances after the last one that did, which is when the termination
+	// is observed even if the startup grace kept the monitor polling after it.
+	var firstEmptyPoll time.Time
 
 	for {
-		instances, err := aws.GetEC2Instances(ec2Svc, "tag:"+tagKey, tagValue)
+		pollTime := time.Now()
+		instances, err := aws.GetEC2Instances(ec2Svcs, "tag:"+tagKey, tagValue, since)
 		if err != nil {
-			termErrChan <- fmt.Errorf("Failed to list nodes: %w", err)
-			return
+			stats.TransientErrors++
+			consecutiveErrors++
+			if consecutiveErrors > maxTransientErrors {
+				termErrChan <- fmt.Errorf("Failed to list nodes after %d consecutive errors: %w", consecutiveErrors, err)
+				return
+			}
+			logging.Warn("Failed to list EC2 instances, retrying", "consecutive_errors", consecutiveErrors, "tolerated", maxTransientErrors, "error", err)
+			time.Sleep(time.Duration(consecutiveErrors) * time.Second)
+			continue
+		}
+		consecutiveErrors = 0
+
+		if !launchedSeen && aws.AnyInstancePresent(launchedIDs, instances) {
+			launchedSeen = true
+		}
+		if len(instances) > 0 {
+			firstEmptyPoll = time.Time{}
+		} else if firstEmptyPoll.IsZero() {
+			firstEmptyPoll = pollTime
+		}
+
+		if len(instances) == 0 && !launchedSeen && time.Since(startTime) < terminationStartupGrace {
+			time.Sleep(1 * time.Second)
+			continue
+		}
+
+		if n := len(stats.Series); n == 0 || stats.Series[n-1].Running != len(instances) {
+			stats.Series = append(stats.Series, bench.TerminationSample{Elapsed: pollTime.Sub(startTime), Running: len(instances)})
 		}
 
 		if len(instances) == 0 {
-			fmt.Println("All EC2 instances have been terminated.")
-			termChan <- time.Since(startTime)
+			if !launchedSeen {
+				logging.Warn("None of the launched EC2 instances were seen while monitoring termination")
+			}
+			logging.Progress("All EC2 instances have been terminated")
+			terminated := firstEmptyPoll
+			if at, ok := events.LastStateTime(previousIDs, ec2.InstanceStateNameTerminated); ok {
+				terminated = bench.TransitionTime(previousPoll, firstEmptyPoll, at)
+			}
+			termChan <- terminated.Sub(startTime)
 			return
 		}
+		previousPoll, previousIDs = pollTime, aws.InstanceIDs(instances)
 
 		select {
 		case <-logTicker.C:
@@ -207,7 +762,7 @@ func MonitorNodeTermination(ec2Svc *ec2.EC2, tagKey, tagValue string, termChan c
 				instanceDetails = append(instanceDetails, detail)
 			}
 			if len(instanceDetails) > 0 {
-				fmt.Println("EC2 instances still running:", strings.Join(instanceDetails, ", "))
+				verbosity.status("EC2 instances still running", "instances", strings.Join(instanceDetails, ", "))
 			}
 		default:
 			time.Sleep(1 * time.Second)
@@ -215,100 +770,346 @@ func MonitorNodeTermination(ec2Svc *ec2.EC2, tagKey, tagValue string, termChan c
 	}
 }
 
-// GenerateDeployment creates a new Kubernetes deployment using specified parameters, including deployment name, namespace, and container configuration.
-// It sets up tolerations and node selectors for the deployment and logs the creation status.
-func GenerateDeployment(clientset kubernetes.Interface, deploymentName, namespace, containerName, containerImage, cpuRequest, tolerationKey, tolerationValue, nodeSelectorKey, nodeSelectorValue string, replicas int) error {
-	deploymentsClient := clientset.AppsV1().Deployments(namespace)
+// DeploymentConfig holds the parameters used by GenerateDeployment to build the benchmark deployment.
+// The memory request and limits are optional; when MemoryRequest, CPULimit or MemoryLimit are empty only the CPU
+// request is set on the container.
+type DeploymentConfig struct {
+	Name, Namespace                   string
+	ContainerName, ContainerImage     string
+	CPURequest, CPULimit, MemoryLimit string
+	// MemoryRequest is the memory request of the container, e.g. 4Gi, to benchmark memory-driven scale-ups.
+	MemoryRequest string
+	// GPURequest is the number of GPUs the container requests, set as both request and limit of the extended resource
+	// GPUResourceName, e.g. nvidia.com/gpu. No GPU is requested when it is 0.
+	GPURequest                         int
+	GPUResourceName                    string
+	TolerationKey, TolerationValue     string
+	NodeSelectorKey, NodeSelectorValue string
+	Replicas                           int
+	// RolloutStrategy is "RollingUpdate" or "Recreate"; the Kubernetes default (RollingUpdate) is used when empty.
+	// MaxUnavailable and MaxSurge are integers or percentages and only apply to RollingUpdate.
+	RolloutStrategy, MaxUnavailable, MaxSurge string
+	// ContainerPort is the TCP port the container declares. No port is declared when it is 0.
+	ContainerPort int
+	// InstanceFamily, when set, additionally requires nodes of this Karpenter instance family, e.g. c7i.
+	InstanceFamily string
+	// OnePodPerNode adds a required pod anti-affinity on the node hostname, so every replica lands on its own node.
+	OnePodPerNode bool
+	// Env holds the environment variables of the container, in order.
+	Env []corev1.EnvVar
+	// Tolerations are added to the toleration of TolerationKey and TolerationValue, for nodes carrying several taints.
+	Tolerations []corev1.Toleration
+	// PodLabels and PodAnnotations are added to the pod template, e.g. for node pools selecting on custom pod labels.
+	// The label the workload selects its pods by takes precedence over PodLabels.
+	PodLabels, PodAnnotations map[string]string
+}
+
+// buildResourceRequirements converts the CPU request, optional memory and GPU requests and optional limits of the
+// deployment config into container resource requirements, returning an error if the memory request or any limit is
+// not a valid quantity or the GPU request is negative.
+func buildResourceRequirements(cfg DeploymentConfig) (corev1.ResourceRequirements, error) {
+	requirements := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse(cfg.CPURequest),
+		},
+	}
+	if cfg.MemoryRequest != "" {
+		quantity, err := resource.ParseQuantity(cfg.MemoryRequest)
+		if err != nil {
+			return requirements, fmt.Errorf("Invalid memory request %q: %w", cfg.MemoryRequest, err)
+		}
+		requirements.Requests[corev1.ResourceMemory] = quantity
+	}
+
+	limits := corev1.ResourceList{}
+	if cfg.CPULimit != "" {
+		quantity, err := resource.ParseQuantity(cfg.CPULimit)
+		if err != nil {
+			return requirements, fmt.Errorf("Invalid CPU limit %q: %w", cfg.CPULimit, err)
+		}
+		limits[corev1.ResourceCPU] = quantity
+	}
+	if cfg.MemoryLimit != "" {
+		quantity, err := resource.ParseQuantity(cfg.MemoryLimit)
+		if err != nil {
+			return requirements, fmt.Errorf("Invalid memory limit %q: %w", cfg.MemoryLimit, err)
+		}
+		limits[corev1.ResourceMemory] = quantity
+	}
+	if cfg.GPURequest < 0 {
+		return requirements, fmt.Errorf("GPU request must be a positive number of GPUs, got %d", cfg.GPURequest)
+	}
+	if cfg.GPURequest > 0 {
+		// Extended resources such as GPUs cannot be overcommitted, so Kubernetes requires the limit and request to match.
+		gpus := *resource.NewQuantity(int64(cfg.GPURequest), resource.DecimalSI)
+		requirements.Requests[corev1.ResourceName(cfg.GPUResourceName)] = gpus
+		limits[corev1.ResourceName(cfg.GPUResourceName)] = gpus
+	}
+	if len(limits) > 0 {
+		requirements.Limits = limits
+	}
+
+	return requirements, nil
+}
+
+// buildDeploymentStrategy converts the rollout settings of the deployment config into a deployment strategy,
+// returning an error for an unknown strategy, an invalid value or rolling update settings combined with Recreate.
+func buildDeploymentStrategy(cfg DeploymentConfig) (appsv1.DeploymentStrategy, error) {
+	strategy := appsv1.DeploymentStrategy{}
+	switch {
+	case cfg.RolloutStrategy == "" && cfg.MaxUnavailable == "" && cfg.MaxSurge == "":
+		return strategy, nil
+	case cfg.RolloutStrategy == "" || strings.EqualFold(cfg.RolloutStrategy, string(appsv1.RollingUpdateDeploymentStrategyType)):
+		strategy.Type = appsv1.RollingUpdateDeploymentStrategyType
+	case strings.EqualFold(cfg.RolloutStrategy, string(appsv1.RecreateDeploymentStrategyType)):
+		if cfg.MaxUnavailable != "" || cfg.MaxSurge != "" {
+			return strategy, fmt.Errorf("Max unavailable and max surge only apply to the RollingUpdate strategy")
+		}
+		strategy.Type = appsv1.RecreateDeploymentStrategyType
+		return strategy, nil
+	default:
+		return strategy, fmt.Errorf("Invalid rollout strategy %q, must be RollingUpdate or Recreate", cfg.RolloutStrategy)
+	}
+
+	rollingUpdate := &appsv1.RollingUpdateDeployment{}
+	if cfg.MaxUnavailable != "" {
+		value, err := parseIntOrPercent(cfg.MaxUnavailable)
+		if err != nil {
+			return strategy, fmt.Errorf("Invalid max unavailable: %w", err)
+		}
+		rollingUpdate.MaxUnavailable = &value
+	}
+	if cfg.MaxSurge != "" {
+		value, err := parseIntOrPercent(cfg.MaxSurge)
+		if err != nil {
+			return strategy, fmt.Errorf("Invalid max surge: %w", err)
+		}
+		rollingUpdate.MaxSurge = &value
+	}
+	if rollingUpdate.MaxUnavailable != nil || rollingUpdate.MaxSurge != nil {
+		strategy.RollingUpdate = rollingUpdate
+	}
+	return strategy, nil
+}
+
+// parseIntOrPercent parses an absolute number such as "1" or a percentage such as "25%".
+func parseIntOrPercent(value string) (intstr.IntOrString, error) {
+	if strings.HasSuffix(value, "%") {
+		if _, err := strconv.Atoi(strings.TrimSuffix(value, "%")); err != nil {
+			return intstr.IntOrString{}, fmt.Errorf("%q is not a valid percentage", value)
+		}
+		return intstr.FromString(value), nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return intstr.IntOrString{}, fmt.Errorf("%q is not a number or percentage", value)
+	}
+	return intstr.FromInt(number), nil
+}
+
+// buildPodTemplate builds the pod template shared by the generated workloads (Deployment or Job) from the deployment config.
+// It sets up the container with its resource requests and limits, its optional port, the toleration and the required node affinity.
+func buildPodTemplate(cfg DeploymentConfig, labels map[string]string) (corev1.PodTemplateSpec, error) {
+	resources, err := buildResourceRequirements(cfg)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+
+	var ports []corev1.ContainerPort
+	if cfg.ContainerPort != 0 {
+		ports = []corev1.ContainerPort{{ContainerPort: int32(cfg.ContainerPort), Protocol: corev1.ProtocolTCP}}
+	}
+
+	requirements := []corev1.NodeSelectorRequirement{
+		{Key: cfg.NodeSelectorKey, Operator: corev1.NodeSelectorOpIn, Values: []string{cfg.NodeSelectorValue}},
+	}
+	if cfg.InstanceFamily != "" {
+		requirements = append(requirements, corev1.NodeSelectorRequirement{Key: InstanceFamilyLabel, Operator: corev1.NodeSelectorOpIn, Values: []string{cfg.InstanceFamily}})
+	}
+
+	affinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: requirements,
+					},
+				},
+			},
+		},
+	}
+	if cfg.OnePodPerNode {
+		affinity.PodAntiAffinity = &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+					TopologyKey:   corev1.LabelHostname,
+				},
+			},
+		}
+	}
+
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      podTemplateLabels(cfg, labels),
+			Annotations: cfg.PodAnnotations,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:      cfg.ContainerName,
+					Image:     cfg.ContainerImage,
+					Resources: resources,
+					Ports:     ports,
+					Env:       cfg.Env,
+				},
+			},
+			Tolerations: append([]corev1.Toleration{
+				{
+					Key:      cfg.TolerationKey,
+					Operator: corev1.TolerationOpEqual,
+					Value:    cfg.TolerationValue,
+					Effect:   corev1.TaintEffectNoSchedule,
+				},
+			}, cfg.Tolerations...),
+			Affinity: affinity,
+		},
+	}, nil
+}
+
+// GenerateDeployment creates a new Kubernetes deployment using the specified deployment config, including deployment name, namespace, and container configuration.
+// It sets up resource requests (and limits, if configured), tolerations and node selectors for the deployment and logs the creation status.
+func GenerateDeployment(clientset kubernetes.Interface, cfg DeploymentConfig) error {
+	deploymentsClient := clientset.AppsV1().Deployments(cfg.Namespace)
 
 	// Ensure deploymentName is not empty
-	if deploymentName == "" {
+	if cfg.Name == "" {
 		return fmt.Errorf("Deployment name must not be empty!")
 	}
 
 	// Define labels to be used by both the selector and the pod template
 	labels := map[string]string{
-		"app": deploymentName,
+		"app": cfg.Name,
+	}
+
+	template, err := buildPodTemplate(cfg, labels)
+	if err != nil {
+		return err
+	}
+
+	strategy, err := buildDeploymentStrategy(cfg)
+	if err != nil {
+		return err
 	}
 
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   deploymentName,
+			Name:   cfg.Name,
 			Labels: labels,
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: utilities.Int32Ptr(int32(replicas)),
+			Replicas: utilities.Int32Ptr(int32(cfg.Replicas)),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
-				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{
-						{
-							Name:  containerName,
-							Image: containerImage,
-							Resources: corev1.ResourceRequirements{
-								Requests: corev1.ResourceList{
-									corev1.ResourceCPU: resource.MustParse(cpuRequest),
-								},
-							},
-						},
-					},
-					Tolerations: []corev1.Toleration{
-						{
-							Key:      tolerationKey,
-							Operator: corev1.TolerationOpEqual,
-							Value:    tolerationValue,
-							Effect:   corev1.TaintEffectNoSchedule,
-						},
-					},
-					Affinity: &corev1.Affinity{
-						NodeAffinity: &corev1.NodeAffinity{
-							RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
-								NodeSelectorTerms: []corev1.NodeSelectorTerm{
-									{
-										MatchExpressions: []corev1.NodeSelectorRequirement{
-											{
-												Key:      nodeSelectorKey,
-												Operator: corev1.NodeSelectorOpIn,
-												Values:   []string{nodeSelectorValue},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
+			Template: template,
+			Strategy: strategy,
 		},
 	}
 
-	fmt.Println("Creating deployment...")
+	logging.Progress("Creating deployment")
 	result, err := deploymentsClient.Create(context.Background(), deployment, metav1.CreateOptions{})
 	if err != nil {
 		return fmt.Errorf("Failed to create deployment: %w", err)
 	}
-	fmt.Printf("Created deployment %q in namespace %q.\n", result.GetObjectMeta().GetName(), namespace)
+	logging.Progress("Created deployment", "deployment", result.GetObjectMeta().GetName(), "namespace", cfg.Namespace)
+
+	return nil
+}
+
+// LoadDeploymentManifest reads a YAML or JSON manifest from the given path and decodes it into a Deployment.
+// It returns an error if the file cannot be read or if it does not contain exactly an apps/v1 Deployment.
+func LoadDeploymentManifest(path string) (*appsv1.Deployment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read deployment manifest: %w", err)
+	}
+
+	obj, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode deployment manifest: %w", err)
+	}
+
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return nil, fmt.Errorf("Deployment manifest must contain an apps/v1 Deployment, got %s", gvk.String())
+	}
+	if deployment.Name == "" {
+		return nil, fmt.Errorf("Deployment manifest must set metadata.name")
+	}
+
+	return deployment, nil
+}
+
+// ApplyDeploymentManifest creates the supplied Deployment in the given namespace with its replica count overridden.
+// It is used in place of GenerateDeployment when the user provides their own manifest, and logs the creation status.
+func ApplyDeploymentManifest(clientset kubernetes.Interface, deployment *appsv1.Deployment, namespace string, replicas int) error {
+	deploymentsClient := clientset.AppsV1().Deployments(namespace)
+
+	deployment.Namespace = namespace
+	deployment.Spec.Replicas = utilities.Int32Ptr(int32(replicas))
+
+	logging.Progress("Creating deployment from manifest")
+	result, err := deploymentsClient.Create(context.Background(), deployment, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to create deployment from manifest: %w", err)
+	}
+	logging.Progress("Created deployment from manifest", "deployment", result.GetObjectMeta().GetName(), "namespace", namespace)
 
 	return nil
 }
 
 // DeleteDeployment removes a specified deployment from a given namespace.
 // It ensures the deployment is deleted according to the specified deletion policy and logs the deletion status.
+// A deployment that does not exist is not an error, so cleanups can be repeated.
 func DeleteDeployment(clientset kubernetes.Interface, deploymentName, namespace string) error {
 	deploymentsClient := clientset.AppsV1().Deployments(namespace)
 
-	fmt.Printf("Deleting deployment %q in namespace %q...\n", deploymentName, namespace)
+	logging.Progress("Deleting deployment", "deployment", deploymentName, "namespace", namespace)
 	deletePolicy := metav1.DeletePropagationForeground
-	if err := deploymentsClient.Delete(context.Background(), deploymentName, metav1.DeleteOptions{
+	err := deploymentsClient.Delete(context.Background(), deploymentName, metav1.DeleteOptions{
 		PropagationPolicy: &deletePolicy,
-	}); err != nil {
+	})
+	if apierrors.IsNotFound(err) {
+		logging.Progress("Deployment is already gone", "deployment", deploymentName)
+		return nil
+	}
+	if err != nil {
 		return fmt.Errorf("Failed to delete deployment: %w", err)
 	}
-	fmt.Printf("Deleted deployment %q.\n", deploymentName)
+	logging.Progress("Deleted deployment", "deployment", deploymentName)
 
 	return nil
 }
+
+// deploymentDeleteTimeout bounds how long WaitForDeploymentDeleted waits for a deployment and its pods to be gone.
+var deploymentDeleteTimeout = 2 * time.Minute
+
+// WaitForDeploymentDeleted waits until the given deployment no longer exists. Since DeleteDeployment uses foreground
+// propagation, this is once all of its pods have been deleted, so a deployment of the same name can be recreated.
+func WaitForDeploymentDeleted(clientset kubernetes.Interface, deploymentName, namespace string) error {
+	startTime := time.Now()
+	for {
+		exists, err := ResourceExists(clientset, "Deployment", deploymentName, namespace)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return nil
+		}
+		if time.Since(startTime) >= deploymentDeleteTimeout {
+			return fmt.Errorf("Timed out waiting for deployment '%s' to be deleted", deploymentName)
+		}
+		time.Sleep(1 * time.Second)
+	}
+}
@@ -0,0 +1,766 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: custom
+spec:
+  replicas: 0
+  selector:
+    matchLabels:
+      app: custom
+  template:
+    metadata:
+      labels:
+        app: custom
+    spec:
+      containers:
+      - name: app
+        image: public.ecr.aws/eks-distro/kubernetes/pause:3.7
+      - name: sidecar
+        image: public.ecr.aws/eks-distro/kubernetes/pause:3.7
+`
+
+// writeManifest writes the given manifest to a temporary file and returns its path.
+func writeManifest(t *testing.T, manifest string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "deployment.yaml")
+	if err := os.WriteFile(path, []byte(manifest), 0o600); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	return path
+}
+
+// TestLoadDeploymentManifest checks that a Deployment manifest is decoded with all of its containers.
+func TestLoadDeploymentManifest(t *testing.T) {
+	deployment, err := LoadDeploymentManifest(writeManifest(t, testManifest))
+	if err != nil {
+		t.Fatalf("LoadDeploymentManifest() returned error: %v", err)
+	}
+	if deployment.Name != "custom" {
+		t.Errorf("LoadDeploymentManifest() name = %q, want %q", deployment.Name, "custom")
+	}
+	if len(deployment.Spec.Template.Spec.Containers) != 2 {
+		t.Errorf("LoadDeploymentManifest() containers = %d, want 2", len(deployment.Spec.Template.Spec.Containers))
+	}
+}
+
+// TestLoadDeploymentManifestRejectsOtherKinds checks that manifests for other kinds are rejected.
+func TestLoadDeploymentManifestRejectsOtherKinds(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: not-a-deployment\n"
+	if _, err := LoadDeploymentManifest(writeManifest(t, manifest)); err == nil {
+		t.Errorf("LoadDeploymentManifest() returned nil error for a ConfigMap manifest")
+	}
+}
+
+// TestApplyDeploymentManifest checks that the manifest is created with the requested replicas and namespace.
+func TestApplyDeploymentManifest(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	deployment, err := LoadDeploymentManifest(writeManifest(t, testManifest))
+	if err != nil {
+		t.Fatalf("LoadDeploymentManifest() returned error: %v", err)
+	}
+
+	if err := ApplyDeploymentManifest(clientset, deployment, "bench", 4); err != nil {
+		t.Fatalf("ApplyDeploymentManifest() returned error: %v", err)
+	}
+
+	created, err := clientset.AppsV1().Deployments("bench").Get(context.Background(), "custom", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("deployment was not created: %v", err)
+	}
+	if *created.Spec.Replicas != 4 {
+		t.Errorf("ApplyDeploymentManifest() replicas = %d, want 4", *created.Spec.Replicas)
+	}
+}
+
+// testDeploymentConfig returns a DeploymentConfig populated with the tool's default flag values.
+func testDeploymentConfig() DeploymentConfig {
+	return DeploymentConfig{
+		Name:              "inflate",
+		Namespace:         "default",
+		ContainerName:     "inflate",
+		ContainerImage:    "public.ecr.aws/eks-distro/kubernetes/pause:3.7",
+		CPURequest:        "1",
+		TolerationKey:     "eks.autify.com/k8s-autoscaler-benchmarker",
+		NodeSelectorKey:   "eks.autify.com/k8s-autoscaler-benchmarker",
+		NodeSelectorValue: "true",
+		Replicas:          1,
+	}
+}
+
+// TestGenerateDeploymentLimits checks that limits are only set on the container when configured.
+func TestGenerateDeploymentLimits(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	if err := GenerateDeployment(clientset, testDeploymentConfig()); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	if limits := created.Spec.Template.Spec.Containers[0].Resources.Limits; len(limits) != 0 {
+		t.Errorf("GenerateDeployment() set limits %v without --cpu-limit/--memory-limit", limits)
+	}
+
+	cfg := testDeploymentConfig()
+	cfg.Name = "limited"
+	cfg.CPULimit = "2"
+	cfg.MemoryLimit = "512Mi"
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ = clientset.AppsV1().Deployments("default").Get(context.Background(), "limited", metav1.GetOptions{})
+	limits := created.Spec.Template.Spec.Containers[0].Resources.Limits
+	if limits.Cpu().String() != "2" || limits.Memory().String() != "512Mi" {
+		t.Errorf("GenerateDeployment() limits = %v, want cpu=2 memory=512Mi", limits)
+	}
+
+	cfg.Name = "invalid"
+	cfg.MemoryLimit = "lots"
+	if err := GenerateDeployment(clientset, cfg); err == nil {
+		t.Errorf("GenerateDeployment() returned nil error for an invalid memory limit")
+	}
+}
+
+// TestGenerateDeploymentMemoryRequest checks that the memory request is only set on the container when configured,
+// and that an invalid one is rejected with an error.
+func TestGenerateDeploymentMemoryRequest(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	if err := GenerateDeployment(clientset, testDeploymentConfig()); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	if _, ok := created.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceMemory]; ok {
+		t.Errorf("GenerateDeployment() set a memory request without --memory-request")
+	}
+
+	cfg := testDeploymentConfig()
+	cfg.Name = "memory"
+	cfg.MemoryRequest = "4Gi"
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ = clientset.AppsV1().Deployments("default").Get(context.Background(), "memory", metav1.GetOptions{})
+	requests := created.Spec.Template.Spec.Containers[0].Resources.Requests
+	if requests.Memory().String() != "4Gi" || requests.Cpu().String() != cfg.CPURequest {
+		t.Errorf("GenerateDeployment() requests = %v, want cpu=%s memory=4Gi", requests, cfg.CPURequest)
+	}
+
+	cfg.Name = "invalid"
+	cfg.MemoryRequest = "plenty"
+	if err := GenerateDeployment(clientset, cfg); err == nil {
+		t.Errorf("GenerateDeployment() returned nil error for an invalid memory request")
+	}
+}
+
+// TestGenerateDeploymentGPURequest checks that a GPU request is set as both request and limit of the configured
+// resource, and that a negative count is rejected.
+func TestGenerateDeploymentGPURequest(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := testDeploymentConfig()
+	cfg.GPURequest = 2
+	cfg.GPUResourceName = "nvidia.com/gpu"
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	resources := created.Spec.Template.Spec.Containers[0].Resources
+	limit, request := resources.Limits["nvidia.com/gpu"], resources.Requests["nvidia.com/gpu"]
+	if limit.Value() != 2 || request.Value() != 2 {
+		t.Errorf("GenerateDeployment() resources = %v, want a request and limit of 2 nvidia.com/gpu", resources)
+	}
+	if _, ok := resources.Limits[corev1.ResourceCPU]; ok {
+		t.Errorf("GenerateDeployment() set a CPU limit without --cpu-limit: %v", resources.Limits)
+	}
+
+	cfg.Name = "invalid"
+	cfg.GPURequest = -1
+	if err := GenerateDeployment(clientset, cfg); err == nil {
+		t.Errorf("GenerateDeployment() returned nil error for a negative GPU request")
+	}
+}
+
+// TestGenerateDeploymentStrategy checks that the rollout strategy is only set when configured and that invalid
+// combinations are rejected.
+func TestGenerateDeploymentStrategy(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	if err := GenerateDeployment(clientset, testDeploymentConfig()); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	if created.Spec.Strategy.Type != "" {
+		t.Errorf("GenerateDeployment() set strategy %q without --rollout-strategy", created.Spec.Strategy.Type)
+	}
+
+	cfg := testDeploymentConfig()
+	cfg.Name = "rolling"
+	cfg.MaxUnavailable = "25%"
+	cfg.MaxSurge = "2"
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ = clientset.AppsV1().Deployments("default").Get(context.Background(), "rolling", metav1.GetOptions{})
+	strategy := created.Spec.Strategy
+	if strategy.Type != appsv1.RollingUpdateDeploymentStrategyType || strategy.RollingUpdate.MaxUnavailable.String() != "25%" || strategy.RollingUpdate.MaxSurge.IntValue() != 2 {
+		t.Errorf("GenerateDeployment() strategy = %+v, want RollingUpdate with maxUnavailable=25%% maxSurge=2", strategy)
+	}
+
+	for _, invalid := range []DeploymentConfig{
+		{RolloutStrategy: "Recreate", MaxSurge: "1"},
+		{RolloutStrategy: "BlueGreen"},
+		{MaxUnavailable: "a lot"},
+	} {
+		cfg := testDeploymentConfig()
+		cfg.Name = "invalid"
+		cfg.RolloutStrategy, cfg.MaxUnavailable, cfg.MaxSurge = invalid.RolloutStrategy, invalid.MaxUnavailable, invalid.MaxSurge
+		if err := GenerateDeployment(clientset, cfg); err == nil {
+			t.Errorf("GenerateDeployment() returned nil error for strategy %+v", invalid)
+		}
+	}
+}
+
+// TestGenerateDeploymentInstanceFamily checks that the instance family is only required when configured, on top of the
+// node selector.
+func TestGenerateDeploymentInstanceFamily(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := testDeploymentConfig()
+	cfg.InstanceFamily = "c7i"
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	expressions := created.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions
+	if len(expressions) != 2 || expressions[0].Key != cfg.NodeSelectorKey || expressions[1].Key != InstanceFamilyLabel || expressions[1].Values[0] != "c7i" {
+		t.Errorf("GenerateDeployment() match expressions = %+v, want the node selector and %s=c7i", expressions, InstanceFamilyLabel)
+	}
+
+	cfg = testDeploymentConfig()
+	cfg.Name = "any-family"
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ = clientset.AppsV1().Deployments("default").Get(context.Background(), "any-family", metav1.GetOptions{})
+	if expressions := created.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions; len(expressions) != 1 {
+		t.Errorf("GenerateDeployment() match expressions = %+v without an instance family, want only the node selector", expressions)
+	}
+}
+
+// TestGenerateDeploymentOnePodPerNode checks that OnePodPerNode adds a required hostname anti-affinity against the
+// deployment's own pods, and that none is set otherwise.
+func TestGenerateDeploymentOnePodPerNode(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := testDeploymentConfig()
+	cfg.OnePodPerNode = true
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	antiAffinity := created.Spec.Template.Spec.Affinity.PodAntiAffinity
+	if antiAffinity == nil || len(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 1 {
+		t.Fatalf("GenerateDeployment() pod anti-affinity = %+v, want one required term", antiAffinity)
+	}
+	term := antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0]
+	if term.TopologyKey != corev1.LabelHostname || term.LabelSelector.MatchLabels["app"] != "inflate" {
+		t.Errorf("GenerateDeployment() anti-affinity term = %+v, want app=inflate spread over %s", term, corev1.LabelHostname)
+	}
+
+	cfg = testDeploymentConfig()
+	cfg.Name = "packed"
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ = clientset.AppsV1().Deployments("default").Get(context.Background(), "packed", metav1.GetOptions{})
+	if created.Spec.Template.Spec.Affinity.PodAntiAffinity != nil {
+		t.Errorf("GenerateDeployment() without OnePodPerNode set a pod anti-affinity: %+v", created.Spec.Template.Spec.Affinity.PodAntiAffinity)
+	}
+}
+
+// TestGenerateDeploymentEnv checks that the environment variables are set on the container in the order given.
+func TestGenerateDeploymentEnv(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := testDeploymentConfig()
+	cfg.Env = []corev1.EnvVar{{Name: "CONFIG_URL", Value: "http://config"}, {Name: "CONFIG", Value: "$(CONFIG_URL)/app"}}
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	created, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	if env := created.Spec.Template.Spec.Containers[0].Env; !reflect.DeepEqual(env, cfg.Env) {
+		t.Errorf("GenerateDeployment() container env = %+v, want %+v", env, cfg.Env)
+	}
+}
+
+// TestReplicasForTotalCPU checks that the replicas are rounded up to cover the total CPU, that rounding is reported and
+// that invalid or non-positive quantities are rejected.
+func TestReplicasForTotalCPU(t *testing.T) {
+	for _, tc := range []struct {
+		totalCPU, cpuRequest string
+		replicas             int
+		rounded              bool
+	}{
+		{"500", "1", 500, false},
+		{"500", "3", 167, true},
+		{"2", "500m", 4, false},
+		{"1500m", "1", 2, true},
+	} {
+		replicas, rounded, err := ReplicasForTotalCPU(tc.totalCPU, tc.cpuRequest)
+		if err != nil {
+			t.Errorf("ReplicasForTotalCPU(%q, %q) returned error: %v", tc.totalCPU, tc.cpuRequest, err)
+		} else if replicas != tc.replicas || rounded != tc.rounded {
+			t.Errorf("ReplicasForTotalCPU(%q, %q) = %d, %v, want %d, %v", tc.totalCPU, tc.cpuRequest, replicas, rounded, tc.replicas, tc.rounded)
+		}
+	}
+
+	for _, invalid := range [][2]string{{"lots", "1"}, {"500", "0"}, {"-4", "1"}} {
+		if _, _, err := ReplicasForTotalCPU(invalid[0], invalid[1]); err == nil {
+			t.Errorf("ReplicasForTotalCPU(%q, %q) returned nil error", invalid[0], invalid[1])
+		}
+	}
+}
+
+// TestFirstPodScheduledTime checks that the earliest PodScheduled transition after the scale-up start is returned,
+// ignoring pods scheduled before it and pods that are not scheduled.
+func TestFirstPodScheduledTime(t *testing.T) {
+	start := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	pod := func(name string, scheduled time.Time, status corev1.ConditionStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "inflate"}},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: status, LastTransitionTime: metav1.NewTime(scheduled)},
+			}},
+		}
+	}
+	clientset := fake.NewSimpleClientset(
+		pod("old", start.Add(-time.Minute), corev1.ConditionTrue),
+		pod("pending", start.Add(5*time.Second), corev1.ConditionFalse),
+		pod("second", start.Add(40*time.Second), corev1.ConditionTrue),
+		pod("first", start.Add(30*time.Second), corev1.ConditionTrue),
+	)
+	if err := GenerateDeployment(clientset, testDeploymentConfig()); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+
+	selector, err := DeploymentPodSelector(clientset, "inflate", "default")
+	if err != nil {
+		t.Fatalf("DeploymentPodSelector() returned error: %v", err)
+	}
+	first, err := FirstPodScheduledTime(clientset, "default", selector, start)
+	if err != nil {
+		t.Fatalf("FirstPodScheduledTime() returned error: %v", err)
+	}
+	if want := start.Add(30 * time.Second); !first.Equal(want) {
+		t.Errorf("FirstPodScheduledTime() = %v, want %v", first, want)
+	}
+}
+
+// TestFindNodesWithHeadroom checks that only nodes with enough unrequested CPU are reported.
+func TestFindNodesWithHeadroom(t *testing.T) {
+	labels := map[string]string{"eks.autify.com/k8s-autoscaler-benchmarker": "true"}
+	node := func(name, cpu string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			},
+		}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "busy", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName: "full",
+			Containers: []corev1.Container{{
+				Name:      "busy",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1500m")}},
+			}},
+		},
+	}
+	clientset := fake.NewSimpleClientset(node("full", "2"), node("roomy", "4"), pod)
+
+	nodeNames, err := FindNodesWithHeadroom(clientset, "eks.autify.com/k8s-autoscaler-benchmarker=true", "1")
+	if err != nil {
+		t.Fatalf("FindNodesWithHeadroom() returned error: %v", err)
+	}
+	if len(nodeNames) != 1 || nodeNames[0] != "roomy" {
+		t.Errorf("FindNodesWithHeadroom() = %v, want [roomy]", nodeNames)
+	}
+}
+
+// TestWaitForDeploymentSettled checks that an observed deployment is settled and an unobserved one times out.
+func TestWaitForDeploymentSettled(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default", Generation: 2},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 1},
+	}, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "observed", Namespace: "default", Generation: 2},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 2},
+	})
+
+	oldTimeout := deploymentSettleTimeout
+	deploymentSettleTimeout = time.Millisecond
+	defer func() { deploymentSettleTimeout = oldTimeout }()
+
+	if err := WaitForDeploymentSettled(clientset, "observed", "default"); err != nil {
+		t.Errorf("WaitForDeploymentSettled() returned error for an observed deployment: %v", err)
+	}
+	if err := WaitForDeploymentSettled(clientset, "pending", "default"); err == nil {
+		t.Errorf("WaitForDeploymentSettled() returned nil error for a deployment the controller has not observed")
+	}
+}
+
+// TestWaitForPodsReadyTimeout checks that a readiness timeout surfaces as bench.ErrSchedulingFailed.
+func TestWaitForPodsReadyTimeout(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	if err := GenerateDeployment(clientset, testDeploymentConfig()); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+
+	_, err := WaitForPodsReady(clientset, "inflate", "default", 1, time.Millisecond, Normal)
+	if !errors.Is(err, bench.ErrSchedulingFailed) {
+		t.Errorf("WaitForPodsReady() error = %v, want bench.ErrSchedulingFailed", err)
+	}
+}
+
+// TestWaitForPodsReadyCrashLoop checks that a crash looping container fails readiness immediately with its last
+// termination reason and exit code.
+func TestWaitForPodsReadyCrashLoop(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "inflate-1", Namespace: "default", Labels: map[string]string{"app": "inflate"}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 "inflate",
+			RestartCount:         2,
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 127}},
+		}}},
+	})
+	if err := GenerateDeployment(clientset, testDeploymentConfig()); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+
+	_, err := WaitForPodsReady(clientset, "inflate", "default", 1, time.Minute, Normal)
+	if !errors.Is(err, bench.ErrSchedulingFailed) {
+		t.Fatalf("WaitForPodsReady() error = %v, want bench.ErrSchedulingFailed", err)
+	}
+	if !strings.Contains(err.Error(), "Error, exit code 127") {
+		t.Errorf("WaitForPodsReady() error = %v, want the last termination reason and exit code", err)
+	}
+}
+
+// TestWaitForPodsStable checks that ready pods pass the stabilization window and pods that never become ready
+// again surface as bench.ErrSchedulingFailed.
+func TestWaitForPodsStable(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	if err := GenerateDeployment(clientset, testDeploymentConfig()); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+
+	oldInterval := stabilityPollInterval
+	stabilityPollInterval = time.Millisecond
+	defer func() { stabilityPollInterval = oldInterval }()
+
+	err := WaitForPodsStable(clientset, "inflate", "default", 1, 10*time.Millisecond, time.Millisecond, Normal)
+	if !errors.Is(err, bench.ErrSchedulingFailed) {
+		t.Errorf("WaitForPodsStable() with unready pods error = %v, want bench.ErrSchedulingFailed", err)
+	}
+
+	deployment, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get deployment: %v", err)
+	}
+	deployment.Status.ReadyReplicas = 1
+	if _, err := clientset.AppsV1().Deployments("default").UpdateStatus(context.Background(), deployment, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update deployment status: %v", err)
+	}
+
+	if err := WaitForPodsStable(clientset, "inflate", "default", 1, 10*time.Millisecond, time.Millisecond, Normal); err != nil {
+		t.Errorf("WaitForPodsStable() with ready pods returned error: %v", err)
+	}
+}
+
+// TestDeleteDeployment checks that an existing deployment is deleted and that deleting a missing one succeeds.
+func TestDeleteDeployment(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	if err := GenerateDeployment(clientset, testDeploymentConfig()); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+
+	if err := DeleteDeployment(clientset, "inflate", "default"); err != nil {
+		t.Fatalf("DeleteDeployment() of an existing deployment returned error: %v", err)
+	}
+	if exists, _ := ResourceExists(clientset, "Deployment", "inflate", "default"); exists {
+		t.Errorf("DeleteDeployment() left the deployment in place")
+	}
+	if err := DeleteDeployment(clientset, "inflate", "default"); err != nil {
+		t.Errorf("DeleteDeployment() of a missing deployment returned error: %v", err)
+	}
+}
+
+// TestWaitForDeploymentDeleted checks that a missing deployment is reported deleted right away and that one that
+// stays around times out.
+func TestWaitForDeploymentDeleted(t *testing.T) {
+	defer func(timeout time.Duration) { deploymentDeleteTimeout = timeout }(deploymentDeleteTimeout)
+	deploymentDeleteTimeout = 0
+
+	clientset := fake.NewSimpleClientset()
+	if err := WaitForDeploymentDeleted(clientset, "inflate", "default"); err != nil {
+		t.Errorf("WaitForDeploymentDeleted() of a missing deployment returned error: %v", err)
+	}
+
+	if err := GenerateDeployment(clientset, testDeploymentConfig()); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	if err := WaitForDeploymentDeleted(clientset, "inflate", "default"); err == nil {
+		t.Error("WaitForDeploymentDeleted() of a remaining deployment returned no error")
+	}
+}
+
+// TestPendingPodReasons checks that the scheduling failures of pending pods are grouped by reason and message, and
+// that running pods and other workloads are ignored.
+func TestPendingPodReasons(t *testing.T) {
+	unschedulable := corev1.PodCondition{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable", Message: "0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector."}
+	pod := func(name, app string, phase corev1.PodPhase, conditions ...corev1.PodCondition) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": app}},
+			Status:     corev1.PodStatus{Phase: phase, Conditions: conditions},
+		}
+	}
+	clientset := fake.NewSimpleClientset(
+		pod("inflate-1", "inflate", corev1.PodPending, unschedulable),
+		pod("inflate-2", "inflate", corev1.PodPending, unschedulable),
+		pod("inflate-3", "inflate", corev1.PodPending),
+		pod("inflate-4", "inflate", corev1.PodRunning),
+		pod("other", "other", corev1.PodPending, unschedulable),
+	)
+
+	reasons, err := PendingPodReasons(clientset, "default", "app=inflate")
+	if err != nil {
+		t.Fatalf("PendingPodReasons() returned error: %v", err)
+	}
+	want := []string{
+		"Unschedulable: 0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector. (2 pods)",
+		"not scheduled yet (1 pods)",
+	}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("PendingPodReasons() = %q, want %q", reasons, want)
+	}
+}
+
+// TestPodsOutsideNodes checks that scheduled pods on nodes not backed by the run's instances are reported, including
+// pre-existing nodes of the same node pool, while pods on the run's nodes, unscheduled pods and other workloads are not.
+func TestPodsOutsideNodes(t *testing.T) {
+	node := func(name, providerID string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"karpenter.sh/nodepool": "default"}},
+			Spec:       corev1.NodeSpec{ProviderID: providerID},
+		}
+	}
+	pod := func(name, app, nodeName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": app}},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+		}
+	}
+	clientset := fake.NewSimpleClientset(
+		node("provisioned", "aws:///us-east-1a/i-new"),
+		node("existing", "aws:///us-east-1a/i-old"),
+		pod("inflate-1", "inflate", "provisioned"),
+		pod("inflate-2", "inflate", "existing"),
+		pod("inflate-3", "inflate", ""),
+		pod("other", "other", "existing"),
+	)
+
+	outside, err := PodsOutsideNodes(clientset, "default", "app=inflate", []string{"i-new"})
+	if err != nil {
+		t.Fatalf("PodsOutsideNodes() returned error: %v", err)
+	}
+	if want := []string{"inflate-2 (existing)"}; !reflect.DeepEqual(outside, want) {
+		t.Errorf("PodsOutsideNodes() = %q, want %q", outside, want)
+	}
+}
+
+// TestCountRunningPods checks that only Running pods that are not being deleted are counted, that their nodes are
+// counted once, and that the other pods of the workload are listed with their phase.
+func TestCountRunningPods(t *testing.T) {
+	pod := func(name, app, nodeName string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": app}},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	terminating := pod("inflate-5", "inflate", "node-b", corev1.PodRunning)
+	terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	terminating.Finalizers = []string{"example.com/hold"}
+	clientset := fake.NewSimpleClientset(
+		pod("inflate-1", "inflate", "node-a", corev1.PodRunning),
+		pod("inflate-2", "inflate", "node-a", corev1.PodRunning),
+		pod("inflate-3", "inflate", "node-b", corev1.PodRunning),
+		pod("inflate-4", "inflate", "node-b", corev1.PodFailed),
+		terminating,
+		pod("inflate-6", "inflate", "", corev1.PodPending),
+		pod("other", "other", "node-c", corev1.PodRunning),
+	)
+
+	running, nodes, others, err := CountRunningPods(clientset, "default", "app=inflate")
+	if err != nil {
+		t.Fatalf("CountRunningPods() returned error: %v", err)
+	}
+	if running != 3 || nodes != 2 {
+		t.Errorf("CountRunningPods() = %d pods on %d nodes, want 3 pods on 2 nodes", running, nodes)
+	}
+	if want := []string{"inflate-4 (Failed)", "inflate-5 (Terminating)", "inflate-6 (Pending)"}; !reflect.DeepEqual(others, want) {
+		t.Errorf("CountRunningPods() others = %q, want %q", others, want)
+	}
+}
+
+// TestMonitorNodeDeregistrationQuiet checks that the periodic status line of the nodes still registered is logged
+// while waiting, and not at all with Quiet.
+func TestMonitorNodeDeregistrationQuiet(t *testing.T) {
+	defer func(interval time.Duration) { nodeStatusInterval = interval }(nodeStatusInterval)
+	nodeStatusInterval = time.Millisecond
+
+	for _, verbosity := range []Verbosity{Normal, Quiet} {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"karpenter.sh/nodepool": "default"}}}
+		clientset := fake.NewSimpleClientset(node)
+
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		deregChan := make(chan time.Duration, 1)
+		errChan := make(chan error, 1)
+		go MonitorNodeDeregistration(clientset, "karpenter.sh/nodepool=default", nil, 0, verbosity, deregChan, errChan)
+		time.Sleep(1500 * time.Millisecond)
+		clientset.CoreV1().Nodes().Delete(context.Background(), "node-1", metav1.DeleteOptions{})
+		select {
+		case <-deregChan:
+		case err := <-errChan:
+			t.Fatalf("MonitorNodeDeregistration() returned error: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("MonitorNodeDeregistration() did not report the deregistration")
+		}
+		log.SetOutput(os.Stderr)
+
+		logged := strings.Contains(buf.String(), "Nodes still registered to the cluster nodes=node-1")
+		if logged != (verbosity == Normal) {
+			t.Errorf("MonitorNodeDeregistration() with verbosity %d logged the status line: %v, want %v:\n%s", verbosity, logged, verbosity == Normal, buf.String())
+		}
+	}
+}
+
+// TestMonitorNodeDeregistrationInstances checks that only the nodes of the given instances are waited for, so a node of
+// another run sharing the selector does not hold up the deregistration.
+func TestMonitorNodeDeregistrationInstances(t *testing.T) {
+	labels := map[string]string{"karpenter.sh/nodepool": "default"}
+	clientset := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: labels}, Spec: corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-run"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2", Labels: labels}, Spec: corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-other"}},
+	)
+
+	deregChan := make(chan time.Duration, 1)
+	errChan := make(chan error, 1)
+	go MonitorNodeDeregistration(clientset, "karpenter.sh/nodepool=default", []string{"i-run"}, 0, Quiet, deregChan, errChan)
+	time.Sleep(1500 * time.Millisecond)
+	select {
+	case <-deregChan:
+		t.Fatal("MonitorNodeDeregistration() reported the deregistration while the run's node was registered")
+	default:
+	}
+	clientset.CoreV1().Nodes().Delete(context.Background(), "node-1", metav1.DeleteOptions{})
+	select {
+	case <-deregChan:
+	case err := <-errChan:
+		t.Fatalf("MonitorNodeDeregistration() returned error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("MonitorNodeDeregistration() did not report the deregistration of the run's node")
+	}
+}
+
+// TestMonitorPodTermination checks that the termination time is reported once the last matching pod is gone, while
+// pods of other workloads are ignored.
+func TestMonitorPodTermination(t *testing.T) {
+	defer func(interval time.Duration) { podTerminationPollInterval = interval }(podTerminationPollInterval)
+	podTerminationPollInterval = 5 * time.Millisecond
+
+	pod := func(name, app string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": app}}}
+	}
+	clientset := fake.NewSimpleClientset(pod("inflate-1", "inflate"), pod("inflate-2", "inflate"), pod("other", "other"))
+
+	podTermChan := make(chan time.Duration, 1)
+	errChan := make(chan error, 1)
+	go MonitorPodTermination(clientset, "default", "app=inflate", podTermChan, errChan)
+
+	time.Sleep(30 * time.Millisecond)
+	for _, name := range []string{"inflate-1", "inflate-2"} {
+		if err := clientset.CoreV1().Pods("default").Delete(context.Background(), name, metav1.DeleteOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case err := <-errChan:
+		t.Fatalf("MonitorPodTermination() returned error: %v", err)
+	case duration := <-podTermChan:
+		if duration < 30*time.Millisecond {
+			t.Errorf("MonitorPodTermination() = %v, want at least the 30ms until the pods were deleted", duration)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("MonitorPodTermination() did not report the termination")
+	}
+}
+
+// TestMonitorNodeTerminationUnseen checks that when none of the launched instances is ever seen, the termination is
+// timed by the first poll that saw no instances rather than by the end of the startup grace.
+func TestMonitorNodeTerminationUnseen(t *testing.T) {
+	defer func(grace time.Duration) { terminationStartupGrace = grace }(terminationStartupGrace)
+	terminationStartupGrace = 100 * time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><reservationSet/></DescribeInstancesResponse>`))
+	}))
+	defer server.Close()
+	sess := session.Must(session.NewSession(&awssdk.Config{
+		Endpoint:    awssdk.String(server.URL),
+		Region:      awssdk.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+
+	var stats TerminationStats
+	termChan := make(chan time.Duration, 1)
+	errChan := make(chan error, 1)
+	MonitorNodeTermination([]*ec2.EC2{ec2.New(sess)}, "karpenter.sh/nodepool", "default", time.Time{}, []string{"i-1"}, 0, Quiet, nil, &stats, termChan, errChan)
+	select {
+	case err := <-errChan:
+		t.Fatalf("MonitorNodeTermination() returned error: %v", err)
+	case duration := <-termChan:
+		if duration >= terminationStartupGrace {
+			t.Errorf("MonitorNodeTermination() = %v, want the time of the first poll, below the grace of %v", duration, terminationStartupGrace)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ResourceExists reports whether the resource of the given kind exists. Supported kinds are the ones the benchmark
+// creates: Deployment, Job, Service, PodDisruptionBudget and Namespace. namespace is ignored for a Namespace.
+func ResourceExists(clientset kubernetes.Interface, kind, name, namespace string) (bool, error) {
+	var err error
+	ctx := context.Background()
+	switch kind {
+	case "Deployment":
+		_, err = clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Job":
+		_, err = clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Service":
+		_, err = clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "PodDisruptionBudget":
+		_, err = clientset.PolicyV1().PodDisruptionBudgets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Namespace":
+		_, err = clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	default:
+		return false, fmt.Errorf("Unsupported resource kind %q", kind)
+	}
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("Failed to get %s %q: %w", kind, name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestResourceExists checks that existing and missing resources are told apart per kind and namespace, and that
+// unsupported kinds are rejected.
+func TestResourceExists(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "inflate", Namespace: "default"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "inflate-tenant-1"}},
+	)
+
+	for _, tc := range []struct {
+		kind, name, namespace string
+		want                  bool
+	}{
+		{"Deployment", "inflate", "default", true},
+		{"Deployment", "inflate", "other", false},
+		{"Service", "inflate", "default", false},
+		{"Namespace", "inflate-tenant-1", "", true},
+		{"Job", "inflate", "default", false},
+	} {
+		got, err := ResourceExists(clientset, tc.kind, tc.name, tc.namespace)
+		if err != nil {
+			t.Errorf("ResourceExists(%s %s/%s) returned error: %v", tc.kind, tc.namespace, tc.name, err)
+		} else if got != tc.want {
+			t.Errorf("ResourceExists(%s %s/%s) = %v, want %v", tc.kind, tc.namespace, tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := ResourceExists(clientset, "ConfigMap", "inflate", "default"); err == nil {
+		t.Errorf("ResourceExists() of an unsupported kind returned nil error")
+	}
+}
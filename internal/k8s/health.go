@@ -0,0 +1,87 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// controllerSelectors lists, per autoscaler type, the label selectors matching the pods of its controller as labeled by
+// the upstream Helm charts and example manifests.
+var controllerSelectors = map[string][]string{
+	"Karpenter":          {"app.kubernetes.io/name=karpenter"},
+	"Cluster Autoscaler": {"app.kubernetes.io/name=aws-cluster-autoscaler", "app=cluster-autoscaler"},
+}
+
+// ControllerRestarts returns the total container restart count of every pod of the autoscaler's controller in any
+// namespace, keyed by pod UID, so that two snapshots can be compared with ControllerRestartsSince. It is empty for
+// autoscaler types without a controller of their own, e.g. Fargate.
+func ControllerRestarts(clientset kubernetes.Interface, autoscalerType string) (map[string]int, error) {
+	restarts := map[string]int{}
+	for _, selector := range controllerSelectors[autoscalerType] {
+		pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{
+			LabelSelector: selector,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list the %s controller pods with selector %s: %w", autoscalerType, selector, err)
+		}
+		for _, pod := range pods.Items {
+			count := 0
+			for _, status := range pod.Status.ContainerStatuses {
+				count += int(status.RestartCount)
+			}
+			restarts[string(pod.UID)] = count
+		}
+	}
+	return restarts, nil
+}
+
+// ControllerRestartsSince returns how often the controller restarted between two ControllerRestarts snapshots. A pod
+// that was not in the before snapshot replaced one that went away and counts as one restart on top of its own.
+func ControllerRestartsSince(before, after map[string]int) int {
+	total := 0
+	for uid, count := range after {
+		if previous, ok := before[uid]; ok {
+			total += count - previous
+		} else if len(before) > 0 {
+			total += count + 1
+		}
+	}
+	return total
+}
+
+// CountEvictions returns the number of pods in the namespace that were evicted since the given time, e.g. by
+// Karpenter's consolidation or by the kubelet under node pressure, as recorded by their Evicted events.
+func CountEvictions(clientset kubernetes.Interface, namespace string, since time.Time) (int, error) {
+	events, err := clientset.CoreV1().Events(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Failed to list events in namespace %s: %w", namespace, err)
+	}
+
+	evicted := map[string]bool{}
+	for _, event := range events.Items {
+		if event.Reason == "Evicted" && event.InvolvedObject.Kind == "Pod" && !eventTime(event).Before(since) {
+			evicted[event.InvolvedObject.Name] = true
+		}
+	}
+	return len(evicted), nil
+}
+
+// eventTime returns when an event last occurred, falling back to its creation for events that only set one of the
+// newer or older timestamp fields.
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
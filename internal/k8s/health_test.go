@@ -0,0 +1,72 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestControllerRestarts checks that the restarts of the controller's containers are summed per pod, that pods of
+// other workloads are ignored, and that a replaced controller pod counts as a restart.
+func TestControllerRestarts(t *testing.T) {
+	controller := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "karpenter-1", Namespace: "karpenter", UID: types.UID("a"), Labels: map[string]string{"app.kubernetes.io/name": "karpenter"}},
+		Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 1}, {RestartCount: 2}}},
+	}
+	other := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "inflate", Namespace: "default", UID: types.UID("b")},
+		Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 5}}},
+	}
+
+	restarts, err := ControllerRestarts(fake.NewSimpleClientset(controller, other), "Karpenter")
+	if err != nil {
+		t.Fatalf("ControllerRestarts() returned error: %v", err)
+	}
+	if len(restarts) != 1 || restarts["a"] != 3 {
+		t.Errorf("ControllerRestarts() = %v, want 3 restarts of pod a", restarts)
+	}
+
+	if got := ControllerRestartsSince(map[string]int{"a": 1}, map[string]int{"a": 3}); got != 2 {
+		t.Errorf("ControllerRestartsSince() of a restarted pod = %d, want 2", got)
+	}
+	if got := ControllerRestartsSince(map[string]int{"a": 1}, map[string]int{"c": 0}); got != 1 {
+		t.Errorf("ControllerRestartsSince() of a replaced pod = %d, want 1", got)
+	}
+	if got := ControllerRestartsSince(map[string]int{}, map[string]int{"c": 0}); got != 0 {
+		t.Errorf("ControllerRestartsSince() without a controller before = %d, want 0", got)
+	}
+}
+
+// TestCountEvictions checks that each evicted pod is counted once and that older events and other reasons are ignored.
+func TestCountEvictions(t *testing.T) {
+	since := time.Now()
+	event := func(name, pod, reason string, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod},
+			Reason:         reason,
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+	clientset := fake.NewSimpleClientset(
+		event("e1", "inflate-1", "Evicted", since.Add(time.Second)),
+		event("e2", "inflate-1", "Evicted", since.Add(2*time.Second)),
+		event("e3", "inflate-2", "Evicted", since.Add(-time.Minute)),
+		event("e4", "inflate-3", "Scheduled", since.Add(time.Second)),
+	)
+
+	evictions, err := CountEvictions(clientset, "default", since)
+	if err != nil {
+		t.Fatalf("CountEvictions() returned error: %v", err)
+	}
+	if evictions != 1 {
+		t.Errorf("CountEvictions() = %d, want 1", evictions)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// hpaOriginalReplicasAnnotation records the minReplicas,maxReplicas of an HPA pinned by PinHPA, so RestoreHPA can
+// restore them even from a cleanup that did not pin it, e.g. after SIGINT.
+const hpaOriginalReplicasAnnotation = "eks.autify.com/k8s-autoscaler-benchmarker-original-replicas"
+
+// FindHPA returns the HorizontalPodAutoscaler targeting the given deployment, or nil if there is none.
+func FindHPA(clientset kubernetes.Interface, deploymentName, namespace string) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpas, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list horizontal pod autoscalers: %w", err)
+	}
+	for i := range hpas.Items {
+		target := hpas.Items[i].Spec.ScaleTargetRef
+		if target.Kind == "Deployment" && target.Name == deploymentName {
+			return &hpas.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// PinHPA sets both minReplicas and maxReplicas of the HPA to replicas, so it drives the scale event instead of fighting
+// it. The original values are kept in an annotation for RestoreHPA. An HPA that is already pinned keeps its recorded
+// original values.
+func PinHPA(clientset kubernetes.Interface, hpa *autoscalingv2.HorizontalPodAutoscaler, replicas int) error {
+	if _, pinned := hpa.Annotations[hpaOriginalReplicasAnnotation]; !pinned {
+		minReplicas := int32(1)
+		if hpa.Spec.MinReplicas != nil {
+			minReplicas = *hpa.Spec.MinReplicas
+		}
+		if hpa.Annotations == nil {
+			hpa.Annotations = make(map[string]string)
+		}
+		hpa.Annotations[hpaOriginalReplicasAnnotation] = fmt.Sprintf("%d,%d", minReplicas, hpa.Spec.MaxReplicas)
+	}
+
+	pinned := int32(replicas)
+	hpa.Spec.MinReplicas = &pinned
+	hpa.Spec.MaxReplicas = pinned
+	if _, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(hpa.Namespace).Update(context.Background(), hpa, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("Failed to pin horizontal pod autoscaler %q: %w", hpa.Name, err)
+	}
+	logging.Progress("Pinned horizontal pod autoscaler", "hpa", hpa.Name, "replicas", replicas)
+	return nil
+}
+
+// RestoreHPA restores the minReplicas and maxReplicas recorded by PinHPA on the HPA targeting the given deployment.
+// It is a no-op when there is no such HPA or it was not pinned, so the call is safe to repeat during cleanup.
+func RestoreHPA(clientset kubernetes.Interface, deploymentName, namespace string) error {
+	hpa, err := FindHPA(clientset, deploymentName, namespace)
+	if err != nil || hpa == nil {
+		return err
+	}
+	original, pinned := hpa.Annotations[hpaOriginalReplicasAnnotation]
+	if !pinned {
+		return nil
+	}
+
+	minValue, maxValue, _ := strings.Cut(original, ",")
+	minReplicas, minErr := strconv.ParseInt(minValue, 10, 32)
+	maxReplicas, maxErr := strconv.ParseInt(maxValue, 10, 32)
+	if minErr != nil || maxErr != nil {
+		return fmt.Errorf("Invalid original replicas %q recorded on horizontal pod autoscaler %q", original, hpa.Name)
+	}
+
+	restoredMin := int32(minReplicas)
+	hpa.Spec.MinReplicas = &restoredMin
+	hpa.Spec.MaxReplicas = int32(maxReplicas)
+	delete(hpa.Annotations, hpaOriginalReplicasAnnotation)
+	if _, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).Update(context.Background(), hpa, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("Failed to restore horizontal pod autoscaler %q: %w", hpa.Name, err)
+	}
+	logging.Progress("Restored horizontal pod autoscaler", "hpa", hpa.Name, "min_replicas", restoredMin, "max_replicas", hpa.Spec.MaxReplicas)
+	return nil
+}
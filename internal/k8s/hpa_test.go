@@ -0,0 +1,56 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestPinAndRestoreHPA checks that the HPA targeting the deployment is found, pinned to the benchmark replicas and
+// restored to its original bounds, and that restoring twice is a no-op.
+func TestPinAndRestoreHPA(t *testing.T) {
+	minReplicas := int32(2)
+	clientset := fake.NewSimpleClientset(&autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    5,
+		},
+	})
+
+	if hpa, err := FindHPA(clientset, "other", "default"); err != nil || hpa != nil {
+		t.Fatalf("FindHPA() = %v, %v, want nil for a deployment without HPA", hpa, err)
+	}
+	hpa, err := FindHPA(clientset, "web", "default")
+	if err != nil || hpa == nil {
+		t.Fatalf("FindHPA() = %v, %v, want the web HPA", hpa, err)
+	}
+
+	if err := PinHPA(clientset, hpa, 20); err != nil {
+		t.Fatalf("PinHPA() returned error: %v", err)
+	}
+	pinned, _ := clientset.AutoscalingV2().HorizontalPodAutoscalers("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if *pinned.Spec.MinReplicas != 20 || pinned.Spec.MaxReplicas != 20 {
+		t.Errorf("PinHPA() bounds = %d-%d, want 20-20", *pinned.Spec.MinReplicas, pinned.Spec.MaxReplicas)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := RestoreHPA(clientset, "web", "default"); err != nil {
+			t.Fatalf("RestoreHPA() returned error: %v", err)
+		}
+	}
+	restored, _ := clientset.AutoscalingV2().HorizontalPodAutoscalers("default").Get(context.Background(), "web", metav1.GetOptions{})
+	if *restored.Spec.MinReplicas != 2 || restored.Spec.MaxReplicas != 5 {
+		t.Errorf("RestoreHPA() bounds = %d-%d, want 2-5", *restored.Spec.MinReplicas, restored.Spec.MaxReplicas)
+	}
+	if _, ok := restored.Annotations[hpaOriginalReplicasAnnotation]; ok {
+		t.Errorf("RestoreHPA() left the original replicas annotation")
+	}
+}
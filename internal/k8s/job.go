@@ -0,0 +1,122 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/utilities"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// GenerateJob creates a new Kubernetes Job running cfg.Replicas pods in parallel, using the same pod template as GenerateDeployment.
+// It is used to benchmark batch scale-up, where the Job's parallelism rather than a replica count drives provisioning.
+func GenerateJob(clientset kubernetes.Interface, cfg DeploymentConfig) error {
+	jobsClient := clientset.BatchV1().Jobs(cfg.Namespace)
+
+	if cfg.Name == "" {
+		return fmt.Errorf("Job name must not be empty!")
+	}
+
+	labels := map[string]string{
+		"app": cfg.Name,
+	}
+
+	template, err := buildPodTemplate(cfg, labels)
+	if err != nil {
+		return err
+	}
+	template.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   cfg.Name,
+			Labels: labels,
+		},
+		Spec: batchv1.JobSpec{
+			Parallelism: utilities.Int32Ptr(int32(cfg.Replicas)),
+			Completions: utilities.Int32Ptr(int32(cfg.Replicas)),
+			Template:    template,
+		},
+	}
+
+	logging.Progress("Creating job")
+	result, err := jobsClient.Create(context.Background(), job, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to create job: %w", err)
+	}
+	logging.Progress("Created job", "job", result.GetObjectMeta().GetName(), "namespace", cfg.Namespace)
+
+	return nil
+}
+
+// WaitForJobPodsRunning waits until the given number of the Job's pods are in the Running phase.
+// Jobs have no ready replica count, so readiness is computed from the phases of the pods labelled with the Job's name.
+// It returns an error wrapping bench.ErrSchedulingFailed if the pods are not all running within timeout.
+func WaitForJobPodsRunning(clientset kubernetes.Interface, jobName, namespace string, replicas int, timeout time.Duration, verbosity Verbosity) (time.Duration, error) {
+	logging.Progress("Waiting for job pods to be running")
+	startTime := time.Now()
+	logTicker := time.NewTicker(podStatusInterval)
+	defer logTicker.Stop()
+
+	for {
+		if time.Since(startTime) >= timeout {
+			return time.Since(startTime), fmt.Errorf("Timed out waiting for %d job pods to be running within %v: %w", replicas, timeout, bench.ErrSchedulingFailed)
+		}
+
+		pods, err := clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("job-name=%s", jobName),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("Failed to list job pods: %w", err)
+		}
+
+		running := 0
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodRunning {
+				running++
+			}
+		}
+
+		if running >= replicas {
+			logging.Progress("All job pods are running")
+			return time.Since(startTime), nil
+		}
+
+		select {
+		case <-logTicker.C:
+			verbosity.status("Waiting for job pods to be running", "running", running, "replicas", replicas)
+		default:
+			time.Sleep(1 * time.Second)
+		}
+	}
+}
+
+// DeleteJob removes the given Job and its pods from the namespace, which acts as the scale-down trigger for Job benchmarks.
+// A Job that no longer exists is not treated as an error, so the call is safe to repeat during cleanup.
+func DeleteJob(clientset kubernetes.Interface, jobName, namespace string) error {
+	logging.Progress("Deleting job", "job", jobName, "namespace", namespace)
+	deletePolicy := metav1.DeletePropagationForeground
+	err := clientset.BatchV1().Jobs(namespace).Delete(context.Background(), jobName, metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to delete job: %w", err)
+	}
+	logging.Progress("Deleted job", "job", jobName)
+
+	return nil
+}
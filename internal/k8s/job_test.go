@@ -0,0 +1,58 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestGenerateJob checks that the generated Job runs one pod per replica in parallel and never restarts them.
+func TestGenerateJob(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := testDeploymentConfig()
+	cfg.Replicas = 5
+
+	if err := GenerateJob(clientset, cfg); err != nil {
+		t.Fatalf("GenerateJob() returned error: %v", err)
+	}
+
+	job, err := clientset.BatchV1().Jobs("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("job was not created: %v", err)
+	}
+	if *job.Spec.Parallelism != 5 || *job.Spec.Completions != 5 {
+		t.Errorf("GenerateJob() parallelism/completions = %d/%d, want 5/5", *job.Spec.Parallelism, *job.Spec.Completions)
+	}
+	if job.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("GenerateJob() restartPolicy = %s, want Never", job.Spec.Template.Spec.RestartPolicy)
+	}
+}
+
+// TestWaitForJobPodsRunning checks that job readiness is computed from the phases of the job's pods.
+func TestWaitForJobPodsRunning(t *testing.T) {
+	pod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"job-name": "inflate"}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	clientset := fake.NewSimpleClientset(pod("a", corev1.PodRunning), pod("b", corev1.PodRunning), pod("c", corev1.PodPending))
+
+	if _, err := WaitForJobPodsRunning(clientset, "inflate", "default", 2, time.Minute, Normal); err != nil {
+		t.Errorf("WaitForJobPodsRunning() returned error: %v", err)
+	}
+}
+
+// TestDeleteJobMissing checks that deleting a Job that does not exist is not an error.
+func TestDeleteJobMissing(t *testing.T) {
+	if err := DeleteJob(fake.NewSimpleClientset(), "missing", "default"); err != nil {
+		t.Errorf("DeleteJob() returned error for a missing job: %v", err)
+	}
+}
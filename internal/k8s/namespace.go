@@ -0,0 +1,44 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateNamespace creates a namespace for a benchmark tenant, labelled as managed by the benchmarker.
+func CreateNamespace(clientset kubernetes.Interface, name string) error {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"app.kubernetes.io/managed-by": "k8s-autoscaler-benchmarker"},
+		},
+	}
+
+	if _, err := clientset.CoreV1().Namespaces().Create(context.Background(), namespace, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("Failed to create namespace %q: %w", name, err)
+	}
+	logging.Progress("Created namespace", "namespace", name)
+
+	return nil
+}
+
+// DeleteNamespace removes the given namespace and everything in it.
+// A namespace that no longer exists is not treated as an error, so the call is safe to repeat during cleanup.
+func DeleteNamespace(clientset kubernetes.Interface, name string) error {
+	logging.Progress("Deleting namespace", "namespace", name)
+	err := clientset.CoreV1().Namespaces().Delete(context.Background(), name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("Failed to delete namespace %q: %w", name, err)
+	}
+	return nil
+}
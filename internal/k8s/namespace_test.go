@@ -0,0 +1,36 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestCreateAndDeleteNamespace checks that tenant namespaces are labelled and that deleting them is safe to repeat.
+func TestCreateAndDeleteNamespace(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	if err := CreateNamespace(clientset, "inflate-tenant-1"); err != nil {
+		t.Fatalf("CreateNamespace() returned error: %v", err)
+	}
+	namespace, err := clientset.CoreV1().Namespaces().Get(context.Background(), "inflate-tenant-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("namespace was not created: %v", err)
+	}
+	if namespace.Labels["app.kubernetes.io/managed-by"] != "k8s-autoscaler-benchmarker" {
+		t.Errorf("CreateNamespace() labels = %v, want app.kubernetes.io/managed-by=k8s-autoscaler-benchmarker", namespace.Labels)
+	}
+	if err := CreateNamespace(clientset, "inflate-tenant-1"); err == nil {
+		t.Errorf("CreateNamespace() of an existing namespace returned nil error")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := DeleteNamespace(clientset, "inflate-tenant-1"); err != nil {
+			t.Errorf("DeleteNamespace() returned error: %v", err)
+		}
+	}
+}
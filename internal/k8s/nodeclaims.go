@@ -0,0 +1,124 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// karpenterAPIVersions are the supported Karpenter API versions; v1alpha5's provisioners are not supported.
+var karpenterAPIVersions = []string{"v1beta1", "v1"}
+
+// karpenterNodePoolKey is the node label, which Karpenter also sets as an EC2 tag, naming the node pool a node belongs
+// to. It is the same in every supported API version.
+const karpenterNodePoolKey = "karpenter.sh/nodepool"
+
+// KarpenterNodePoolKey returns the node label and EC2 tag key naming the node pool for the given Karpenter API version,
+// or an error if the version is not supported.
+func KarpenterNodePoolKey(apiVersion string) (string, error) {
+	if !slices.Contains(karpenterAPIVersions, apiVersion) {
+		return "", fmt.Errorf("Unsupported Karpenter API version %q, use v1beta1 or v1", apiVersion)
+	}
+	return karpenterNodePoolKey, nil
+}
+
+// NodeClaimGVR identifies Karpenter's NodeClaim resource in the given API version, e.g. v1beta1 or v1.
+func NodeClaimGVR(apiVersion string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: "karpenter.sh", Version: apiVersion, Resource: "nodeclaims"}
+}
+
+// nodeClaimPollInterval is how often NodeClaimTimings lists the NodeClaims while waiting for them to initialize.
+var nodeClaimPollInterval = 5 * time.Second
+
+// nodeClaimTimeout bounds how long NodeClaimTimings waits for the NodeClaims to initialize.
+var nodeClaimTimeout = 2 * time.Minute
+
+// NodeClaimTimings waits until every NodeClaim of the node pool created after since, read in the given Karpenter API
+// version, has its Launched, Registered and Initialized conditions true, then returns their durations taken from the
+// conditions' transition times, sorted by NodeClaim name. If some NodeClaims do not initialize within the timeout, the timings of the initialized ones are
+// returned along with an error naming the others.
+func NodeClaimTimings(client dynamic.Interface, apiVersion, nodepool string, since time.Time) ([]bench.NodeClaimTiming, error) {
+	nodePoolKey, err := KarpenterNodePoolKey(apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	startTime := time.Now()
+	for {
+		list, err := client.Resource(NodeClaimGVR(apiVersion)).List(context.Background(), metav1.ListOptions{LabelSelector: nodePoolKey + "=" + nodepool})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list NodeClaims: %w", err)
+		}
+
+		var timings []bench.NodeClaimTiming
+		var pending []string
+		for _, item := range list.Items {
+			if item.GetCreationTimestamp().Time.Before(since.Truncate(time.Second)) {
+				continue
+			}
+			timing, ok := nodeClaimTiming(item)
+			if !ok {
+				pending = append(pending, item.GetName())
+				continue
+			}
+			timings = append(timings, timing)
+		}
+		sort.Slice(timings, func(i, j int) bool { return timings[i].Name < timings[j].Name })
+
+		if len(pending) == 0 {
+			return timings, nil
+		}
+		if time.Since(startTime) >= nodeClaimTimeout {
+			return timings, fmt.Errorf("Timed out waiting for NodeClaims to initialize: %s", strings.Join(pending, ", "))
+		}
+		time.Sleep(nodeClaimPollInterval)
+	}
+}
+
+// nodeClaimTiming returns the condition durations of a NodeClaim, and false if it is not yet launched, registered and
+// initialized.
+func nodeClaimTiming(nodeClaim unstructured.Unstructured) (bench.NodeClaimTiming, bool) {
+	conditions, _, _ := unstructured.NestedSlice(nodeClaim.Object, "status", "conditions")
+	transitions := make(map[string]time.Time)
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["status"] != "True" {
+			continue
+		}
+		conditionType, _ := condition["type"].(string)
+		lastTransition, _ := condition["lastTransitionTime"].(string)
+		if transition, err := time.Parse(time.RFC3339, lastTransition); err == nil {
+			transitions[conditionType] = transition
+		}
+	}
+
+	launched, ok := transitions["Launched"]
+	if !ok {
+		return bench.NodeClaimTiming{}, false
+	}
+	registered, ok := transitions["Registered"]
+	if !ok {
+		return bench.NodeClaimTiming{}, false
+	}
+	initialized, ok := transitions["Initialized"]
+	if !ok {
+		return bench.NodeClaimTiming{}, false
+	}
+	return bench.NodeClaimTiming{
+		Name:                    nodeClaim.GetName(),
+		LaunchedToRegistered:    registered.Sub(launched),
+		RegisteredToInitialized: initialized.Sub(registered),
+	}, true
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// testNodeClaim returns a NodeClaim of the default node pool in the Karpenter API version created at created, with
+// the given conditions true at the given times.
+func testNodeClaim(apiVersion, name string, created time.Time, conditions map[string]time.Time) *unstructured.Unstructured {
+	var statusConditions []interface{}
+	for conditionType, transition := range conditions {
+		statusConditions = append(statusConditions, map[string]interface{}{
+			"type":               conditionType,
+			"status":             "True",
+			"lastTransitionTime": transition.Format(time.RFC3339),
+		})
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "karpenter.sh/" + apiVersion,
+		"kind":       "NodeClaim",
+		"metadata": map[string]interface{}{
+			"name":              name,
+			"creationTimestamp": created.Format(time.RFC3339),
+			"labels":            map[string]interface{}{"karpenter.sh/nodepool": "default"},
+		},
+		"status": map[string]interface{}{"conditions": statusConditions},
+	}}
+}
+
+// TestNodeClaimTimings checks that the condition durations of the NodeClaims created since the start are returned in
+// every supported Karpenter API version, and that a NodeClaim that never initializes times out with the timings of the
+// others.
+func TestNodeClaimTimings(t *testing.T) {
+	defer func(interval, timeout time.Duration) { nodeClaimPollInterval, nodeClaimTimeout = interval, timeout }(nodeClaimPollInterval, nodeClaimTimeout)
+	nodeClaimPollInterval, nodeClaimTimeout = time.Millisecond, 10*time.Millisecond
+
+	since := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	want := []bench.NodeClaimTiming{{Name: "default-a", LaunchedToRegistered: 30 * time.Second, RegisteredToInitialized: 6 * time.Second}}
+	for _, version := range karpenterAPIVersions {
+		initialized := testNodeClaim(version, "default-a", since.Add(time.Second), map[string]time.Time{
+			"Launched":    since.Add(5 * time.Second),
+			"Registered":  since.Add(35 * time.Second),
+			"Initialized": since.Add(41 * time.Second),
+		})
+		old := testNodeClaim(version, "default-old", since.Add(-time.Hour), map[string]time.Time{"Launched": since.Add(-time.Hour)})
+		registering := testNodeClaim(version, "default-b", since.Add(time.Second), map[string]time.Time{"Launched": since.Add(6 * time.Second)})
+		gvrs := map[schema.GroupVersionResource]string{NodeClaimGVR(version): "NodeClaimList"}
+
+		client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrs, initialized, old)
+		timings, err := NodeClaimTimings(client, version, "default", since)
+		if err != nil {
+			t.Fatalf("NodeClaimTimings(%s) returned error: %v", version, err)
+		}
+		if !reflect.DeepEqual(timings, want) {
+			t.Errorf("NodeClaimTimings(%s) = %+v, want %+v", version, timings, want)
+		}
+
+		client = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrs, initialized, registering)
+		timings, err = NodeClaimTimings(client, version, "default", since)
+		if err == nil || !reflect.DeepEqual(timings, want) {
+			t.Errorf("NodeClaimTimings(%s) with an uninitialized NodeClaim = %+v, %v, want %+v and a timeout error", version, timings, err, want)
+		}
+	}
+}
+
+// TestKarpenterNodePoolKey checks that both supported Karpenter API versions select nodes by the node pool label and
+// that other versions are rejected.
+func TestKarpenterNodePoolKey(t *testing.T) {
+	for _, version := range []string{"v1beta1", "v1"} {
+		if key, err := KarpenterNodePoolKey(version); err != nil || key != "karpenter.sh/nodepool" {
+			t.Errorf("KarpenterNodePoolKey(%q) = %q, %v, want karpenter.sh/nodepool", version, key, err)
+		}
+	}
+	if _, err := KarpenterNodePoolKey("v1alpha5"); err == nil {
+		t.Error("KarpenterNodePoolKey(\"v1alpha5\") returned no error")
+	}
+}
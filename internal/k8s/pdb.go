@@ -0,0 +1,84 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
+
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PDBName returns the name of the PodDisruptionBudget created for the given deployment.
+func PDBName(deploymentName string) string {
+	return deploymentName + "-benchmark-pdb"
+}
+
+// ParsePDBSpec parses a PodDisruptionBudget spec given as "minAvailable=N" or "maxUnavailable=N",
+// where N is a number or a percentage.
+func ParsePDBSpec(spec string) (policyv1.PodDisruptionBudgetSpec, error) {
+	pdbSpec := policyv1.PodDisruptionBudgetSpec{}
+	key, value, found := strings.Cut(spec, "=")
+	if !found {
+		return pdbSpec, fmt.Errorf("PDB spec %q must be minAvailable=N or maxUnavailable=N", spec)
+	}
+
+	parsed, err := parseIntOrPercent(strings.TrimSpace(value))
+	if err != nil {
+		return pdbSpec, fmt.Errorf("Invalid PDB spec %q: %w", spec, err)
+	}
+
+	switch strings.TrimSpace(key) {
+	case "minAvailable":
+		pdbSpec.MinAvailable = &parsed
+	case "maxUnavailable":
+		pdbSpec.MaxUnavailable = &parsed
+	default:
+		return pdbSpec, fmt.Errorf("PDB spec %q must be minAvailable=N or maxUnavailable=N", spec)
+	}
+	return pdbSpec, nil
+}
+
+// CreatePodDisruptionBudget creates a PodDisruptionBudget selecting the pods of the given deployment, so that
+// evictions during node drains and consolidation are limited by the budget in spec.
+func CreatePodDisruptionBudget(clientset kubernetes.Interface, deploymentName, namespace string, spec policyv1.PodDisruptionBudgetSpec) error {
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to get deployment: %w", err)
+	}
+	spec.Selector = deployment.Spec.Selector
+
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: PDBName(deploymentName),
+		},
+		Spec: spec,
+	}
+
+	logging.Progress("Creating pod disruption budget")
+	if _, err := clientset.PolicyV1().PodDisruptionBudgets(namespace).Create(context.Background(), pdb, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("Failed to create pod disruption budget: %w", err)
+	}
+	logging.Progress("Created pod disruption budget", "pdb", pdb.Name, "namespace", namespace)
+
+	return nil
+}
+
+// DeletePodDisruptionBudget removes the PodDisruptionBudget created for the given deployment.
+// A budget that no longer exists is not treated as an error, so the call is safe to repeat during cleanup.
+func DeletePodDisruptionBudget(clientset kubernetes.Interface, deploymentName, namespace string) error {
+	name := PDBName(deploymentName)
+	logging.Progress("Deleting pod disruption budget", "pdb", name, "namespace", namespace)
+	err := clientset.PolicyV1().PodDisruptionBudgets(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("Failed to delete pod disruption budget: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestParsePDBSpec checks the accepted PDB spec forms and that malformed specs are rejected.
+func TestParsePDBSpec(t *testing.T) {
+	spec, err := ParsePDBSpec("minAvailable=2")
+	if err != nil || spec.MinAvailable == nil || spec.MinAvailable.IntValue() != 2 {
+		t.Errorf("ParsePDBSpec(minAvailable=2) = %+v, %v", spec, err)
+	}
+	spec, err = ParsePDBSpec("maxUnavailable=50%")
+	if err != nil || spec.MaxUnavailable == nil || spec.MaxUnavailable.String() != "50%" {
+		t.Errorf("ParsePDBSpec(maxUnavailable=50%%) = %+v, %v", spec, err)
+	}
+
+	for _, invalid := range []string{"2", "minReady=2", "minAvailable=two"} {
+		if _, err := ParsePDBSpec(invalid); err == nil {
+			t.Errorf("ParsePDBSpec(%q) returned nil error", invalid)
+		}
+	}
+}
+
+// TestCreateAndDeletePodDisruptionBudget checks that the PDB selects the deployment's pods and is cleaned up idempotently.
+func TestCreateAndDeletePodDisruptionBudget(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	if err := GenerateDeployment(clientset, testDeploymentConfig()); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	spec, _ := ParsePDBSpec("minAvailable=1")
+	if err := CreatePodDisruptionBudget(clientset, "inflate", "default", spec); err != nil {
+		t.Fatalf("CreatePodDisruptionBudget() returned error: %v", err)
+	}
+
+	pdb, err := clientset.PolicyV1().PodDisruptionBudgets("default").Get(context.Background(), PDBName("inflate"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("pod disruption budget was not created: %v", err)
+	}
+	if pdb.Spec.Selector.MatchLabels["app"] != "inflate" {
+		t.Errorf("CreatePodDisruptionBudget() selector = %v, want app=inflate", pdb.Spec.Selector)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := DeletePodDisruptionBudget(clientset, "inflate", "default"); err != nil {
+			t.Errorf("DeletePodDisruptionBudget() returned error: %v", err)
+		}
+	}
+	if _, err := clientset.PolicyV1().PodDisruptionBudgets("default").Get(context.Background(), PDBName("inflate"), metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("pod disruption budget still exists after deletion: %v", err)
+	}
+}
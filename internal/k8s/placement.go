@@ -0,0 +1,112 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodPlacementIssues compares a pod spec against the node selector and toleration the benchmark expects, returning a
+// description of every mismatch. Pods that are not pinned to the benchmark nodes may schedule onto unrelated nodes,
+// in which case the benchmark measures nothing. An empty tolerationKey skips the toleration check.
+func PodPlacementIssues(spec corev1.PodSpec, nodeSelectorKey, nodeSelectorValue, tolerationKey string) []string {
+	var issues []string
+
+	if !selectsNodes(spec, nodeSelectorKey, nodeSelectorValue) {
+		issues = append(issues, fmt.Sprintf("no nodeSelector or required node affinity for %s=%s", nodeSelectorKey, nodeSelectorValue))
+	}
+
+	if tolerationKey != "" && !toleratesKey(spec.Tolerations, tolerationKey) {
+		issues = append(issues, fmt.Sprintf("no toleration for the %s taint", tolerationKey))
+	}
+
+	return issues
+}
+
+// selectsNodes reports whether the pod spec restricts scheduling to nodes labelled key=value, either through its
+// nodeSelector or a required node affinity term.
+func selectsNodes(spec corev1.PodSpec, key, value string) bool {
+	if spec.NodeSelector[key] == value {
+		return true
+	}
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil || spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return false
+	}
+
+	terms := spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for _, term := range terms {
+		if !termSelects(term, key, value) {
+			return false
+		}
+	}
+	return len(terms) > 0
+}
+
+// termSelects reports whether a node selector term only matches nodes labelled key=value (or carrying key at all).
+func termSelects(term corev1.NodeSelectorTerm, key, value string) bool {
+	for _, expression := range term.MatchExpressions {
+		if expression.Key != key {
+			continue
+		}
+		switch expression.Operator {
+		case corev1.NodeSelectorOpExists:
+			return true
+		case corev1.NodeSelectorOpIn:
+			for _, v := range expression.Values {
+				if v == value {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// toleratesKey reports whether any of the tolerations tolerates taints with the given key.
+func toleratesKey(tolerations []corev1.Toleration, key string) bool {
+	for _, toleration := range tolerations {
+		if toleration.Key == key || (toleration.Key == "" && toleration.Operator == corev1.TolerationOpExists) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckDeploymentPlacement returns the PodPlacementIssues of the pod template of an existing deployment.
+func CheckDeploymentPlacement(clientset kubernetes.Interface, deploymentName, namespace, nodeSelectorKey, nodeSelectorValue, tolerationKey string) ([]string, error) {
+	deployment, err := clientset.AppsV1().Deployments(namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get deployment: %w", err)
+	}
+	return PodPlacementIssues(deployment.Spec.Template.Spec, nodeSelectorKey, nodeSelectorValue, tolerationKey), nil
+}
+
+// ParseToleration parses a toleration in the key=value:effect syntax of kubectl taint, e.g. gpu=true:NoExecute. Without
+// =value the toleration tolerates every value of the key. The effect must be NoSchedule, PreferNoSchedule or NoExecute.
+func ParseToleration(spec string) (corev1.Toleration, error) {
+	keyValue, effect, ok := strings.Cut(spec, ":")
+	if !ok {
+		return corev1.Toleration{}, fmt.Errorf("Toleration must have the form key=value:effect, got %q", spec)
+	}
+	switch corev1.TaintEffect(effect) {
+	case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		return corev1.Toleration{}, fmt.Errorf("Effect of toleration %q must be NoSchedule, PreferNoSchedule or NoExecute, got %q", spec, effect)
+	}
+	key, value, hasValue := strings.Cut(keyValue, "=")
+	if key == "" {
+		return corev1.Toleration{}, fmt.Errorf("Toleration %q has no key", spec)
+	}
+	toleration := corev1.Toleration{Key: key, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffect(effect)}
+	if hasValue {
+		toleration.Operator, toleration.Value = corev1.TolerationOpEqual, value
+	}
+	return toleration, nil
+}
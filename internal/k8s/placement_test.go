@@ -0,0 +1,91 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestPodPlacementIssues checks that the generated pod template passes and that missing selectors and tolerations are reported.
+func TestPodPlacementIssues(t *testing.T) {
+	const key, value = "eks.autify.com/k8s-autoscaler-benchmarker", "true"
+
+	template, err := buildPodTemplate(testDeploymentConfig(), nil)
+	if err != nil {
+		t.Fatalf("buildPodTemplate() returned error: %v", err)
+	}
+	if issues := PodPlacementIssues(template.Spec, key, value, key); len(issues) != 0 {
+		t.Errorf("PodPlacementIssues() for the generated template = %v, want none", issues)
+	}
+
+	nodeSelector := corev1.PodSpec{
+		NodeSelector: map[string]string{key: value},
+		Tolerations:  []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+	}
+	if issues := PodPlacementIssues(nodeSelector, key, value, key); len(issues) != 0 {
+		t.Errorf("PodPlacementIssues() with nodeSelector and wildcard toleration = %v, want none", issues)
+	}
+
+	if issues := PodPlacementIssues(corev1.PodSpec{}, key, value, key); len(issues) != 2 {
+		t.Errorf("PodPlacementIssues() for an unpinned pod = %v, want 2 issues", issues)
+	}
+
+	wrongValue := corev1.PodSpec{NodeSelector: map[string]string{key: "false"}}
+	if issues := PodPlacementIssues(wrongValue, key, value, ""); len(issues) != 1 {
+		t.Errorf("PodPlacementIssues() with a different label value = %v, want 1 issue", issues)
+	}
+}
+
+// TestParseToleration checks the key=value:effect syntax, a key without value tolerating every value, and that
+// malformed tolerations are rejected.
+func TestParseToleration(t *testing.T) {
+	tests := []struct {
+		spec string
+		want corev1.Toleration
+	}{
+		{"gpu=true:NoSchedule", corev1.Toleration{Key: "gpu", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule}},
+		{"dedicated=ml:NoExecute", corev1.Toleration{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ml", Effect: corev1.TaintEffectNoExecute}},
+		{"spot:PreferNoSchedule", corev1.Toleration{Key: "spot", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectPreferNoSchedule}},
+	}
+	for _, tt := range tests {
+		got, err := ParseToleration(tt.spec)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseToleration(%q) = %+v, %v, want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"gpu=true", "gpu=true:NoEvict", "=true:NoSchedule", ":NoSchedule"} {
+		if _, err := ParseToleration(invalid); err == nil {
+			t.Errorf("ParseToleration(%q) returned nil error", invalid)
+		}
+	}
+}
+
+// TestGenerateDeploymentTolerations checks that additional tolerations, including a NoExecute one, are added after the
+// toleration of the toleration key.
+func TestGenerateDeploymentTolerations(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := testDeploymentConfig()
+	gpu, _ := ParseToleration("nvidia.com/gpu=true:NoSchedule")
+	dedicated, _ := ParseToleration("dedicated=ml:NoExecute")
+	cfg.Tolerations = []corev1.Toleration{gpu, dedicated}
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+
+	created, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	tolerations := created.Spec.Template.Spec.Tolerations
+	if len(tolerations) != 3 || tolerations[0].Key != cfg.TolerationKey || !reflect.DeepEqual(tolerations[1:], cfg.Tolerations) {
+		t.Fatalf("Tolerations = %+v, want the toleration key's followed by %+v", tolerations, cfg.Tolerations)
+	}
+	if tolerations[2].Effect != corev1.TaintEffectNoExecute {
+		t.Errorf("Toleration effect = %s, want NoExecute", tolerations[2].Effect)
+	}
+}
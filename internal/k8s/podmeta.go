@@ -0,0 +1,82 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// workloadLabelKey is the label the generated workloads select their pods by. It cannot be overridden with pod labels.
+const workloadLabelKey = "app"
+
+// parseKeyValuePairs parses a comma-separated list of key=value pairs into a map, returning an error naming the kind
+// of pair for a malformed or duplicate one. An empty list yields a nil map.
+func parseKeyValuePairs(pairs, kind string) (map[string]string, error) {
+	var parsed map[string]string
+	for _, part := range strings.Split(pairs, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("Pod %s must have the form key=value, got %q", kind, part)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("Invalid pod %s key %q: %s", kind, key, strings.Join(errs, "; "))
+		}
+		if _, ok := parsed[key]; ok {
+			return nil, fmt.Errorf("Pod %s %q is given more than once", kind, key)
+		}
+		if parsed == nil {
+			parsed = map[string]string{}
+		}
+		parsed[key] = value
+	}
+	return parsed, nil
+}
+
+// ParsePodLabels parses a comma-separated list of key=value labels, e.g. "team=ml,tier=batch", for the pods of the
+// generated workload. The keys and values must be valid label keys and values, and the app label the workload selects
+// its pods by cannot be set.
+func ParsePodLabels(labels string) (map[string]string, error) {
+	parsed, err := parseKeyValuePairs(labels, "label")
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range parsed {
+		if key == workloadLabelKey {
+			return nil, fmt.Errorf("Pod label %q is set by the benchmark to select the workload's pods and cannot be overridden", key)
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("Invalid value %q of pod label %q: %s", value, key, strings.Join(errs, "; "))
+		}
+	}
+	return parsed, nil
+}
+
+// ParsePodAnnotations parses a comma-separated list of key=value annotations for the pods of the generated workload.
+// The keys must be valid annotation keys; the values are free-form but cannot contain commas.
+func ParsePodAnnotations(annotations string) (map[string]string, error) {
+	return parseKeyValuePairs(annotations, "annotation")
+}
+
+// podTemplateLabels returns the labels of the generated pods: the configured pod labels merged with the labels the
+// workload selects its pods by.
+func podTemplateLabels(cfg DeploymentConfig, selectorLabels map[string]string) map[string]string {
+	if len(cfg.PodLabels) == 0 {
+		return selectorLabels
+	}
+	labels := make(map[string]string, len(cfg.PodLabels)+len(selectorLabels))
+	for key, value := range cfg.PodLabels {
+		labels[key] = value
+	}
+	for key, value := range selectorLabels {
+		labels[key] = value
+	}
+	return labels
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestParsePodLabels checks the parsing of several labels and that malformed pairs, invalid values and the app label
+// are rejected.
+func TestParsePodLabels(t *testing.T) {
+	labels, err := ParsePodLabels("team=ml, karpenter.sh/capacity-type=spot,tier=")
+	if err != nil {
+		t.Fatalf("ParsePodLabels() returned error: %v", err)
+	}
+	want := map[string]string{"team": "ml", "karpenter.sh/capacity-type": "spot", "tier": ""}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("ParsePodLabels() = %v, want %v", labels, want)
+	}
+
+	if labels, err := ParsePodLabels(""); err != nil || labels != nil {
+		t.Errorf("ParsePodLabels(\"\") = %v, %v, want no labels", labels, err)
+	}
+	for _, invalid := range []string{"team", "=ml", "team=ml,team=ai", "team=has space", "bad key=ml", "app=other"} {
+		if _, err := ParsePodLabels(invalid); err == nil {
+			t.Errorf("ParsePodLabels(%q) returned nil error", invalid)
+		}
+	}
+}
+
+// TestParsePodAnnotations checks that annotation values are free-form while malformed pairs are rejected.
+func TestParsePodAnnotations(t *testing.T) {
+	annotations, err := ParsePodAnnotations("karpenter.sh/do-not-disrupt=true,note=benchmark run 1")
+	if err != nil {
+		t.Fatalf("ParsePodAnnotations() returned error: %v", err)
+	}
+	if annotations["karpenter.sh/do-not-disrupt"] != "true" || annotations["note"] != "benchmark run 1" {
+		t.Errorf("ParsePodAnnotations() = %v, want do-not-disrupt and note", annotations)
+	}
+	if _, err := ParsePodAnnotations("note"); err == nil {
+		t.Errorf("ParsePodAnnotations(\"note\") returned nil error")
+	}
+}
+
+// TestGenerateDeploymentPodMetadata checks that the pod labels are merged with the app label, which alone selects the
+// pods, and that the annotations are set on the pod template.
+func TestGenerateDeploymentPodMetadata(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := testDeploymentConfig()
+	cfg.PodLabels = map[string]string{"team": "ml", "tier": "batch"}
+	cfg.PodAnnotations = map[string]string{"karpenter.sh/do-not-disrupt": "true"}
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+
+	created, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	wantLabels := map[string]string{"app": "inflate", "team": "ml", "tier": "batch"}
+	if !reflect.DeepEqual(created.Spec.Template.Labels, wantLabels) {
+		t.Errorf("Pod template labels = %v, want %v", created.Spec.Template.Labels, wantLabels)
+	}
+	if !reflect.DeepEqual(created.Spec.Selector.MatchLabels, map[string]string{"app": "inflate"}) {
+		t.Errorf("Selector = %v, want only app=inflate", created.Spec.Selector.MatchLabels)
+	}
+	if created.Spec.Template.Annotations["karpenter.sh/do-not-disrupt"] != "true" {
+		t.Errorf("Pod template annotations = %v, want karpenter.sh/do-not-disrupt=true", created.Spec.Template.Annotations)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// quiescencePollInterval is the interval at which WaitForQuiescence checks the cluster.
+var quiescencePollInterval = 5 * time.Second
+
+// WaitForQuiescence waits until the cluster has been quiet for the whole window: the set of nodes matching
+// labelSelector did not change, all of them meet the readiness requirements and none is being deleted, and no pod in
+// namespace is pending. It
+// returns false without an error if the cluster did not stay quiet for a window within timeout, leaving it to the
+// caller whether to benchmark anyway. Unless verbosity is Quiet, it prints what keeps the cluster busy at every poll.
+func WaitForQuiescence(clientset kubernetes.Interface, labelSelector, namespace string, readiness NodeReadiness, window, timeout time.Duration, verbosity Verbosity) (bool, error) {
+	logging.Progress("Waiting for the cluster to stay quiet before the scale-up", "window", window)
+	startTime := time.Now()
+	quietSince := time.Now()
+	previousNodes := ""
+
+	for {
+		nodes, busy, err := clusterActivity(clientset, labelSelector, namespace, readiness)
+		if err != nil {
+			return false, err
+		}
+		if busy == "" && nodes != previousNodes {
+			busy = "the nodes changed"
+		}
+		previousNodes = nodes
+
+		if busy != "" {
+			verbosity.status("Cluster is not quiet yet", "activity", busy)
+			quietSince = time.Now()
+		} else if time.Since(quietSince) >= window {
+			logging.Progress("Cluster is quiet")
+			return true, nil
+		}
+
+		if time.Since(startTime) >= timeout {
+			return false, nil
+		}
+		time.Sleep(quiescencePollInterval)
+	}
+}
+
+// clusterActivity returns the sorted names of the nodes matching labelSelector, and a description of the activity
+// that keeps the cluster from being quiet, which is empty when there is none.
+func clusterActivity(clientset kubernetes.Interface, labelSelector, namespace string, readiness NodeReadiness) (string, string, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return "", "", fmt.Errorf("Failed to list nodes: %w", err)
+	}
+
+	var names []string
+	busy := ""
+	for _, node := range nodes.Items {
+		names = append(names, node.Name)
+		if node.DeletionTimestamp != nil {
+			busy = fmt.Sprintf("node '%s' is being deleted", node.Name)
+		} else if _, ready := readiness.readySince(node); !ready {
+			busy = fmt.Sprintf("node '%s' is not ready", node.Name)
+		}
+	}
+	sort.Strings(names)
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{FieldSelector: "status.phase=Pending"})
+	if err != nil {
+		return "", "", fmt.Errorf("Failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodPending {
+			busy = fmt.Sprintf("pod '%s' is pending", pod.Name)
+			break
+		}
+	}
+
+	return strings.Join(names, ","), busy, nil
+}
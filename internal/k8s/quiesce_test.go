@@ -0,0 +1,69 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestWaitForQuiescence checks that a cluster with only ready nodes and no pending pods is reported quiet, and that
+// a not ready node, a node still carrying a taint required to be absent or a pending pod keeps it from being quiet
+// until the timeout.
+func TestWaitForQuiescence(t *testing.T) {
+	defer func(interval time.Duration) { quiescencePollInterval = interval }(quiescencePollInterval)
+	quiescencePollInterval = 5 * time.Millisecond
+
+	labels := map[string]string{"karpenter.sh/nodepool": "default"}
+	readyNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "ready", Labels: labels},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+	}
+	notReadyNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "joining", Labels: labels},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}}},
+	}
+	taintedNode := readyNode.DeepCopy()
+	taintedNode.Name = "uninitialized"
+	taintedNode.Spec.Taints = []corev1.Taint{{Key: "node.cloudprovider.kubernetes.io/uninitialized", Effect: corev1.TaintEffectNoSchedule}}
+	pendingPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "leftover", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	runningPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	taintReadiness := NodeReadiness{AbsentTaints: []string{"node.cloudprovider.kubernetes.io/uninitialized"}}
+
+	testCases := []struct {
+		name      string
+		clientset *fake.Clientset
+		readiness NodeReadiness
+		want      bool
+	}{
+		{"quiet", fake.NewSimpleClientset(readyNode, runningPod), NodeReadiness{}, true},
+		{"not ready node", fake.NewSimpleClientset(readyNode, notReadyNode), NodeReadiness{}, false},
+		{"pending pod", fake.NewSimpleClientset(readyNode, pendingPod), NodeReadiness{}, false},
+		{"tainted node", fake.NewSimpleClientset(readyNode, taintedNode), taintReadiness, false},
+		{"taint not required absent", fake.NewSimpleClientset(readyNode, taintedNode), NodeReadiness{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			quiet, err := WaitForQuiescence(tc.clientset, "karpenter.sh/nodepool=default", "default", tc.readiness, 20*time.Millisecond, 100*time.Millisecond, Normal)
+			if err != nil {
+				t.Fatalf("WaitForQuiescence() returned error: %v", err)
+			}
+			if quiet != tc.want {
+				t.Errorf("WaitForQuiescence() = %v, want %v", quiet, tc.want)
+			}
+		})
+	}
+}
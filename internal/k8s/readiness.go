@@ -0,0 +1,94 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NodeReadiness holds the requirements a node has to meet, besides NodeReady=True, to count as registered and ready,
+// for distributions that signal schedulability through other conditions or by removing taints. The zero value only
+// requires NodeReady=True.
+type NodeReadiness struct {
+	// Conditions maps condition types to the status they must have, e.g. MemoryPressure to False.
+	Conditions map[corev1.NodeConditionType]corev1.ConditionStatus
+	// AbsentTaints are the keys of taints the node must not have, e.g. karpenter.sh/unregistered.
+	AbsentTaints []string
+}
+
+// ParseNodeReadiness parses a comma-separated list of Type=Status conditions, e.g. "MemoryPressure=False", and a
+// comma-separated list of taint keys into a NodeReadiness. Empty lists add no requirement.
+func ParseNodeReadiness(conditions, absentTaints string) (NodeReadiness, error) {
+	var readiness NodeReadiness
+	for _, part := range strings.Split(conditions, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		conditionType, status, ok := strings.Cut(part, "=")
+		if !ok || conditionType == "" {
+			return NodeReadiness{}, fmt.Errorf("Node condition must have the form Type=Status, got %q", part)
+		}
+		switch corev1.ConditionStatus(status) {
+		case corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown:
+		default:
+			return NodeReadiness{}, fmt.Errorf("Status of node condition %s must be True, False or Unknown, got %q", conditionType, status)
+		}
+		if readiness.Conditions == nil {
+			readiness.Conditions = make(map[corev1.NodeConditionType]corev1.ConditionStatus)
+		}
+		readiness.Conditions[corev1.NodeConditionType(conditionType)] = corev1.ConditionStatus(status)
+	}
+	for _, key := range strings.Split(absentTaints, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			readiness.AbsentTaints = append(readiness.AbsentTaints, key)
+		}
+	}
+	return readiness, nil
+}
+
+// readySince reports whether the node meets NodeReady=True and the requirements, and the latest transition of the
+// required conditions. Taint removals are not timestamped, so that is not when the node met the requirements when
+// taints are required to be absent.
+func (r NodeReadiness) readySince(node corev1.Node) (time.Time, bool) {
+	var since time.Time
+	matched := 0
+	for _, condition := range node.Status.Conditions {
+		want := r.Conditions[condition.Type]
+		if condition.Type == corev1.NodeReady {
+			want = corev1.ConditionTrue
+		}
+		if want == "" {
+			continue
+		}
+		if condition.Status != want {
+			return time.Time{}, false
+		}
+		matched++
+		if condition.LastTransitionTime.Time.After(since) {
+			since = condition.LastTransitionTime.Time
+		}
+	}
+	// NodeReady is required even if it is not listed in Conditions.
+	required := len(r.Conditions)
+	if _, listed := r.Conditions[corev1.NodeReady]; !listed {
+		required++
+	}
+	if matched < required {
+		return time.Time{}, false
+	}
+
+	for _, taint := range node.Spec.Taints {
+		for _, key := range r.AbsentTaints {
+			if taint.Key == key {
+				return time.Time{}, false
+			}
+		}
+	}
+	return since, true
+}
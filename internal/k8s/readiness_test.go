@@ -0,0 +1,72 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestParseNodeReadiness checks the parsing of the required conditions and taints, and that invalid statuses are rejected.
+func TestParseNodeReadiness(t *testing.T) {
+	readiness, err := ParseNodeReadiness("MemoryPressure=False, DiskPressure=False", "karpenter.sh/unregistered,")
+	if err != nil {
+		t.Fatalf("ParseNodeReadiness() returned error: %v", err)
+	}
+	if len(readiness.Conditions) != 2 || readiness.Conditions["MemoryPressure"] != corev1.ConditionFalse {
+		t.Errorf("ParseNodeReadiness() conditions = %v, want MemoryPressure and DiskPressure False", readiness.Conditions)
+	}
+	if len(readiness.AbsentTaints) != 1 || readiness.AbsentTaints[0] != "karpenter.sh/unregistered" {
+		t.Errorf("ParseNodeReadiness() taints = %v, want [karpenter.sh/unregistered]", readiness.AbsentTaints)
+	}
+
+	if readiness, err := ParseNodeReadiness("", ""); err != nil || readiness.Conditions != nil || readiness.AbsentTaints != nil {
+		t.Errorf("ParseNodeReadiness() of empty lists = %+v, %v, want no requirements", readiness, err)
+	}
+	for _, conditions := range []string{"MemoryPressure", "MemoryPressure=false", "=True"} {
+		if _, err := ParseNodeReadiness(conditions, ""); err == nil {
+			t.Errorf("ParseNodeReadiness(%q) returned nil error", conditions)
+		}
+	}
+}
+
+// TestNodeReadinessReadySince checks that a node is only ready once all required conditions have their status and
+// none of the taints is left, and that the ready time is the latest required transition.
+func TestNodeReadinessReadySince(t *testing.T) {
+	start := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	node := func(memoryPressure corev1.ConditionStatus, taints ...string) corev1.Node {
+		n := corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(start.Add(10 * time.Second))},
+			{Type: corev1.NodeMemoryPressure, Status: memoryPressure, LastTransitionTime: metav1.NewTime(start.Add(25 * time.Second))},
+		}}}
+		for _, key := range taints {
+			n.Spec.Taints = append(n.Spec.Taints, corev1.Taint{Key: key, Effect: corev1.TaintEffectNoSchedule})
+		}
+		return n
+	}
+	readiness, _ := ParseNodeReadiness("MemoryPressure=False", "karpenter.sh/unregistered")
+
+	tests := []struct {
+		name      string
+		readiness NodeReadiness
+		node      corev1.Node
+		wantReady bool
+		wantSince time.Time
+	}{
+		{"default only needs NodeReady", NodeReadiness{}, node(corev1.ConditionTrue, "karpenter.sh/unregistered"), true, start.Add(10 * time.Second)},
+		{"all requirements met", readiness, node(corev1.ConditionFalse), true, start.Add(25 * time.Second)},
+		{"condition has the wrong status", readiness, node(corev1.ConditionTrue), false, time.Time{}},
+		{"taint left", readiness, node(corev1.ConditionFalse, "karpenter.sh/unregistered"), false, time.Time{}},
+		{"condition missing", NodeReadiness{Conditions: map[corev1.NodeConditionType]corev1.ConditionStatus{"NetworkReady": corev1.ConditionTrue}}, node(corev1.ConditionFalse), false, time.Time{}},
+	}
+	for _, tt := range tests {
+		since, ready := tt.readiness.readySince(tt.node)
+		if ready != tt.wantReady || !since.Equal(tt.wantSince) {
+			t.Errorf("%s: readySince() = %v, %v, want %v, %v", tt.name, since, ready, tt.wantSince, tt.wantReady)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// instanceIDFromProviderID returns the EC2 instance ID of a node's spec.providerID, e.g. i-0abc from
+// aws:///us-east-1a/i-0abc, or false if the provider ID does not name an instance.
+func instanceIDFromProviderID(providerID string) (string, bool) {
+	id := providerID[strings.LastIndex(providerID, "/")+1:]
+	return id, strings.HasPrefix(id, "i-")
+}
+
+// KubeletRegistrationLag correlates the nodes matching the label selector with the given instances by the instance ID
+// in their provider ID, and returns the average time from each instance entering the running state until its Node
+// object was created, i.e. the time the OS boot and the kubelet took to join, along with the number of nodes
+// correlated. Node creation timestamps have second precision. The average is zero when no node could be correlated.
+func KubeletRegistrationLag(clientset kubernetes.Interface, labelSelector string, runningTimes map[string]time.Time) (time.Duration, int, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("Failed to list nodes with selector %s: %w", labelSelector, err)
+	}
+
+	var total time.Duration
+	var matched int
+	for _, node := range nodes.Items {
+		id, ok := instanceIDFromProviderID(node.Spec.ProviderID)
+		if !ok {
+			continue
+		}
+		runningTime, ok := runningTimes[id]
+		if !ok {
+			continue
+		}
+		total += node.CreationTimestamp.Sub(runningTime)
+		matched++
+	}
+	if matched == 0 {
+		return 0, 0, nil
+	}
+	return total / time.Duration(matched), matched, nil
+}
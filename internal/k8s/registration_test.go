@@ -0,0 +1,45 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestKubeletRegistrationLag checks that nodes are correlated with the running instances by provider ID, and that
+// nodes of other instances or without an instance provider ID are ignored.
+func TestKubeletRegistrationLag(t *testing.T) {
+	running := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	node := func(name, providerID string, created time.Time) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"pool": "bench"}, CreationTimestamp: metav1.NewTime(created)},
+			Spec:       corev1.NodeSpec{ProviderID: providerID},
+		}
+	}
+	clientset := fake.NewSimpleClientset(
+		node("a", "aws:///us-east-1a/i-a", running.Add(30*time.Second)),
+		node("b", "aws:///us-east-1b/i-b", running.Add(50*time.Second)),
+		node("old", "aws:///us-east-1a/i-old", running.Add(-time.Hour)),
+		node("kind", "kind://docker/kind/worker", running),
+	)
+	runningTimes := map[string]time.Time{"i-a": running, "i-b": running.Add(10 * time.Second), "i-gone": running}
+
+	lag, matched, err := KubeletRegistrationLag(clientset, "pool=bench", runningTimes)
+	if err != nil {
+		t.Fatalf("KubeletRegistrationLag() returned error: %v", err)
+	}
+	if lag != 35*time.Second || matched != 2 {
+		t.Errorf("KubeletRegistrationLag() = %v, %d, want 35s, 2", lag, matched)
+	}
+
+	lag, matched, err = KubeletRegistrationLag(clientset, "pool=bench", nil)
+	if err != nil || lag != 0 || matched != 0 {
+		t.Errorf("KubeletRegistrationLag() without instances = %v, %d, %v, want 0, 0, nil", lag, matched, err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"sync"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeCountSampleInterval is the interval at which NodeCountSampler counts the nodes.
+var nodeCountSampleInterval = 5 * time.Second
+
+// NodeCountSampler counts the nodes matching a label selector at a fixed interval in the background, recording the
+// shape of the scale-up and scale-down.
+type NodeCountSampler struct {
+	mu       sync.Mutex
+	samples  []bench.NodeCountSample
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// StartNodeCountSampler starts counting the nodes matching labelSelector every nodeCountSampleInterval, with the
+// sample times relative to start. Failed counts are skipped.
+func StartNodeCountSampler(clientset kubernetes.Interface, labelSelector string, start time.Time) *NodeCountSampler {
+	sampler := &NodeCountSampler{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(sampler.done)
+		ticker := time.NewTicker(nodeCountSampleInterval)
+		defer ticker.Stop()
+		for {
+			if count, err := CountNodes(clientset, labelSelector); err == nil {
+				sampler.mu.Lock()
+				sampler.samples = append(sampler.samples, bench.NodeCountSample{Elapsed: time.Since(start), Nodes: count})
+				sampler.mu.Unlock()
+			}
+			select {
+			case <-sampler.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return sampler
+}
+
+// Stop stops the sampling and returns the samples recorded so far. It is safe to call more than once.
+func (s *NodeCountSampler) Stop() []bench.NodeCountSample {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]bench.NodeCountSample(nil), s.samples...)
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestNodeCountSampler checks that the sampler records the number of matching nodes over time and that Stop is safe
+// to repeat.
+func TestNodeCountSampler(t *testing.T) {
+	defer func(interval time.Duration) { nodeCountSampleInterval = interval }(nodeCountSampleInterval)
+	nodeCountSampleInterval = 10 * time.Millisecond
+
+	clientset := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "system", Labels: map[string]string{"role": "system"}}},
+	)
+	sampler := StartNodeCountSampler(clientset, "karpenter.sh/nodepool=default", time.Now())
+	time.Sleep(50 * time.Millisecond)
+	for _, name := range []string{"node-1", "node-2"} {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"karpenter.sh/nodepool": "default"}}}
+		if _, err := clientset.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	samples := sampler.Stop()
+	if len(samples) < 2 {
+		t.Fatalf("Stop() returned %d samples, want several", len(samples))
+	}
+	if first, last := samples[0], samples[len(samples)-1]; first.Nodes != 0 || last.Nodes != 2 || last.Elapsed <= first.Elapsed {
+		t.Errorf("Stop() samples = %+v, want 0 nodes rising to 2", samples)
+	}
+	if again := sampler.Stop(); len(again) != len(samples) {
+		t.Errorf("second Stop() returned %d samples, want %d", len(again), len(samples))
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// LabelSelector returns the equality label selector "key=value", after validating that key is a qualified label name
+// and value a valid label value, so node pool or node group names that are not valid label values are reported
+// instead of producing a malformed selector.
+func LabelSelector(key, value string) (string, error) {
+	selector, err := labels.ValidatedSelectorFromSet(labels.Set{key: value})
+	if err != nil {
+		return "", fmt.Errorf("Invalid label selector %s=%s: %w", key, value, err)
+	}
+	return selector.String(), nil
+}
+
+// WithLabelFilter narrows selector to the nodes that also carry the label given by filter as "key=value", so nodes
+// sharing the autoscaler's labels, e.g. system nodes in mixed clusters, can be excluded. selector is returned unchanged
+// when filter is empty.
+func WithLabelFilter(selector, filter string) (string, error) {
+	if filter == "" {
+		return selector, nil
+	}
+	key, value, ok := strings.Cut(filter, "=")
+	if !ok {
+		return "", fmt.Errorf("Invalid label filter %q, expected key=value", filter)
+	}
+	filterSelector, err := LabelSelector(key, value)
+	if err != nil {
+		return "", err
+	}
+	if selector == "" {
+		return filterSelector, nil
+	}
+	return selector + "," + filterSelector, nil
+}
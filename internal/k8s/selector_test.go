@@ -0,0 +1,52 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import "testing"
+
+// TestLabelSelector checks that keys and values with dots, slashes and dashes produce well-formed selectors and that
+// values which are not valid label values, e.g. containing slashes or colons, are rejected.
+func TestLabelSelector(t *testing.T) {
+	selector, err := LabelSelector("karpenter.sh/nodepool", "team-a.spot_v2")
+	if err != nil {
+		t.Fatalf("LabelSelector() returned error: %v", err)
+	}
+	if selector != "karpenter.sh/nodepool=team-a.spot_v2" {
+		t.Errorf("LabelSelector() = %q, want %q", selector, "karpenter.sh/nodepool=team-a.spot_v2")
+	}
+
+	for _, invalid := range [][2]string{
+		{"eks.amazonaws.com/nodegroup", "team/a"},
+		{"eks.amazonaws.com/nodegroup", "team:a"},
+		{"eks.amazonaws.com/nodegroup", "a,b=c"},
+		{"eks.amazonaws.com:nodegroup", "a"},
+	} {
+		if _, err := LabelSelector(invalid[0], invalid[1]); err == nil {
+			t.Errorf("LabelSelector(%q, %q) returned nil error", invalid[0], invalid[1])
+		}
+	}
+}
+
+// TestWithLabelFilter checks that the filter label is appended to the selector, that an empty filter leaves it unchanged
+// and that malformed filters are rejected.
+func TestWithLabelFilter(t *testing.T) {
+	selector, err := WithLabelFilter("karpenter.sh/nodepool=default", "node-role=benchmark")
+	if err != nil {
+		t.Fatalf("WithLabelFilter() returned error: %v", err)
+	}
+	if selector != "karpenter.sh/nodepool=default,node-role=benchmark" {
+		t.Errorf("WithLabelFilter() = %q, want %q", selector, "karpenter.sh/nodepool=default,node-role=benchmark")
+	}
+
+	selector, err = WithLabelFilter("karpenter.sh/nodepool=default", "")
+	if err != nil || selector != "karpenter.sh/nodepool=default" {
+		t.Errorf("WithLabelFilter() with an empty filter = %q, %v, want the selector unchanged", selector, err)
+	}
+
+	for _, invalid := range []string{"node-role", "node-role=a/b", "=benchmark"} {
+		if _, err := WithLabelFilter("karpenter.sh/nodepool=default", invalid); err == nil {
+			t.Errorf("WithLabelFilter(%q) returned nil error", invalid)
+		}
+	}
+}
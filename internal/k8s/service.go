@@ -0,0 +1,62 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CreateService creates a ClusterIP Service with the workload's name that selects the pods of a generated workload
+// (labelled app=<name>) and exposes the given container port.
+func CreateService(clientset kubernetes.Interface, name, namespace string, port int) error {
+	if port == 0 {
+		return fmt.Errorf("A container port is required to create a service")
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"app": name},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{"app": name},
+			Ports: []corev1.ServicePort{
+				{
+					Protocol:   corev1.ProtocolTCP,
+					Port:       int32(port),
+					TargetPort: intstr.FromInt(port),
+				},
+			},
+		},
+	}
+
+	logging.Progress("Creating service")
+	if _, err := clientset.CoreV1().Services(namespace).Create(context.Background(), service, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("Failed to create service: %w", err)
+	}
+	logging.Progress("Created service", "service", name, "namespace", namespace)
+
+	return nil
+}
+
+// DeleteService removes the given Service from the namespace.
+// A Service that no longer exists is not treated as an error, so the call is safe to repeat during cleanup.
+func DeleteService(clientset kubernetes.Interface, name, namespace string) error {
+	logging.Progress("Deleting service", "service", name, "namespace", namespace)
+	err := clientset.CoreV1().Services(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("Failed to delete service: %w", err)
+	}
+	return nil
+}
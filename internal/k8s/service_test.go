@@ -0,0 +1,47 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestContainerPortAndService checks that the container port is declared and the service selects the generated pods.
+func TestContainerPortAndService(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	cfg := testDeploymentConfig()
+	cfg.ContainerPort = 8080
+	if err := GenerateDeployment(clientset, cfg); err != nil {
+		t.Fatalf("GenerateDeployment() returned error: %v", err)
+	}
+	deployment, _ := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	if ports := deployment.Spec.Template.Spec.Containers[0].Ports; len(ports) != 1 || ports[0].ContainerPort != 8080 {
+		t.Errorf("GenerateDeployment() container ports = %v, want [8080]", ports)
+	}
+
+	if err := CreateService(clientset, "inflate", "default", 8080); err != nil {
+		t.Fatalf("CreateService() returned error: %v", err)
+	}
+	service, err := clientset.CoreV1().Services("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("service was not created: %v", err)
+	}
+	if service.Spec.Selector["app"] != deployment.Spec.Template.Labels["app"] || service.Spec.Ports[0].TargetPort.IntValue() != 8080 {
+		t.Errorf("CreateService() spec = %+v, want selector app=inflate and target port 8080", service.Spec)
+	}
+
+	if err := DeleteService(clientset, "inflate", "default"); err != nil {
+		t.Errorf("DeleteService() returned error: %v", err)
+	}
+	if err := DeleteService(clientset, "inflate", "default"); err != nil {
+		t.Errorf("DeleteService() of a missing service returned error: %v", err)
+	}
+	if err := CreateService(clientset, "inflate", "default", 0); err == nil {
+		t.Errorf("CreateService() without a port returned nil error")
+	}
+}
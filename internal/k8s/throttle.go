@@ -0,0 +1,58 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"math"
+	"net/http"
+	"sync/atomic"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// RequestCounter counts the requests sent to the API server by the clients whose transport it wraps.
+type RequestCounter struct {
+	requests atomic.Int64
+}
+
+// Requests returns the number of requests counted so far.
+func (c *RequestCounter) Requests() int64 {
+	return c.requests.Load()
+}
+
+// wrap returns a round tripper counting every request before passing it on to rt.
+func (c *RequestCounter) wrap(rt http.RoundTripper) http.RoundTripper {
+	return countingRoundTripper{next: rt, counter: c}
+}
+
+// countingRoundTripper counts the requests it passes on to next.
+type countingRoundTripper struct {
+	next    http.RoundTripper
+	counter *RequestCounter
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.counter.requests.Add(1)
+	return t.next.RoundTrip(req)
+}
+
+// NewRequestLimiter returns a rate limiter allowing maxRPS requests per second on average, with bursts of up to one
+// second's worth of requests. It returns nil for a non-positive maxRPS, which leaves client-go's default limit in place.
+func NewRequestLimiter(maxRPS float64) flowcontrol.RateLimiter {
+	if maxRPS <= 0 {
+		return nil
+	}
+	return flowcontrol.NewTokenBucketRateLimiter(float32(maxRPS), int(math.Max(1, math.Ceil(maxRPS))))
+}
+
+// ConfigureRequests makes the clients built from config count their requests with counter and, if limiter is not nil,
+// share it to cap their combined request rate.
+func ConfigureRequests(config *rest.Config, limiter flowcontrol.RateLimiter, counter *RequestCounter) {
+	if limiter != nil {
+		config.RateLimiter = limiter
+	}
+	config.Wrap(counter.wrap)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// TestConfigureRequests checks that the requests of every client sharing a configuration are counted, and that a
+// shared limiter caps their combined rate.
+func TestConfigureRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(corev1.NodeList{TypeMeta: metav1.TypeMeta{Kind: "NodeList", APIVersion: "v1"}})
+	}))
+	defer server.Close()
+
+	var counter RequestCounter
+	limiter := NewRequestLimiter(10)
+	clients := make([]*kubernetes.Clientset, 2)
+	for i := range clients {
+		config := &rest.Config{Host: server.URL}
+		ConfigureRequests(config, limiter, &counter)
+		clients[i] = kubernetes.NewForConfigOrDie(config)
+	}
+
+	start := time.Now()
+	for i := 0; i < 15; i++ {
+		if _, err := clients[i%2].CoreV1().Nodes().List(context.Background(), metav1.ListOptions{}); err != nil {
+			t.Fatalf("List() returned error: %v", err)
+		}
+	}
+	if got := counter.Requests(); got != 15 {
+		t.Errorf("Requests() = %d, want 15", got)
+	}
+	// A burst of 10 requests passes at once, the other 5 wait for tokens refilled at 10 per second.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("15 requests limited to 10 per second took %v, want at least 400ms", elapsed)
+	}
+}
+
+// TestNewRequestLimiter checks that no limiter replaces client-go's default without a positive maximum.
+func TestNewRequestLimiter(t *testing.T) {
+	if limiter := NewRequestLimiter(0); limiter != nil {
+		t.Errorf("NewRequestLimiter(0) = %v, want nil", limiter)
+	}
+	if limiter := NewRequestLimiter(0.5); limiter == nil || limiter.QPS() != 0.5 {
+		t.Errorf("NewRequestLimiter(0.5) = %v, want a limiter of 0.5 requests per second", limiter)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+// Package logging configures the log output of the k8s-autoscaler-benchmarker, either as the traditional text lines or
+// as JSON objects for log aggregation pipelines. The logs are separate from the summary, which is written to stdout.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Formats are the log formats Setup accepts.
+var Formats = []string{"text", "json"}
+
+// Setup directs the logs to w in the given format. It is called once, before anything is logged. With "text" they
+// keep the log package's format; with "json" every log line, including those written with the log package, becomes a
+// JSON object with time, level and msg fields and the fields of the event.
+func Setup(w io.Writer, format string) error {
+	switch format {
+	case "text":
+		// slog's default logger writes its events through the log package, as "INFO msg key=value" lines.
+		log.SetOutput(w)
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(w, nil)))
+	default:
+		return fmt.Errorf("Log format must be one of %s, got %q", strings.Join(Formats, ", "), format)
+	}
+	return nil
+}
+
+// ForRun returns the logger of a benchmark run, which adds the autoscaler and the target to every event.
+func ForRun(autoscaler, target string) *slog.Logger {
+	return slog.Default().With("autoscaler", autoscaler, "target", target)
+}
+
+// Phase logs that a benchmark phase completed after elapsed, as a "Phase completed" event with the phase and
+// elapsed_ms fields.
+func Phase(logger *slog.Logger, phase string, elapsed time.Duration) {
+	logger.Info("Phase completed", "phase", phase, "elapsed_ms", elapsed.Milliseconds())
+}
+
+// Warn logs a problem the benchmark recovers from, with the fields given as alternating keys and values.
+func Warn(msg string, args ...any) {
+	slog.Warn(msg, args...)
+}
+
+// Progress logs the progress of a benchmark, e.g. a monitor starting or a resource being created, with the fields given
+// as alternating keys and values. It goes to the logs rather than stdout, which only carries the summary.
+func Progress(msg string, args ...any) {
+	slog.Info(msg, args...)
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// restoreDefaults resets the loggers Setup changes once the test finished.
+func restoreDefaults(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	})
+}
+
+// TestSetupJSON checks that phase events, warnings and log package lines are written as JSON objects with their fields.
+func TestSetupJSON(t *testing.T) {
+	restoreDefaults(t)
+	var buf bytes.Buffer
+	if err := Setup(&buf, "json"); err != nil {
+		t.Fatalf("Setup() returned error: %v", err)
+	}
+
+	Phase(ForRun("Karpenter", "default"), "pod readiness", 1500*time.Millisecond)
+	Warn("Failed to list EC2 instances, retrying", "consecutive_errors", 2)
+	log.Printf("Warning: unable to determine the first pod schedule time")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Setup() logged %d lines, want 3:\n%s", len(lines), buf.String())
+	}
+	events := make([]map[string]any, len(lines))
+	for i, line := range lines {
+		if err := json.Unmarshal([]byte(line), &events[i]); err != nil {
+			t.Fatalf("Log line %q is not JSON: %v", line, err)
+		}
+	}
+
+	phase := events[0]
+	want := map[string]any{"level": "INFO", "msg": "Phase completed", "phase": "pod readiness", "elapsed_ms": 1500.0, "autoscaler": "Karpenter", "target": "default"}
+	for key, value := range want {
+		if phase[key] != value {
+			t.Errorf("Phase event %s = %v, want %v", key, phase[key], value)
+		}
+	}
+	if _, ok := phase["time"]; !ok {
+		t.Errorf("Phase event has no time field: %v", phase)
+	}
+	if events[1]["level"] != "WARN" || events[1]["consecutive_errors"] != 2.0 {
+		t.Errorf("Warning event = %v, want level WARN and consecutive_errors 2", events[1])
+	}
+	if events[2]["msg"] != "Warning: unable to determine the first pod schedule time" {
+		t.Errorf("log package line = %v, want its message in msg", events[2])
+	}
+}
+
+// TestSetupText checks that the text format keeps the log package's lines and rejects unknown formats.
+func TestSetupText(t *testing.T) {
+	restoreDefaults(t)
+	var buf bytes.Buffer
+	if err := Setup(&buf, "text"); err != nil {
+		t.Fatalf("Setup() returned error: %v", err)
+	}
+	log.Printf("Warning: something happened")
+	if !strings.HasSuffix(buf.String(), " Warning: something happened\n") || strings.HasPrefix(buf.String(), "{") {
+		t.Errorf("Text log line = %q, want the log package's format", buf.String())
+	}
+
+	if err := Setup(&buf, "yaml"); err == nil {
+		t.Errorf("Setup() with an unknown format returned nil error")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package utilities
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// caFunctionDurationMetric is the Cluster Autoscaler histogram of the duration of its internal functions.
+const caFunctionDurationMetric = "cluster_autoscaler_function_duration_seconds"
+
+// caMetricsTimeout bounds a single scrape of the Cluster Autoscaler metrics endpoint.
+const caMetricsTimeout = 10 * time.Second
+
+// functionLabel matches the function label of a Cluster Autoscaler metric sample.
+var functionLabel = regexp.MustCompile(`function="([^"]*)"`)
+
+// ScrapeCAFunctionDurations fetches the Prometheus metrics exposed by Cluster Autoscaler at url (e.g.
+// http://localhost:8085/metrics) and returns the totals of its function duration histograms.
+func ScrapeCAFunctionDurations(url string) (map[string]bench.FunctionTotals, error) {
+	client := http.Client{Timeout: caMetricsTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to scrape Cluster Autoscaler metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to scrape Cluster Autoscaler metrics: %s", resp.Status)
+	}
+	return ParseCAFunctionDurations(resp.Body)
+}
+
+// ParseCAFunctionDurations reads the Prometheus text exposition format and returns the _sum and _count samples of
+// cluster_autoscaler_function_duration_seconds keyed by their function label. Other metrics are ignored.
+func ParseCAFunctionDurations(r io.Reader) (map[string]bench.FunctionTotals, error) {
+	totals := make(map[string]bench.FunctionTotals)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, caFunctionDurationMetric) {
+			continue
+		}
+		name, rest, _ := strings.Cut(line, "{")
+		labels, value, ok := strings.Cut(rest, "}")
+		if !ok {
+			continue
+		}
+		match := functionLabel.FindStringSubmatch(labels)
+		fields := strings.Fields(value)
+		if match == nil || len(fields) == 0 {
+			continue
+		}
+		sample, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s sample %q: %w", name, line, err)
+		}
+
+		function := totals[match[1]]
+		switch name {
+		case caFunctionDurationMetric + "_sum":
+			function.Sum = sample
+		case caFunctionDurationMetric + "_count":
+			function.Count = sample
+		default:
+			continue
+		}
+		totals[match[1]] = function
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read Cluster Autoscaler metrics: %w", err)
+	}
+	return totals, nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package utilities
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// caMetrics is an excerpt of the metrics exposed by Cluster Autoscaler.
+const caMetrics = `# HELP cluster_autoscaler_function_duration_seconds Time taken by various parts of CA main loop.
+# TYPE cluster_autoscaler_function_duration_seconds histogram
+cluster_autoscaler_function_duration_seconds_bucket{function="main",le="0.01"} 10
+cluster_autoscaler_function_duration_seconds_sum{function="main"} 12.5
+cluster_autoscaler_function_duration_seconds_count{function="main"} 25
+cluster_autoscaler_function_duration_seconds_sum{function="scaleUp"} 3
+cluster_autoscaler_function_duration_seconds_count{function="scaleUp"} 2 1712345678000
+# HELP cluster_autoscaler_nodes_count Number of nodes in cluster.
+cluster_autoscaler_nodes_count{state="ready"} 3
+`
+
+// TestParseCAFunctionDurations checks that the sums and counts are collected per function and other samples ignored.
+func TestParseCAFunctionDurations(t *testing.T) {
+	got, err := ParseCAFunctionDurations(strings.NewReader(caMetrics))
+	if err != nil {
+		t.Fatalf("ParseCAFunctionDurations() returned error: %v", err)
+	}
+	want := map[string]bench.FunctionTotals{
+		"main":    {Sum: 12.5, Count: 25},
+		"scaleUp": {Sum: 3, Count: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCAFunctionDurations() = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseCAFunctionDurations(strings.NewReader(`cluster_autoscaler_function_duration_seconds_sum{function="main"} abc`)); err == nil {
+		t.Error("ParseCAFunctionDurations() with an invalid sample returned no error")
+	}
+}
+
+// TestScrapeCAFunctionDurations checks that the metrics are fetched over HTTP and that error statuses are reported.
+func TestScrapeCAFunctionDurations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, caMetrics)
+	}))
+	defer server.Close()
+
+	totals, err := ScrapeCAFunctionDurations(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("ScrapeCAFunctionDurations() returned error: %v", err)
+	}
+	if totals["main"].Count != 25 {
+		t.Errorf("ScrapeCAFunctionDurations() = %+v, want the main function counted 25 times", totals)
+	}
+
+	if _, err := ScrapeCAFunctionDurations(server.URL + "/missing"); err == nil {
+		t.Error("ScrapeCAFunctionDurations() of a missing endpoint returned no error")
+	}
+}
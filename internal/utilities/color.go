@@ -0,0 +1,22 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package utilities
+
+// colorEnabled reports whether the printed summaries use ANSI colors. It is set once at startup by SetColor.
+var colorEnabled = true
+
+// SetColor enables or disables the ANSI colors of the printed summaries, e.g. for --no-color or when stdout is not
+// a terminal, so that output piped to a file stays readable.
+func SetColor(enabled bool) {
+	colorEnabled = enabled
+}
+
+// color returns the ANSI escape code, or an empty string when colors are disabled.
+func color(code string) string {
+	if !colorEnabled {
+		return ""
+	}
+	return code
+}
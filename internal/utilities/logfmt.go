@@ -0,0 +1,52 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package utilities
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// FormatLogfmt returns a single logfmt line summarizing a successful benchmark result, e.g.
+// "autoscaler=Karpenter target=default phase_provision_s=12.30 ... status=ok", for log aggregators that parse logfmt.
+func FormatLogfmt(result *bench.BenchmarkResult) string {
+	pairs := []string{logfmtPair("autoscaler", result.AutoscalerType)}
+	if result.Target != "" {
+		pairs = append(pairs, logfmtPair("target", result.Target))
+	}
+	for _, phase := range bench.PhaseNames {
+		pairs = append(pairs, logfmtPair("phase_"+phase+"_s", fmt.Sprintf("%.2f", result.PhaseDuration(phase).Seconds())))
+	}
+	pairs = append(pairs,
+		logfmtPair("instances", strconv.Itoa(result.InstanceCount)),
+		logfmtPair("completeness", strconv.Itoa(result.CompletenessScore())),
+		logfmtPair("status", "ok"),
+	)
+	return strings.Join(pairs, " ")
+}
+
+// FormatLogfmtFailure returns the logfmt line of a failed benchmark, "status=failed reason=...", including the failed
+// phase when err is a bench.PhaseError.
+func FormatLogfmtFailure(err error) string {
+	pairs := []string{logfmtPair("status", "failed")}
+	var phaseErr *bench.PhaseError
+	if errors.As(err, &phaseErr) {
+		pairs = append(pairs, logfmtPair("phase", phaseErr.Phase))
+	}
+	pairs = append(pairs, logfmtPair("reason", strings.ReplaceAll(err.Error(), "\n", "; ")))
+	return strings.Join(pairs, " ")
+}
+
+// logfmtPair formats key=value, quoting the value when it is empty or contains spaces, quotes or equal signs.
+func logfmtPair(key, value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\"=") {
+		value = strconv.Quote(value)
+	}
+	return key + "=" + value
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package utilities
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// TestFormatLogfmt checks that every phase is emitted in seconds and that values with spaces are quoted.
+func TestFormatLogfmt(t *testing.T) {
+	got := FormatLogfmt(&bench.BenchmarkResult{
+		AutoscalerType:           "Cluster Autoscaler",
+		Target:                   "benchmark-ng",
+		InstanceProvisioningTime: 12300 * time.Millisecond,
+		InstanceRegistrationTime: 45600 * time.Millisecond,
+		PodReadinessTime:         3 * time.Second,
+		InstanceCount:            2,
+		RegisteredNodes:          2,
+	})
+	want := `autoscaler="Cluster Autoscaler" target=benchmark-ng phase_provision_s=12.30 phase_register_s=45.60 phase_ready_s=3.00 phase_dereg_s=0.00 phase_terminate_s=0.00 instances=2 completeness=100 status=ok`
+	if got != want {
+		t.Errorf("FormatLogfmt() = %s, want %s", got, want)
+	}
+}
+
+// TestFormatLogfmtFailure checks that the failed phase and the quoted reason are emitted.
+func TestFormatLogfmtFailure(t *testing.T) {
+	err := bench.NewPhaseError("pod readiness", errors.New(`pods "inflate" not ready`))
+	want := `status=failed phase="pod readiness" reason="Error during pod readiness: pods \"inflate\" not ready"`
+	if got := FormatLogfmtFailure(err); got != want {
+		t.Errorf("FormatLogfmtFailure() = %s, want %s", got, want)
+	}
+}
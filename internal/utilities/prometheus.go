@@ -0,0 +1,252 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package utilities
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// metricPrefix is the common prefix of every exported Prometheus metric but lastRunMetric.
+const metricPrefix = "k8s_autoscaler_benchmarker_"
+
+// lastRunMetric is the name of the gauge holding the Unix time at which the last run finished.
+const lastRunMetric = "kab_last_run_timestamp_seconds"
+
+// WritePrometheusMetrics writes the benchmark results in the Prometheus text exposition format, one sample per result
+// labelled with its autoscaler, target, namespace and replica count.
+func WritePrometheusMetrics(w io.Writer, results []*bench.BenchmarkResult) error {
+	phases := []struct {
+		name     string
+		duration func(*bench.BenchmarkResult) time.Duration
+	}{
+		{"instance_provisioning", func(r *bench.BenchmarkResult) time.Duration { return r.InstanceProvisioningTime }},
+		{"instance_registration", func(r *bench.BenchmarkResult) time.Duration { return r.InstanceRegistrationTime }},
+		{"pod_readiness", func(r *bench.BenchmarkResult) time.Duration { return r.PodReadinessTime }},
+		{"node_deregistration", func(r *bench.BenchmarkResult) time.Duration { return r.NodeDeregistrationTime }},
+		{"instance_termination", func(r *bench.BenchmarkResult) time.Duration { return r.InstanceTerminationTime }},
+	}
+
+	var b strings.Builder
+	writeHeader(&b, "phase_duration_seconds", "Duration of each benchmark phase in seconds.")
+	for _, result := range results {
+		for _, phase := range phases {
+			fmt.Fprintf(&b, "%sphase_duration_seconds{%s,phase=%q} %g\n", metricPrefix, resultLabels(result), phase.name, phase.duration(result).Seconds())
+		}
+	}
+
+	writeHeader(&b, "scale_up_seconds", "Total duration of the scale-up phases (provisioning, registration and pod readiness) in seconds.")
+	for _, result := range results {
+		fmt.Fprintf(&b, "%sscale_up_seconds{%s} %g\n", metricPrefix, resultLabels(result), result.ScaleUpTime().Seconds())
+	}
+	writeHeader(&b, "scale_down_seconds", "Total duration of the scale-down phases (node deregistration and instance termination) in seconds.")
+	for _, result := range results {
+		fmt.Fprintf(&b, "%sscale_down_seconds{%s} %g\n", metricPrefix, resultLabels(result), result.ScaleDownTime().Seconds())
+	}
+
+	gauges := []struct {
+		name, help string
+		value      func(*bench.BenchmarkResult) float64
+	}{
+		{"instances_launched", "Number of EC2 instances launched during the scale-up.", func(r *bench.BenchmarkResult) float64 { return float64(r.InstanceCount) }},
+		{"describe_instances_calls", "Number of EC2 DescribeInstances requests the run sent.", func(r *bench.BenchmarkResult) float64 { return float64(r.DescribeInstancesCalls) }},
+		{"completeness_score", "Scale-up completeness score from 0 to 100.", func(r *bench.BenchmarkResult) float64 { return float64(r.CompletenessScore()) }},
+		{"provisioning_seconds_per_node", "Time until the last instance launched divided by the number of launched instances.", func(r *bench.BenchmarkResult) float64 { return r.ProvisioningSecondsPerNode() }},
+		{"readiness_seconds_per_pod", "Pod readiness time divided by the number of ready pods.", func(r *bench.BenchmarkResult) float64 { return r.ReadinessSecondsPerPod() }},
+		{"bin_packing_efficiency_percent", "Share of the registered nodes' allocatable CPU requested by the benchmark pods.", func(r *bench.BenchmarkResult) float64 { return r.BinPackingEfficiencyPercent() }},
+	}
+	for _, gauge := range gauges {
+		writeHeader(&b, gauge.name, gauge.help)
+		for _, result := range results {
+			fmt.Fprintf(&b, "%s%s{%s} %g\n", metricPrefix, gauge.name, resultLabels(result), gauge.value(result))
+		}
+	}
+
+	writeHeader(&b, "run_info", "Always 1, labelled with the metadata the run was tagged with via --metadata.")
+	for _, result := range results {
+		fmt.Fprintf(&b, "%srun_info{%s%s} 1\n", metricPrefix, resultLabels(result), metadataLabels(result.Metadata))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WritePrometheusTextfile writes the benchmark results and a kab_last_run_timestamp_seconds gauge set to finishedAt to
+// path in the format of the node_exporter textfile collector. The file is written to a temporary file in the same
+// directory and renamed into place, so the collector never reads a partial file. path must end in ".prom".
+func WritePrometheusTextfile(path string, results []*bench.BenchmarkResult, finishedAt time.Time) error {
+	if filepath.Ext(path) != ".prom" {
+		return fmt.Errorf("Prometheus textfile %q must have the .prom extension to be picked up by node_exporter", path)
+	}
+
+	var b strings.Builder
+	if err := writeRunMetrics(&b, results, finishedAt); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("Failed to create Prometheus textfile: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write Prometheus textfile: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Failed to write Prometheus textfile: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("Failed to write Prometheus textfile: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("Failed to write Prometheus textfile: %w", err)
+	}
+	return nil
+}
+
+// pushgatewayTimeout bounds a single push to the Prometheus Pushgateway.
+const pushgatewayTimeout = 10 * time.Second
+
+// PushPrometheusMetrics pushes the benchmark results and a kab_last_run_timestamp_seconds gauge set to finishedAt to the
+// Prometheus Pushgateway at gatewayURL, grouped under the given job. The push replaces the metrics previously pushed
+// for the job, so the gateway always holds the results of the last run.
+func PushPrometheusMetrics(gatewayURL, job string, results []*bench.BenchmarkResult, finishedAt time.Time) error {
+	var b strings.Builder
+	if err := writeRunMetrics(&b, results, finishedAt); err != nil {
+		return err
+	}
+
+	pushURL := strings.TrimSuffix(gatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+	req, err := http.NewRequest(http.MethodPut, pushURL, strings.NewReader(b.String()))
+	if err != nil {
+		return fmt.Errorf("Invalid Pushgateway URL %q: %w", gatewayURL, err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	client := http.Client{Timeout: pushgatewayTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed to push metrics to %s: %w", pushURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("Failed to push metrics to %s: %s: %s", pushURL, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// writeRunMetrics writes the metrics of the results followed by a kab_last_run_timestamp_seconds gauge set to finishedAt.
+// Only the last of the results sharing a label set, e.g. the runs of --iterations, is written: duplicate samples make
+// a textfile invalid and are rejected by the Pushgateway.
+func writeRunMetrics(b *strings.Builder, results []*bench.BenchmarkResult, finishedAt time.Time) error {
+	if err := WritePrometheusMetrics(b, lastResults(results)); err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "# HELP %s Unix time at which the last benchmark run finished.\n", lastRunMetric)
+	fmt.Fprintf(b, "# TYPE %s gauge\n", lastRunMetric)
+	fmt.Fprintf(b, "%s %d\n", lastRunMetric, finishedAt.Unix())
+	return nil
+}
+
+// lastResults returns the last result of each label set, in the order the label sets first appear.
+func lastResults(results []*bench.BenchmarkResult) []*bench.BenchmarkResult {
+	index := map[string]int{}
+	var last []*bench.BenchmarkResult
+	for _, result := range results {
+		labels := resultLabels(result)
+		if i, ok := index[labels]; ok {
+			last[i] = result
+			continue
+		}
+		index[labels] = len(last)
+		last = append(last, result)
+	}
+	return last
+}
+
+// OpenMetricsContentType is the content type of the OpenMetrics text format, which WriteRunsCounter uses to attach exemplars.
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// WriteRunsCounter writes a runs_total counter with the number of completed benchmark runs. In the OpenMetrics format
+// and with a non-empty traceID, the sample carries an exemplar with the trace ID of the last run observed at finishedAt,
+// so a metric can be linked to the trace of the run that produced it. The Prometheus text format has no exemplars, and
+// OpenMetrics only allows them on counters and histogram buckets, which is why they are not attached to the gauges.
+func WriteRunsCounter(w io.Writer, runs int, traceID string, finishedAt time.Time, openMetrics bool) error {
+	var b strings.Builder
+	if !openMetrics {
+		fmt.Fprintf(&b, "# HELP %sruns_total Number of benchmark runs completed.\n", metricPrefix)
+		fmt.Fprintf(&b, "# TYPE %sruns_total counter\n", metricPrefix)
+		fmt.Fprintf(&b, "%sruns_total %d\n", metricPrefix, runs)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	fmt.Fprintf(&b, "# HELP %sruns Number of benchmark runs completed.\n", metricPrefix)
+	fmt.Fprintf(&b, "# TYPE %sruns counter\n", metricPrefix)
+	fmt.Fprintf(&b, "%sruns_total %d", metricPrefix, runs)
+	if traceID != "" {
+		fmt.Fprintf(&b, " # {trace_id=%q} 1 %.3f", traceID, float64(finishedAt.UnixMilli())/1000)
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// TraceIDFromTraceparent returns the trace ID of a W3C traceparent header value, e.g. as propagated by a CI system in
+// the TRACEPARENT environment variable, or an empty string if the value is not a valid traceparent.
+func TraceIDFromTraceparent(traceparent string) string {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return ""
+	}
+	traceID := parts[1]
+	if strings.Trim(traceID, "0123456789abcdef") != "" || strings.Trim(traceID, "0") == "" {
+		return ""
+	}
+	return traceID
+}
+
+// writeHeader writes the HELP and TYPE lines of a gauge.
+func writeHeader(b *strings.Builder, name, help string) {
+	fmt.Fprintf(b, "# HELP %s%s %s\n", metricPrefix, name, help)
+	fmt.Fprintf(b, "# TYPE %s%s gauge\n", metricPrefix, name)
+}
+
+// metadataLabels returns the run metadata as additional label pairs, each key prefixed with "metadata_" and with
+// characters that are invalid in Prometheus label names replaced by underscores.
+func metadataLabels(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		name := strings.Map(func(r rune) rune {
+			if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return '_'
+		}, key)
+		fmt.Fprintf(&b, ",metadata_%s=%q", name, metadata[key])
+	}
+	return b.String()
+}
+
+// resultLabels returns the label pairs identifying a result, with values quoted and escaped as Prometheus expects.
+func resultLabels(result *bench.BenchmarkResult) string {
+	return fmt.Sprintf("autoscaler=%q,target=%q,namespace=%q,replicas=\"%d\"", result.AutoscalerType, result.Target, result.Namespace, result.ExpectedReplicas)
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package utilities
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// TestWritePrometheusMetrics checks that phase durations and gauges are exported with the result's labels.
+func TestWritePrometheusMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	err := WritePrometheusMetrics(&buf, []*bench.BenchmarkResult{{
+		AutoscalerType:           "Karpenter",
+		Target:                   "default",
+		Namespace:                "benchmark",
+		InstanceProvisioningTime: 2500 * time.Millisecond,
+		InstanceRegistrationTime: 4 * time.Second,
+		InstanceCount:            3,
+		ExpectedReplicas:         6,
+		ReadyReplicas:            6,
+		Metadata:                 map[string]string{"git-sha": "abc123", "env": "staging"},
+	}})
+	if err != nil {
+		t.Fatalf("WritePrometheusMetrics() returned error: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{
+		"# TYPE k8s_autoscaler_benchmarker_phase_duration_seconds gauge",
+		`k8s_autoscaler_benchmarker_phase_duration_seconds{autoscaler="Karpenter",target="default",namespace="benchmark",replicas="6",phase="instance_provisioning"} 2.5`,
+		`k8s_autoscaler_benchmarker_scale_up_seconds{autoscaler="Karpenter",target="default",namespace="benchmark",replicas="6"} 6.5`,
+		`k8s_autoscaler_benchmarker_scale_down_seconds{autoscaler="Karpenter",target="default",namespace="benchmark",replicas="6"} 0`,
+		`k8s_autoscaler_benchmarker_instances_launched{autoscaler="Karpenter",target="default",namespace="benchmark",replicas="6"} 3`,
+		`k8s_autoscaler_benchmarker_completeness_score{autoscaler="Karpenter",target="default",namespace="benchmark",replicas="6"} 70`,
+		`k8s_autoscaler_benchmarker_run_info{autoscaler="Karpenter",target="default",namespace="benchmark",replicas="6",metadata_env="staging",metadata_git_sha="abc123"} 1`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("WritePrometheusMetrics() output does not contain %q:\n%s", expected, output)
+		}
+	}
+}
+
+// TestWritePrometheusTextfile checks that the textfile contains the metrics of the last of several iterations and the
+// last run timestamp, and that files without the .prom extension are rejected.
+func TestWritePrometheusTextfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "k8s_autoscaler_benchmarker.prom")
+	finishedAt := time.Unix(1712000000, 0)
+	iterations := []*bench.BenchmarkResult{
+		{AutoscalerType: "Karpenter", Target: "default", PodReadinessTime: 5 * time.Second},
+		{AutoscalerType: "Karpenter", Target: "default"},
+	}
+	if err := WritePrometheusTextfile(path, iterations, finishedAt); err != nil {
+		t.Fatalf("WritePrometheusTextfile() returned error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read textfile: %v", err)
+	}
+	for _, expected := range []string{
+		`k8s_autoscaler_benchmarker_phase_duration_seconds{autoscaler="Karpenter",target="default",namespace="",replicas="0",phase="pod_readiness"} 0`,
+		"kab_last_run_timestamp_seconds 1712000000",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("WritePrometheusTextfile() content does not contain %q:\n%s", expected, content)
+		}
+	}
+	if count := strings.Count(string(content), `phase="pod_readiness"`); count != 1 {
+		t.Errorf("WritePrometheusTextfile() wrote %d pod readiness samples, want 1", count)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("WritePrometheusTextfile() left %d files in the directory, want 1", len(entries))
+	}
+
+	if err := WritePrometheusTextfile(filepath.Join(t.TempDir(), "metrics.txt"), nil, finishedAt); err == nil {
+		t.Errorf("WritePrometheusTextfile() returned nil error for a file without the .prom extension")
+	}
+}
+
+// TestPushPrometheusMetrics checks that the metrics are pushed to the job's Pushgateway endpoint and that a rejected
+// push is reported.
+func TestPushPrometheusMetrics(t *testing.T) {
+	var method, path, body string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(data)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	results := []*bench.BenchmarkResult{{AutoscalerType: "Cluster Autoscaler", Target: "ng-1", Namespace: "default", ExpectedReplicas: 10, InstanceTerminationTime: 30 * time.Second}}
+	if err := PushPrometheusMetrics(server.URL+"/", "k8s_autoscaler_benchmarker", results, time.Unix(1712000000, 0)); err != nil {
+		t.Fatalf("PushPrometheusMetrics() returned error: %v", err)
+	}
+	if method != http.MethodPut || path != "/metrics/job/k8s_autoscaler_benchmarker" {
+		t.Errorf("PushPrometheusMetrics() sent %s %s, want PUT /metrics/job/k8s_autoscaler_benchmarker", method, path)
+	}
+	for _, expected := range []string{
+		`k8s_autoscaler_benchmarker_phase_duration_seconds{autoscaler="Cluster Autoscaler",target="ng-1",namespace="default",replicas="10",phase="instance_termination"} 30`,
+		`k8s_autoscaler_benchmarker_scale_down_seconds{autoscaler="Cluster Autoscaler",target="ng-1",namespace="default",replicas="10"} 30`,
+		"kab_last_run_timestamp_seconds 1712000000",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("PushPrometheusMetrics() body does not contain %q:\n%s", expected, body)
+		}
+	}
+
+	status = http.StatusBadRequest
+	if err := PushPrometheusMetrics(server.URL, "k8s_autoscaler_benchmarker", results, time.Unix(1712000000, 0)); err == nil {
+		t.Errorf("PushPrometheusMetrics() returned nil error for a rejected push")
+	}
+}
+
+// TestWriteRunsCounter checks that the OpenMetrics output attaches an exemplar with the trace ID to the runs counter,
+// and that the Prometheus text format and runs without a trace ID do not.
+func TestWriteRunsCounter(t *testing.T) {
+	finishedAt := time.UnixMilli(1712000000250)
+	for _, tc := range []struct {
+		traceID     string
+		openMetrics bool
+		want        string
+	}{
+		{"4bf92f3577b34da6a3ce929d0e0e4736", true, "# TYPE k8s_autoscaler_benchmarker_runs counter\nk8s_autoscaler_benchmarker_runs_total 3 # {trace_id=\"4bf92f3577b34da6a3ce929d0e0e4736\"} 1 1712000000.250\n"},
+		{"", true, "k8s_autoscaler_benchmarker_runs_total 3\n"},
+		{"4bf92f3577b34da6a3ce929d0e0e4736", false, "# TYPE k8s_autoscaler_benchmarker_runs_total counter\nk8s_autoscaler_benchmarker_runs_total 3\n"},
+	} {
+		var buf bytes.Buffer
+		if err := WriteRunsCounter(&buf, 3, tc.traceID, finishedAt, tc.openMetrics); err != nil {
+			t.Fatalf("WriteRunsCounter() returned error: %v", err)
+		}
+		if !strings.HasSuffix(buf.String(), tc.want) {
+			t.Errorf("WriteRunsCounter(%q, %v) = %q, want it to end with %q", tc.traceID, tc.openMetrics, buf.String(), tc.want)
+		}
+	}
+}
+
+// TestTraceIDFromTraceparent checks that the trace ID is extracted from valid traceparent values only.
+func TestTraceIDFromTraceparent(t *testing.T) {
+	for traceparent, want := range map[string]string{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01": "",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01": "",
+		"4bf92f3577b34da6a3ce929d0e0e4736":                        "",
+		"":                                                        "",
+	} {
+		if got := TraceIDFromTraceparent(traceparent); got != want {
+			t.Errorf("TraceIDFromTraceparent(%q) = %q, want %q", traceparent, got, want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package utilities
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// TargetOutputPath returns the path to write the output of one target to. When several targets were benchmarked, the
+// target is inserted before the extension, e.g. report-default.json, so the targets do not overwrite each other.
+// Standard output, given as "-", is returned unchanged.
+func TargetOutputPath(path, target string, multipleTargets bool) string {
+	if !multipleTargets || path == "-" {
+		return path
+	}
+	extension := filepath.Ext(path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(path, extension), target, extension)
+}
+
+// FlagValues returns the value of every flag of the flag set, including those left at their default, keyed by flag
+// name, to record the configuration a benchmark ran with.
+func FlagValues(fs *flag.FlagSet) map[string]string {
+	values := make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		values[f.Name] = f.Value.String()
+	})
+	return values
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package utilities
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+// TestTargetOutputPath checks that the target is only inserted when several targets were benchmarked, and never into
+// standard output.
+func TestTargetOutputPath(t *testing.T) {
+	for _, tc := range []struct {
+		path     string
+		multiple bool
+		want     string
+	}{
+		{"out/report.json", false, "out/report.json"},
+		{"out/report.json", true, "out/report-default.json"},
+		{"report", true, "report-default"},
+		{"-", true, "-"},
+	} {
+		if got := TargetOutputPath(tc.path, "default", tc.multiple); got != tc.want {
+			t.Errorf("TargetOutputPath(%q, %v) = %q, want %q", tc.path, tc.multiple, got, tc.want)
+		}
+	}
+}
+
+// TestFlagValues checks that the values of set and default flags are recorded.
+func TestFlagValues(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("nodepool", "", "")
+	fs.Int("replicas", 1, "")
+	if err := fs.Parse([]string{"--nodepool", "default"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := FlagValues(fs), map[string]string{"nodepool": "default", "replicas": "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FlagValues() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package utilities
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// LoadScenario reads the named scenario from a YAML file mapping scenario names to flag settings, e.g.
+//
+//	big-burst:
+//	  nodepool: default
+//	  replicas: 200
+//	  metadata:
+//	    scenario: big-burst
+//
+// and returns the values to set per flag name. A list sets a repeatable flag once per element and a map once per
+// key=value pair, sorted by key.
+func LoadScenario(path, name string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read scenarios file: %w", err)
+	}
+	var scenarios map[string]map[string]interface{}
+	if err := yaml.Unmarshal(data, &scenarios); err != nil {
+		return nil, fmt.Errorf("Failed to decode scenarios file %s: %w", path, err)
+	}
+
+	scenario, ok := scenarios[name]
+	if !ok {
+		names := make([]string, 0, len(scenarios))
+		for scenarioName := range scenarios {
+			names = append(names, scenarioName)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("Scenario %q not found in %s, available scenarios: %s", name, path, strings.Join(names, ", "))
+	}
+
+	settings := make(map[string][]string, len(scenario))
+	for flagName, value := range scenario {
+		values, err := scenarioValues(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value of %q in scenario %q: %w", flagName, name, err)
+		}
+		settings[flagName] = values
+	}
+	return settings, nil
+}
+
+// scenarioValues converts a decoded scenario value to the strings to set its flag to.
+func scenarioValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		var values []string
+		for _, element := range v {
+			elementValues, err := scenarioValues(element)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, elementValues...)
+		}
+		return values, nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := make([]string, 0, len(keys))
+		for _, key := range keys {
+			values = append(values, fmt.Sprintf("%s=%v", key, v[key]))
+		}
+		return values, nil
+	case nil:
+		return nil, fmt.Errorf("Missing value")
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
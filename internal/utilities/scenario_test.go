@@ -0,0 +1,59 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testScenarios is a scenarios file with two scenarios.
+const testScenarios = `big-burst:
+  nodepool: default
+  replicas: 200
+  cpu-request: 500m
+  parallel: true
+  min-pod-running-before-scaledown: 30s
+  metadata:
+    team: platform
+    scenario: big-burst
+single-node:
+  node-group: ng-benchmark
+  replicas: 1
+`
+
+// TestLoadScenario checks that a scenario's settings are converted to flag values, that maps become sorted key=value
+// pairs, and that an unknown scenario lists the available ones.
+func TestLoadScenario(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenarios.yaml")
+	if err := os.WriteFile(path, []byte(testScenarios), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadScenario(path, "big-burst")
+	if err != nil {
+		t.Fatalf("LoadScenario() returned error: %v", err)
+	}
+	want := map[string][]string{
+		"nodepool":                         {"default"},
+		"replicas":                         {"200"},
+		"cpu-request":                      {"500m"},
+		"parallel":                         {"true"},
+		"min-pod-running-before-scaledown": {"30s"},
+		"metadata":                         {"scenario=big-burst", "team=platform"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadScenario() = %v, want %v", got, want)
+	}
+
+	if _, err := LoadScenario(path, "missing"); err == nil || !strings.Contains(err.Error(), "big-burst, single-node") {
+		t.Errorf("LoadScenario() of an unknown scenario = %v, want an error listing the available scenarios", err)
+	}
+	if _, err := LoadScenario(filepath.Join(t.TempDir(), "missing.yaml"), "big-burst"); err == nil {
+		t.Error("LoadScenario() of a missing file returned no error")
+	}
+}
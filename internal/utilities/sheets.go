@@ -0,0 +1,148 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package utilities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// sheetsBaseURL is the endpoint of the Google Sheets API. Tests point it to a local server.
+var sheetsBaseURL = "https://sheets.googleapis.com/v4"
+
+// sheetsScope is the OAuth2 scope granting read and write access to spreadsheets.
+const sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+
+// sheetsTimeout bounds each request to the token endpoint and the Sheets API.
+const sheetsTimeout = 10 * time.Second
+
+// serviceAccountKey holds the fields of a Google service account key file needed to request access tokens.
+type serviceAccountKey struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// SheetsExporter appends results to a Google Sheet as rows with the same columns as the CSV output, authenticating as
+// a service account. The header row is appended first if the sheet is empty, and the run numbers continue from the
+// last row already in the sheet.
+type SheetsExporter struct {
+	client        *http.Client
+	sheetID       string
+	sheetRange    string
+	headerChecked bool
+	rows          int
+}
+
+// NewSheetsExporter returns a SheetsExporter appending to the given range, e.g. a sheet name like Sheet1, of the
+// spreadsheet with the given ID, authenticating with the service account key file at credentialsFile. The spreadsheet
+// must be shared with the service account's email.
+func NewSheetsExporter(sheetID, sheetRange, credentialsFile string) (*SheetsExporter, error) {
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read Google service account credentials: %w", err)
+	}
+	var key serviceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("Failed to parse Google service account credentials %s: %w", credentialsFile, err)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" || key.TokenURI == "" {
+		return nil, fmt.Errorf("Google service account credentials %s lack client_email, private_key or token_uri", credentialsFile)
+	}
+
+	conf := &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Scopes:       []string{sheetsScope},
+		TokenURL:     key.TokenURI,
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Timeout: sheetsTimeout})
+	client := conf.Client(ctx)
+	client.Timeout = sheetsTimeout
+	return &SheetsExporter{client: client, sheetID: sheetID, sheetRange: sheetRange}, nil
+}
+
+// Append appends the row of a result, preceded by the header row if the sheet is still empty.
+func (e *SheetsExporter) Append(result *bench.BenchmarkResult) error {
+	var rows [][]string
+	if !e.headerChecked {
+		values, err := e.values()
+		if err != nil {
+			return err
+		}
+		e.headerChecked = true
+		if len(values) == 0 {
+			rows = append(rows, bench.CSVHeader())
+		}
+		e.rows = bench.LastRun(values)
+	}
+	e.rows++
+	rows = append(rows, bench.CSVRow(e.rows, result))
+
+	body, err := json.Marshal(map[string][][]string{"values": rows})
+	if err != nil {
+		return fmt.Errorf("Failed to encode sheet rows: %w", err)
+	}
+	resp, err := e.client.Post(e.valuesURL()+":append?valueInputOption=RAW&insertDataOption=INSERT_ROWS", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("Failed to append to Google Sheet %s: %w", e.sheetID, err)
+	}
+	defer resp.Body.Close()
+	return sheetsStatusError(resp, "append to", e.sheetID)
+}
+
+// values returns the rows already in the range of the sheet, with every cell formatted as a string.
+func (e *SheetsExporter) values() ([][]string, error) {
+	resp, err := e.client.Get(e.valuesURL())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read Google Sheet %s: %w", e.sheetID, err)
+	}
+	defer resp.Body.Close()
+	if err := sheetsStatusError(resp, "read", e.sheetID); err != nil {
+		return nil, err
+	}
+	var values struct {
+		Values [][]any `json:"values"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&values); err != nil {
+		return nil, fmt.Errorf("Failed to decode Google Sheet %s: %w", e.sheetID, err)
+	}
+	rows := make([][]string, len(values.Values))
+	for i, row := range values.Values {
+		for _, cell := range row {
+			rows[i] = append(rows[i], fmt.Sprint(cell))
+		}
+	}
+	return rows, nil
+}
+
+// valuesURL returns the Sheets API URL of the exporter's range.
+func (e *SheetsExporter) valuesURL() string {
+	return sheetsBaseURL + "/spreadsheets/" + url.PathEscape(e.sheetID) + "/values/" + url.PathEscape(e.sheetRange)
+}
+
+// sheetsStatusError returns an error describing a failed Sheets API response, or nil for a successful one.
+func sheetsStatusError(resp *http.Response, action, sheetID string) error {
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("Failed to %s Google Sheet %s: %s: %s", action, sheetID, resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package utilities
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// writeServiceAccountKey writes a service account key file with a fresh private key and the given token endpoint, and
+// returns its path.
+func writeServiceAccountKey(t *testing.T, tokenURI string) string {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	data, _ := json.Marshal(serviceAccountKey{ClientEmail: "bench@example.iam.gserviceaccount.com", PrivateKey: string(keyPEM), TokenURI: tokenURI})
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Failed to write credentials: %v", err)
+	}
+	return path
+}
+
+// TestSheetsExporterAppend checks that the exporter authenticates with a bearer token, appends the header row only to
+// an empty sheet, and numbers the rows like the CSV output, continuing from the rows already in the sheet.
+func TestSheetsExporterAppend(t *testing.T) {
+	var mu sync.Mutex
+	var appended [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"access_token":"secret","token_type":"Bearer","expires_in":3600}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthenticated", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/spreadsheets/sheet-id/values/Benchmarks" && r.URL.Path != "/spreadsheets/sheet-id/values/Benchmarks:append" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(map[string][][]string{"values": appended})
+			return
+		}
+		var body struct {
+			Values [][]string `json:"values"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		appended = append(appended, body.Values...)
+		io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+	defer func(previous string) { sheetsBaseURL = previous }(sheetsBaseURL)
+	sheetsBaseURL = server.URL
+
+	exporter, err := NewSheetsExporter("sheet-id", "Benchmarks", writeServiceAccountKey(t, server.URL+"/token"))
+	if err != nil {
+		t.Fatalf("NewSheetsExporter() returned error: %v", err)
+	}
+	result := &bench.BenchmarkResult{AutoscalerType: "Karpenter", Target: "default", InstanceProvisioningTime: 12 * time.Second}
+	for i := 0; i < 2; i++ {
+		if err := exporter.Append(result); err != nil {
+			t.Fatalf("Append() returned error: %v", err)
+		}
+	}
+
+	if len(appended) != 3 {
+		t.Fatalf("Appended %d rows, want header and 2 rows: %v", len(appended), appended)
+	}
+	if strings.Join(appended[0], ",") != strings.Join(bench.CSVHeader(), ",") {
+		t.Errorf("First row = %v, want the CSV header", appended[0])
+	}
+	if appended[1][0] != "1" || appended[2][0] != "2" || appended[2][3] != "12.000" {
+		t.Errorf("Rows = %v, want runs 1 and 2 with 12.000 provisioning seconds", appended[1:])
+	}
+
+	// A second session appending to the now non-empty sheet adds no header and continues the run numbering.
+	exporter, _ = NewSheetsExporter("sheet-id", "Benchmarks", writeServiceAccountKey(t, server.URL+"/token"))
+	if err := exporter.Append(result); err != nil {
+		t.Fatalf("Append() returned error: %v", err)
+	}
+	if len(appended) != 4 || appended[3][0] != "3" {
+		t.Errorf("Rows after a second session = %v, want one more row as run 3 without header", appended)
+	}
+}
+
+// TestSheetsExporterAuthFailure checks that an unusable key file and a rejected token request surface as errors.
+func TestSheetsExporterAuthFailure(t *testing.T) {
+	if _, err := NewSheetsExporter("sheet-id", "Sheet1", filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("NewSheetsExporter() with a missing key file returned nil error")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+	defer func(previous string) { sheetsBaseURL = previous }(sheetsBaseURL)
+	sheetsBaseURL = server.URL
+
+	exporter, err := NewSheetsExporter("sheet-id", "Sheet1", writeServiceAccountKey(t, server.URL+"/token"))
+	if err != nil {
+		t.Fatalf("NewSheetsExporter() returned error: %v", err)
+	}
+	if err := exporter.Append(&bench.BenchmarkResult{}); err == nil {
+		t.Errorf("Append() with a rejected token request returned nil error")
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package utilities
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrShuttingDown is returned by Guard once the shutdown began.
+var ErrShuttingDown = errors.New("Shutting down, not creating new resources")
+
+// Shutdown coordinates a graceful shutdown, e.g. on SIGINT: Begin cancels the context the running work watches and
+// runs the cleanup, and Wait lets the interrupted work block until the cleanup finished instead of exiting the process
+// underneath it. Resources created through Guard cannot slip past the cleanup.
+type Shutdown struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+	done   chan struct{}
+	// creating is held by Guard while a resource is created, and by the cleanup before it starts.
+	creating sync.Mutex
+}
+
+// NewShutdown returns a Shutdown that has not begun.
+func NewShutdown() *Shutdown {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Shutdown{ctx: ctx, cancel: cancel, done: make(chan struct{})}
+}
+
+// Context returns the context that is cancelled when the shutdown begins.
+func (s *Shutdown) Context() context.Context {
+	return s.ctx
+}
+
+// Begin cancels the context and runs cleanup, waiting at most timeout for it to finish. The cleanup starts once a
+// creation running in Guard returned. Only the first call runs a cleanup, later calls wait for that one. It reports
+// whether the cleanup finished in time.
+func (s *Shutdown) Begin(cleanup func(), timeout time.Duration) bool {
+	s.once.Do(func() {
+		s.cancel()
+		go func() {
+			defer close(s.done)
+			s.creating.Lock()
+			s.creating.Unlock()
+			cleanup()
+		}()
+	})
+	return s.Wait(timeout)
+}
+
+// Guard runs create, e.g. the creation of a deployment, unless the shutdown began, in which case it returns
+// ErrShuttingDown without calling it. As the cleanup waits for a running create, a resource created through Guard is
+// either there for the cleanup to delete or never created, rather than created right after the cleanup looked for it.
+func (s *Shutdown) Guard(create func() error) error {
+	s.creating.Lock()
+	defer s.creating.Unlock()
+	if s.ctx.Err() != nil {
+		return ErrShuttingDown
+	}
+	return create()
+}
+
+// Wait blocks until the cleanup of a begun shutdown finished, for at most timeout, and reports whether it did. It
+// returns true right away when no shutdown began.
+func (s *Shutdown) Wait(timeout time.Duration) bool {
+	if s.ctx.Err() == nil {
+		return true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-s.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
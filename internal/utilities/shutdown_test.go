@@ -0,0 +1,118 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package utilities
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestShutdownWaitsForCleanup simulates a shutdown in the middle of a run: the run notices the cancellation before
+// the cleanup finished, and Wait must only return once the cleanup ran.
+func TestShutdownWaitsForCleanup(t *testing.T) {
+	shutdown := NewShutdown()
+	if !shutdown.Wait(time.Second) {
+		t.Fatalf("Wait() without a shutdown should return true right away")
+	}
+
+	interrupted := make(chan struct{})
+	go func() {
+		// The run polls until its context is cancelled.
+		<-shutdown.Context().Done()
+		close(interrupted)
+	}()
+
+	var cleanups atomic.Int32
+	go shutdown.Begin(func() {
+		time.Sleep(50 * time.Millisecond)
+		cleanups.Add(1)
+	}, time.Second)
+
+	<-interrupted
+	if !shutdown.Wait(time.Second) {
+		t.Fatalf("Wait() timed out before the cleanup finished")
+	}
+	if cleanups.Load() != 1 {
+		t.Fatalf("Cleanup ran %d times before Wait() returned, want 1", cleanups.Load())
+	}
+
+	if !shutdown.Begin(func() { cleanups.Add(1) }, time.Second) || cleanups.Load() != 1 {
+		t.Errorf("A second Begin() ran another cleanup, got %d cleanups", cleanups.Load())
+	}
+}
+
+// TestShutdownTimeout checks that Begin and Wait give up on a cleanup that does not finish within the timeout.
+func TestShutdownTimeout(t *testing.T) {
+	shutdown := NewShutdown()
+	release := make(chan struct{})
+	defer close(release)
+
+	if shutdown.Begin(func() { <-release }, 10*time.Millisecond) {
+		t.Errorf("Begin() reported a blocked cleanup as finished")
+	}
+	if shutdown.Wait(10 * time.Millisecond) {
+		t.Errorf("Wait() reported a blocked cleanup as finished")
+	}
+}
+
+// TestShutdownGuardedCreation simulates a SIGINT while a run is creating its deployment: the cleanup must wait for the
+// creation, delete the deployment and complete before Begin returns, and no deployment may be created afterwards.
+func TestShutdownGuardedCreation(t *testing.T) {
+	shutdown := NewShutdown()
+	clientset := fake.NewSimpleClientset()
+	deployments := clientset.AppsV1().Deployments("default")
+
+	creating := make(chan struct{})
+	release := make(chan struct{})
+	created := make(chan error)
+	go func() {
+		created <- shutdown.Guard(func() error {
+			close(creating)
+			<-release
+			_, err := deployments.Create(context.Background(), &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "inflate"}}, metav1.CreateOptions{})
+			return err
+		})
+	}()
+	<-creating
+
+	var deleted atomic.Bool
+	finished := make(chan bool)
+	go func() {
+		finished <- shutdown.Begin(func() {
+			if err := deployments.Delete(context.Background(), "inflate", metav1.DeleteOptions{}); err != nil {
+				t.Errorf("Cleanup failed to delete the deployment: %v", err)
+			}
+			deleted.Store(true)
+		}, time.Second)
+	}()
+
+	<-shutdown.Context().Done()
+	time.Sleep(20 * time.Millisecond)
+	if deleted.Load() {
+		t.Fatalf("Cleanup ran before the guarded creation returned")
+	}
+	close(release)
+	if err := <-created; err != nil {
+		t.Fatalf("Guard() returned error: %v", err)
+	}
+	if !<-finished || !deleted.Load() {
+		t.Fatalf("Begin() returned before the cleanup deleted the deployment")
+	}
+	if _, err := deployments.Get(context.Background(), "inflate", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Deployment still exists after the cleanup, Get() error = %v", err)
+	}
+
+	called := false
+	if err := shutdown.Guard(func() error { called = true; return nil }); !errors.Is(err, ErrShuttingDown) || called {
+		t.Errorf("Guard() after the shutdown = %v with create called %v, want ErrShuttingDown without calling it", err, called)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package utilities
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// templateFuncs are the helper functions available to --template-file templates in addition to the text/template builtins.
+var templateFuncs = template.FuncMap{
+	"seconds":           func(d time.Duration) string { return fmt.Sprintf("%.2f", d.Seconds()) },
+	"counts":            formatCounts,
+	"metadata":          FormatMetadata,
+	"join":              strings.Join,
+	"maxBatch":          bench.MaxTerminationBatch,
+	"meanBatchInterval": bench.MeanTerminationBatchInterval,
+}
+
+// RenderTemplate renders the Go text/template in the file at path against the benchmark result and writes it to w.
+// See examples/summary.tmpl for a template matching the built-in summary.
+func RenderTemplate(w io.Writer, path string, result *bench.BenchmarkResult) error {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).ParseFiles(path)
+	if err != nil {
+		return fmt.Errorf("Failed to parse template file: %w", err)
+	}
+	if err := tmpl.Execute(w, result); err != nil {
+		return fmt.Errorf("Failed to render template file: %w", err)
+	}
+	return nil
+}
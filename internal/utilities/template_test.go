@@ -0,0 +1,59 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package utilities
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// TestRenderDefaultTemplate checks that the shipped example template renders the summary fields.
+func TestRenderDefaultTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	err := RenderTemplate(&buf, filepath.Join("..", "..", "examples", "summary.tmpl"), &bench.BenchmarkResult{
+		InstanceProvisioningTime: 2 * time.Second,
+		InstanceRegistrationTime: 6 * time.Second,
+		DescribeInstancesCalls:   57,
+		Metadata:                 map[string]string{"pr": "42", "env": "staging"},
+		LaunchTemplates:          map[string]int{"lt-0abc:3": 2},
+		TerminationSeries:        []bench.TerminationSample{{Running: 2}, {Elapsed: time.Minute, Running: 0}},
+		RampSteps:                []bench.RampStep{{Step: 1, Replicas: 4, NewInstances: 2}},
+	})
+	if err != nil {
+		t.Fatalf("RenderTemplate() returned error: %v", err)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{
+		"Instance Initiation Time:     2.00 seconds",
+		"Instance Registration Time:   6.00 seconds",
+		"DescribeInstances API Calls:  57",
+		"Metadata:                     env=staging, pr=42",
+		"Launch Templates:             lt-0abc:3 (2)",
+		"Termination Batches:          1 (max 2 instances",
+		"Scale-Up Completeness:        100/100",
+		"Step 1: 4 replicas",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("RenderTemplate() output does not contain %q:\n%s", expected, output)
+		}
+	}
+}
+
+// TestRenderTemplateInvalid checks that template parse errors are reported.
+func TestRenderTemplateInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.tmpl")
+	if err := os.WriteFile(path, []byte("{{.InstanceCount"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	if err := RenderTemplate(&bytes.Buffer{}, path, &bench.BenchmarkResult{}); err == nil {
+		t.Errorf("RenderTemplate() returned nil error for an invalid template")
+	}
+}
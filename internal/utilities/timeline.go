@@ -0,0 +1,121 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+
+package utilities
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// timelinePhase is a single bar of the HTML timeline, positioned relative to the start of the benchmark.
+type timelinePhase struct {
+	Name          string
+	Start         time.Duration
+	Duration      time.Duration
+	LeftPercent   float64
+	WidthPercent  float64
+	ScaleDown     bool
+	DurationLabel string
+}
+
+// timelineTemplate renders a self-contained HTML page with one horizontal bar per phase.
+var timelineTemplate = template.Must(template.New("timeline").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+.row { display: flex; align-items: center; margin: 6px 0; }
+.label { width: 220px; font-weight: bold; }
+.track { position: relative; flex: 1; height: 24px; background: #f0f0f0; }
+.bar { position: absolute; top: 0; height: 24px; background: #2e8b57; }
+.bar.down { background: #c0392b; }
+.value { width: 120px; text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Total duration: {{.Total}}</p>
+{{range .Phases}}<div class="row"><div class="label">{{.Name}}</div><div class="track"><div class="bar{{if .ScaleDown}} down{{end}}" style="left: {{printf "%.2f" .LeftPercent}}%; width: {{printf "%.2f" .WidthPercent}}%;"></div></div><div class="value">{{.DurationLabel}}</div></div>
+{{end}}</body>
+</html>
+`))
+
+// timelinePhases lays out the phases of the result on a common time axis. Scale-up phases run one after another,
+// while the scale-down phases (deregistration and termination) both start once the scale-up has finished.
+func timelinePhases(result *bench.BenchmarkResult, fargate bool) []timelinePhase {
+	var phases []timelinePhase
+	var offset time.Duration
+	addScaleUp := func(name string, duration time.Duration) {
+		phases = append(phases, timelinePhase{Name: name, Start: offset, Duration: duration})
+		offset += duration
+	}
+
+	if fargate {
+		addScaleUp("Pod Provisioning", result.InstanceRegistrationTime)
+		addScaleUp("Pod Readiness", result.PodReadinessTime)
+		phases = append(phases, timelinePhase{Name: "Node Deregistration", Start: offset, Duration: result.NodeDeregistrationTime, ScaleDown: true})
+	} else {
+		addScaleUp("Instance Initiation", result.InstanceProvisioningTime)
+		addScaleUp("Instance Registration", result.InstanceRegistrationTime)
+		addScaleUp("Pod Readiness", result.PodReadinessTime)
+		phases = append(phases,
+			timelinePhase{Name: "Instance Deregistration", Start: offset, Duration: result.NodeDeregistrationTime, ScaleDown: true},
+			timelinePhase{Name: "Instance Termination", Start: offset, Duration: result.InstanceTerminationTime, ScaleDown: true},
+		)
+	}
+
+	total := timelineTotal(phases)
+	for i := range phases {
+		if total > 0 {
+			phases[i].LeftPercent = float64(phases[i].Start) / float64(total) * 100
+			phases[i].WidthPercent = float64(phases[i].Duration) / float64(total) * 100
+		}
+		phases[i].DurationLabel = fmt.Sprintf("%.2f seconds", phases[i].Duration.Seconds())
+	}
+	return phases
+}
+
+// timelineTotal returns the end of the latest phase, i.e. the total duration covered by the timeline.
+func timelineTotal(phases []timelinePhase) time.Duration {
+	var total time.Duration
+	for _, phase := range phases {
+		if end := phase.Start + phase.Duration; end > total {
+			total = end
+		}
+	}
+	return total
+}
+
+// WriteHTMLTimeline renders the phases of the benchmark result as a horizontal timeline in a self-contained
+// HTML file at path, so results can be shared without any external tooling.
+func WriteHTMLTimeline(path string, result *bench.BenchmarkResult, fargate bool) error {
+	phases := timelinePhases(result, fargate)
+	data := struct {
+		Title  string
+		Total  string
+		Phases []timelinePhase
+	}{
+		Title:  fmt.Sprintf("%s Benchmark Timeline", result.AutoscalerType),
+		Total:  fmt.Sprintf("%.2f seconds", timelineTotal(phases).Seconds()),
+		Phases: phases,
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Failed to create HTML timeline file: %w", err)
+	}
+	defer file.Close()
+
+	if err := timelineTemplate.Execute(file, data); err != nil {
+		return fmt.Errorf("Failed to render HTML timeline: %w", err)
+	}
+	return nil
+}
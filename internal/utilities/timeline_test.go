@@ -0,0 +1,65 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// TestTimelinePhases checks that scale-up phases are laid out back to back and scale-down phases start together.
+func TestTimelinePhases(t *testing.T) {
+	phases := timelinePhases(&bench.BenchmarkResult{
+		InstanceProvisioningTime: 2 * time.Second,
+		InstanceRegistrationTime: 6 * time.Second,
+		PodReadinessTime:         2 * time.Second,
+		NodeDeregistrationTime:   5 * time.Second,
+		InstanceTerminationTime:  10 * time.Second,
+	}, false)
+
+	wantStarts := []time.Duration{0, 2 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	if len(phases) != len(wantStarts) {
+		t.Fatalf("timelinePhases() returned %d phases, want %d", len(phases), len(wantStarts))
+	}
+	for i, phase := range phases {
+		if phase.Start != wantStarts[i] {
+			t.Errorf("phase %q starts at %v, want %v", phase.Name, phase.Start, wantStarts[i])
+		}
+	}
+	if last := phases[len(phases)-1]; last.LeftPercent != 50 || last.WidthPercent != 50 {
+		t.Errorf("termination bar at left %.2f%% width %.2f%%, want 50%% and 50%%", last.LeftPercent, last.WidthPercent)
+	}
+}
+
+// TestWriteHTMLTimeline checks that the rendered HTML file contains every phase and its duration.
+func TestWriteHTMLTimeline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timeline.html")
+	err := WriteHTMLTimeline(path, &bench.BenchmarkResult{
+		AutoscalerType:           "Karpenter",
+		InstanceProvisioningTime: 2 * time.Second,
+		InstanceRegistrationTime: 6 * time.Second,
+		PodReadinessTime:         1 * time.Second,
+		NodeDeregistrationTime:   3 * time.Second,
+		InstanceTerminationTime:  4 * time.Second,
+	}, false)
+	if err != nil {
+		t.Fatalf("WriteHTMLTimeline() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read HTML timeline: %v", err)
+	}
+	output := string(data)
+	for _, expected := range []string{"Karpenter Benchmark Timeline", "Instance Initiation", "Instance Termination", "6.00 seconds", "left: 15.38%", "Total duration: 13.00 seconds"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("WriteHTMLTimeline() output does not contain %q:\n%s", expected, output)
+		}
+	}
+}
@@ -7,30 +7,490 @@ package utilities
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
 )
 
 // PrintSummary displays a summary of the benchmark results with colored output for better readability.
-// It takes the time duration of various operations and prints them to the standard output.
+// It takes the benchmark result and prints the time duration of the various operations and the number of
+// EC2 DescribeInstances API calls made to the standard output.
 // The color coding helps in distinguishing between different sections of the summary.
-func PrintSummary(provisioningTime, instanceRegistrationTime, podReadinessTime, nodeDeregistrationTime, terminationTime time.Duration) {
-	const colorReset = "\033[0m"
-	const colorBold = "\033[1m"
-	const colorRed = "\033[31m"
-	const colorGreen = "\033[32m"
-	const colorYellow = "\033[33m"
-	const colorCyan = "\033[36m"
+func PrintSummary(result *bench.BenchmarkResult) {
+	colorReset := color("\033[0m")
+	colorBold := color("\033[1m")
+	colorRed := color("\033[31m")
+	colorGreen := color("\033[32m")
+	colorYellow := color("\033[33m")
+	colorCyan := color("\033[36m")
 
 	fmt.Printf("\n%s%sBenchmarks Summary%s\n", colorBold, colorCyan, colorReset)
 	fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
-	fmt.Printf("%sInstance Initiation Time:     %s%.2f seconds%s\n", colorBold+colorGreen, colorReset, provisioningTime.Seconds(), colorReset)
-	fmt.Printf("%sInstance Registration Time:   %s%.2f seconds%s\n", colorBold+colorGreen, colorReset, instanceRegistrationTime.Seconds(), colorReset)
-	fmt.Printf("%sPod Readiness Time:           %s%.2f seconds%s\n", colorBold+colorGreen, colorReset, podReadinessTime.Seconds(), colorReset)
-	fmt.Printf("%sInstance Deregistration Time: %s%.2f seconds%s\n", colorBold+colorRed, colorReset, nodeDeregistrationTime.Seconds(), colorReset)
-	fmt.Printf("%sInstance Termination Time:    %s%.2f seconds%s\n", colorBold+colorRed, colorReset, terminationTime.Seconds(), colorReset)
+	printAnomalies(result.Anomalies)
+	fmt.Printf("%sInstance Initiation Time:     %s%.2f seconds%s\n", colorBold+colorGreen, colorReset, result.InstanceProvisioningTime.Seconds(), colorReset)
+	fmt.Printf("%sInstance Registration Time:   %s%.2f seconds%s\n", colorBold+colorGreen, colorReset, result.InstanceRegistrationTime.Seconds(), colorReset)
+	fmt.Printf("%sPod Readiness Time:           %s%.2f seconds%s\n", colorBold+colorGreen, colorReset, result.PodReadinessTime.Seconds(), colorReset)
+	if result.PodTerminationTime > 0 {
+		fmt.Printf("%sPod Termination Time:         %s%.2f seconds%s\n", colorBold+colorRed, colorReset, result.PodTerminationTime.Seconds(), colorReset)
+	}
+	fmt.Printf("%sInstance Deregistration Time: %s%.2f seconds%s\n", colorBold+colorRed, colorReset, result.NodeDeregistrationTime.Seconds(), colorReset)
+	fmt.Printf("%sInstance Termination Time:    %s%.2f seconds%s\n", colorBold+colorRed, colorReset, result.InstanceTerminationTime.Seconds(), colorReset)
+	fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+	if result.Target != "" {
+		fmt.Printf("%sTarget:                       %s%s (%s)%s\n", colorBold+colorCyan, colorReset, result.Target, result.AutoscalerType, colorReset)
+	}
+	if result.Namespace != "" {
+		fmt.Printf("%sNamespace:                    %s%s%s\n", colorBold+colorCyan, colorReset, result.Namespace, colorReset)
+	}
+	if len(result.Metadata) > 0 {
+		fmt.Printf("%sMetadata:                     %s%s%s\n", colorBold+colorCyan, colorReset, FormatMetadata(result.Metadata), colorReset)
+	}
+	if result.TraceID != "" {
+		fmt.Printf("%sTrace ID:                     %s%s%s\n", colorBold+colorCyan, colorReset, result.TraceID, colorReset)
+	}
+	if result.RunID != "" {
+		fmt.Printf("%sRun ID:                       %s%s%s\n", colorBold+colorCyan, colorReset, result.RunID, colorReset)
+	}
+	if result.TimeToFirstSchedule > 0 {
+		fmt.Printf("%sTime to First Schedule:       %s%.2f seconds%s\n", colorBold+colorCyan, colorReset, result.TimeToFirstSchedule.Seconds(), colorReset)
+	}
+	if result.KubeletRegistrationLag > 0 {
+		fmt.Printf("%sKubelet Registration Lag:     %s%.2f seconds (average)%s\n", colorBold+colorCyan, colorReset, result.KubeletRegistrationLag.Seconds(), colorReset)
+	}
+	if result.AWSAccountID != "" || result.AWSRegion != "" {
+		fmt.Printf("%sAWS Account / Region:         %s%s / %s%s\n", colorBold+colorCyan, colorReset, result.AWSAccountID, result.AWSRegion, colorReset)
+	}
+	fmt.Printf("%sDescribeInstances API Calls:  %s%d%s\n", colorBold+colorCyan, colorReset, result.DescribeInstancesCalls, colorReset)
+	if result.K8sRequests > 0 {
+		fmt.Printf("%sKubernetes API Requests:      %s%d (%.2f per second)%s\n", colorBold+colorCyan, colorReset, result.K8sRequests, result.K8sRequestsPerSecond, colorReset)
+	}
+	if result.CostEstimateUSD > 0 {
+		fmt.Printf("%sEstimated Cost:               %s$%.4f (approximate)%s\n", colorBold+colorCyan, colorReset, result.CostEstimateUSD, colorReset)
+	}
+	if result.InstanceCount > 0 {
+		fmt.Printf("%sInstances Launched:           %s%d (%d registered as nodes)%s\n", colorBold+colorCyan, colorReset, result.InstanceCount, result.RegisteredNodes, colorReset)
+	}
+	if result.BaselineNodes > 0 {
+		fmt.Printf("%sBaseline Nodes:               %s%d%s\n", colorBold+colorCyan, colorReset, result.BaselineNodes, colorReset)
+	}
+	if result.ExpectedReplicas > 0 && result.PodNodes > 0 {
+		fmt.Printf("%sReady Replicas:               %s%d/%d on %d nodes%s\n", colorBold+colorCyan, colorReset, result.ReadyReplicas, result.ExpectedReplicas, result.PodNodes, colorReset)
+	} else if result.ExpectedReplicas > 0 {
+		fmt.Printf("%sReady Replicas:               %s%d/%d%s\n", colorBold+colorCyan, colorReset, result.ReadyReplicas, result.ExpectedReplicas, colorReset)
+	}
+	if result.InstanceCount > 0 {
+		fmt.Printf("%sProvisioning per Node:        %s%.2f seconds%s\n", colorBold+colorCyan, colorReset, result.ProvisioningSecondsPerNode(), colorReset)
+	}
+	if result.ReadyReplicas > 0 {
+		fmt.Printf("%sReadiness per Pod:            %s%.2f seconds%s\n", colorBold+colorCyan, colorReset, result.ReadinessSecondsPerPod(), colorReset)
+	}
+	if mean, count := result.MeanLaunchProvisioningTime(false); count > 0 {
+		fmt.Printf("%sFirst-Launch Provisioning:    %s%.2f seconds (%d instances)%s\n", colorBold+colorCyan, colorReset, mean.Seconds(), count, colorReset)
+	}
+	if mean, count := result.MeanLaunchProvisioningTime(true); count > 0 {
+		fmt.Printf("%sRepeat-Launch Provisioning:   %s%.2f seconds (%d instances)%s\n", colorBold+colorCyan, colorReset, mean.Seconds(), count, colorReset)
+	}
+	if result.SpotInstances > 0 || result.OnDemandInstances > 0 {
+		fmt.Printf("%sSpot / On-Demand Instances:   %s%d / %d%s\n", colorBold+colorCyan, colorReset, result.SpotInstances, result.OnDemandInstances, colorReset)
+	}
+	if len(result.InstanceTypes) > 0 {
+		fmt.Printf("%sInstance Types:               %s%s%s\n", colorBold+colorCyan, colorReset, formatCounts(result.InstanceTypes), colorReset)
+	}
+	if len(result.AvailabilityZones) > 0 {
+		fmt.Printf("%sAvailability Zones:           %s%s%s\n", colorBold+colorCyan, colorReset, formatCounts(result.AvailabilityZones), colorReset)
+	}
+	if len(result.LaunchTemplates) > 0 {
+		fmt.Printf("%sLaunch Templates:             %s%s%s\n", colorBold+colorCyan, colorReset, formatCounts(result.LaunchTemplates), colorReset)
+	}
+	if result.TransientTerminationErrors > 0 {
+		fmt.Printf("%sTransient AWS Errors:         %s%d (during termination monitoring)%s\n", colorBold+colorYellow, colorReset, result.TransientTerminationErrors, colorReset)
+	}
+	if batches := result.TerminationBatches(); len(batches) > 0 {
+		fmt.Printf("%sTermination Batches:          %s%d (max %d instances, %.2f seconds apart on average)%s\n", colorBold+colorCyan, colorReset, len(batches), bench.MaxTerminationBatch(batches), bench.MeanTerminationBatchInterval(batches).Seconds(), colorReset)
+	}
+	if result.ChurnedInstances > 0 {
+		fmt.Printf("%sChurned Instances:            %s%d (terminated before scale-down: %s)%s\n", colorBold+colorYellow, colorReset, result.ChurnedInstances, strings.Join(result.ChurnedInstanceIDs, ", "), colorReset)
+	}
+	if result.SpotInterrupted() {
+		fmt.Printf("%sSpot Interrupted:             %strue (%s) - results were disrupted by spot churn%s\n", colorBold+colorRed, colorReset, strings.Join(result.SpotInterruptedInstances, ", "), colorReset)
+	}
+	if result.StabilizationTimedOut {
+		fmt.Printf("%sPre-Run Stabilization:        %stimed out - results may include prior cluster activity%s\n", colorBold+colorRed, colorReset, colorReset)
+	}
+	if len(result.PhaseAttempts) > 0 {
+		fmt.Printf("%sPhase Attempts:               %s%s%s\n", colorBold+colorYellow, colorReset, formatCounts(result.PhaseAttempts), colorReset)
+	}
+	if result.CalibratedScaleUpTime > 0 {
+		fmt.Printf("%sCalibrated Scale-Up Time:     %s%.2f seconds (coefficient of variation %.3f)%s\n", colorBold+colorCyan, colorReset, result.CalibratedScaleUpTime.Seconds(), result.CalibrationCV, colorReset)
+	}
+	if result.ChaosTerminatedInstance != "" {
+		fmt.Printf("%sChaos Replacement Time:       %s%.2f seconds (after terminating %s)%s\n", colorBold+colorCyan, colorReset, result.ChaosReplacementTime.Seconds(), result.ChaosTerminatedInstance, colorReset)
+		fmt.Printf("%sChaos Pod Recovery Time:      %s%.2f seconds%s\n", colorBold+colorCyan, colorReset, result.ChaosRecoveryTime.Seconds(), colorReset)
+		fmt.Printf("%sRandom Seed:                  %s%d (--seed to repeat the choice)%s\n", colorBold+colorCyan, colorReset, result.Seed, colorReset)
+	}
+	if result.AllocatableCPUMillis > 0 {
+		fmt.Printf("%sBin-Packing Efficiency:       %s%.1f%% (%dm of %dm CPU requested, %.1f GiB memory allocatable)%s\n", colorBold+colorCyan, colorReset, result.BinPackingEfficiencyPercent(), result.RequestedCPUMillis, result.AllocatableCPUMillis, float64(result.AllocatableMemoryBytes)/(1<<30), colorReset)
+	}
+	if len(result.NodeCountSeries) > 1 {
+		counts := make([]int, len(result.NodeCountSeries))
+		peak := 0
+		for i, sample := range result.NodeCountSeries {
+			counts[i] = sample.Nodes
+			peak = max(peak, sample.Nodes)
+		}
+		fmt.Printf("%sNodes Over Time:              %s%s (peak %d)%s\n", colorBold+colorCyan, colorReset, Sparkline(counts, sparklineWidth), peak, colorReset)
+	}
+	fmt.Printf("%sScale-Up Completeness:        %s%d/100%s\n", colorBold+colorCyan, colorReset, result.CompletenessScore(), colorReset)
+	fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
+
+	if len(result.RampSteps) > 0 {
+		fmt.Printf("%s%sRamp Steps%s\n", colorBold, colorCyan, colorReset)
+		fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+		fmt.Printf("%s%-6s %-9s %-14s %-13s %s%s\n", colorBold+colorGreen, "Step", "Replicas", "Provisioning", "Pod Readiness", "New Instances", colorReset)
+		for _, step := range result.RampSteps {
+			fmt.Printf("%-6d %-9d %-14s %-13s %d\n", step.Step, step.Replicas, fmt.Sprintf("%.2fs", step.InstanceProvisioningTime.Seconds()), fmt.Sprintf("%.2fs", step.PodReadinessTime.Seconds()), step.NewInstances)
+		}
+		fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
+	}
+
+	if stats := result.NodeClaimStats(); len(stats) > 0 {
+		fmt.Printf("%s%sNodeClaim Phases%s\n", colorBold, colorCyan, colorReset)
+		fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+		fmt.Printf("%s%-23s %-7s %-9s %-9s %s%s\n", colorBold+colorGreen, "Phase", "Claims", "Min", "Median", "Max", colorReset)
+		for _, phase := range stats {
+			fmt.Printf("%-23s %-7d %-9s %-9s %s\n", phase.State, phase.Instances, fmt.Sprintf("%.2fs", phase.Min.Seconds()), fmt.Sprintf("%.2fs", phase.Median.Seconds()), fmt.Sprintf("%.2fs", phase.Max.Seconds()))
+		}
+		fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
+	}
+
+	if len(result.CAFunctionLatencies) > 0 {
+		fmt.Printf("%s%sCluster Autoscaler Function Latency%s\n", colorBold, colorCyan, colorReset)
+		fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+		fmt.Printf("%s%-22s %-7s %s%s\n", colorBold+colorGreen, "Function", "Calls", "Mean", colorReset)
+		for _, latency := range result.CAFunctionLatencies {
+			fmt.Printf("%-22s %-7d %s\n", latency.Function, latency.Calls, fmt.Sprintf("%.3fs", latency.Mean.Seconds()))
+		}
+		fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
+	}
+
+	if stats := result.InstanceStateStats(); len(stats) > 0 {
+		fmt.Printf("%s%sInstance State Durations%s\n", colorBold, colorCyan, colorReset)
+		fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+		fmt.Printf("%s%-10s %-10s %-9s %-9s %s%s\n", colorBold+colorGreen, "State", "Instances", "Min", "Median", "Max", colorReset)
+		for _, state := range stats {
+			fmt.Printf("%-10s %-10d %-9s %-9s %s\n", state.State, state.Instances, fmt.Sprintf("%.2fs", state.Min.Seconds()), fmt.Sprintf("%.2fs", state.Median.Seconds()), fmt.Sprintf("%.2fs", state.Max.Seconds()))
+		}
+		fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
+	}
+
+	if len(result.TenantReadiness) > 0 {
+		fmt.Printf("%s%sTenant Readiness%s\n", colorBold, colorCyan, colorReset)
+		fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+		fmt.Printf("%s%-30s %s%s\n", colorBold+colorGreen, "Namespace", "Pod Readiness", colorReset)
+		for _, tenant := range result.TenantReadiness {
+			fmt.Printf("%-30s %.2fs\n", tenant.Namespace, tenant.PodReadinessTime.Seconds())
+		}
+		fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
+	}
+}
+
+// sparklineWidth is the maximum number of characters of the sparklines printed in the summary.
+const sparklineWidth = 60
+
+// sparklineLevels are the block characters of a sparkline, from lowest to highest.
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the values as a line of block characters scaled from 0 to the largest value. When there are more
+// values than width, consecutive values are merged into their maximum so peaks remain visible.
+func Sparkline(values []int, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		merged := make([]int, width)
+		for i, value := range values {
+			bucket := i * width / len(values)
+			merged[bucket] = max(merged[bucket], value)
+		}
+		values = merged
+	}
+
+	peak := 0
+	for _, value := range values {
+		peak = max(peak, value)
+	}
+	var b strings.Builder
+	for _, value := range values {
+		level := 0
+		if peak > 0 && value > 0 {
+			level = value * (len(sparklineLevels) - 1) / peak
+		}
+		b.WriteRune(sparklineLevels[level])
+	}
+	return b.String()
+}
+
+// PrintFargateSummary displays a summary of a Fargate benchmark result in the same style as PrintSummary.
+// Fargate has no EC2 instances, so only pod provisioning (Fargate node registration), pod readiness
+// and Fargate node deregistration times are reported.
+func PrintFargateSummary(result *bench.BenchmarkResult) {
+	colorReset := color("\033[0m")
+	colorBold := color("\033[1m")
+	colorRed := color("\033[31m")
+	colorGreen := color("\033[32m")
+	colorYellow := color("\033[33m")
+	colorCyan := color("\033[36m")
+
+	fmt.Printf("\n%s%sFargate Benchmarks Summary%s\n", colorBold, colorCyan, colorReset)
+	fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+	printAnomalies(result.Anomalies)
+	fmt.Printf("%sPod Provisioning Time:        %s%.2f seconds%s\n", colorBold+colorGreen, colorReset, result.InstanceRegistrationTime.Seconds(), colorReset)
+	fmt.Printf("%sPod Readiness Time:           %s%.2f seconds%s\n", colorBold+colorGreen, colorReset, result.PodReadinessTime.Seconds(), colorReset)
+	fmt.Printf("%sNode Deregistration Time:     %s%.2f seconds%s\n", colorBold+colorRed, colorReset, result.NodeDeregistrationTime.Seconds(), colorReset)
+	if result.TimeToFirstSchedule > 0 || len(result.Metadata) > 0 || result.TraceID != "" || result.StabilizationTimedOut {
+		fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+	}
+	if len(result.Metadata) > 0 {
+		fmt.Printf("%sMetadata:                     %s%s%s\n", colorBold+colorCyan, colorReset, FormatMetadata(result.Metadata), colorReset)
+	}
+	if result.TraceID != "" {
+		fmt.Printf("%sTrace ID:                     %s%s%s\n", colorBold+colorCyan, colorReset, result.TraceID, colorReset)
+	}
+	if result.TimeToFirstSchedule > 0 {
+		fmt.Printf("%sTime to First Schedule:       %s%.2f seconds%s\n", colorBold+colorCyan, colorReset, result.TimeToFirstSchedule.Seconds(), colorReset)
+	}
+	if result.StabilizationTimedOut {
+		fmt.Printf("%sPre-Run Stabilization:        %stimed out - results may include prior cluster activity%s\n", colorBold+colorRed, colorReset, colorReset)
+	}
+	fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
+}
+
+// printAnomalies lists the anomalies detected during a run at the top of its summary, followed by a separator, so
+// reviewers see first what might invalidate the result. It prints nothing when there are none.
+func printAnomalies(anomalies []string) {
+	colorReset := color("\033[0m")
+	colorBold := color("\033[1m")
+	colorRed := color("\033[31m")
+	colorYellow := color("\033[33m")
+
+	if len(anomalies) == 0 {
+		return
+	}
+	fmt.Printf("%sAnomalies (these may invalidate the result):%s\n", colorBold+colorRed, colorReset)
+	for _, anomaly := range anomalies {
+		fmt.Printf("%s  ! %s%s\n", colorRed, anomaly, colorReset)
+	}
+	fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+}
+
+// PrintComparison displays the phase times of several benchmark results side by side, one row per benchmarked target,
+// after the individual summaries of a multi-target benchmark.
+func PrintComparison(results []*bench.BenchmarkResult) {
+	colorReset := color("\033[0m")
+	colorBold := color("\033[1m")
+	colorGreen := color("\033[32m")
+	colorYellow := color("\033[33m")
+	colorCyan := color("\033[36m")
+
+	fmt.Printf("\n%s%sComparison%s\n", colorBold, colorCyan, colorReset)
+	fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+	fmt.Printf("%s%-30s %-19s %-12s %-12s %-12s %-14s %s%s\n", colorBold+colorGreen, "Target", "Autoscaler", "Initiation", "Registration", "Readiness", "Deregistration", "Termination", colorReset)
+	for _, result := range results {
+		fmt.Printf("%-30s %-19s %-12s %-12s %-12s %-14s %s\n", result.Target, result.AutoscalerType,
+			fmt.Sprintf("%.2fs", result.InstanceProvisioningTime.Seconds()),
+			fmt.Sprintf("%.2fs", result.InstanceRegistrationTime.Seconds()),
+			fmt.Sprintf("%.2fs", result.PodReadinessTime.Seconds()),
+			fmt.Sprintf("%.2fs", result.NodeDeregistrationTime.Seconds()),
+			fmt.Sprintf("%.2fs", result.InstanceTerminationTime.Seconds()))
+	}
+	fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
+}
+
+// PrintComparisonDeltas displays, for every benchmark result after the first, the difference of each phase time to the
+// first result's, so a negative delta means that phase was faster than in the baseline.
+func PrintComparisonDeltas(results []*bench.BenchmarkResult) {
+	colorReset := color("\033[0m")
+	colorBold := color("\033[1m")
+	colorGreen := color("\033[32m")
+	colorYellow := color("\033[33m")
+	colorCyan := color("\033[36m")
+
+	if len(results) < 2 {
+		return
+	}
+	baseline := results[0]
+	delta := func(value, base time.Duration) string {
+		return fmt.Sprintf("%+.2fs", (value - base).Seconds())
+	}
+
+	fmt.Printf("\n%s%sDeltas vs %s%s\n", colorBold, colorCyan, baseline.Target, colorReset)
+	fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+	fmt.Printf("%s%-30s %-12s %-12s %-12s %-14s %s%s\n", colorBold+colorGreen, "Target", "Initiation", "Registration", "Readiness", "Deregistration", "Termination", colorReset)
+	for _, result := range results[1:] {
+		fmt.Printf("%-30s %-12s %-12s %-12s %-14s %s\n", result.Target,
+			delta(result.InstanceProvisioningTime, baseline.InstanceProvisioningTime),
+			delta(result.InstanceRegistrationTime, baseline.InstanceRegistrationTime),
+			delta(result.PodReadinessTime, baseline.PodReadinessTime),
+			delta(result.NodeDeregistrationTime, baseline.NodeDeregistrationTime),
+			delta(result.InstanceTerminationTime, baseline.InstanceTerminationTime))
+	}
+	fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
+}
+
+// PrintResultDiff displays the phase-by-phase difference between two benchmark results, e.g. two archived reports,
+// with the absolute and percentage change from before to after. Faster phases are printed in green and slower in red.
+func PrintResultDiff(before, after *bench.BenchmarkResult, beforeName, afterName string) {
+	colorReset := color("\033[0m")
+	colorBold := color("\033[1m")
+	colorRed := color("\033[31m")
+	colorGreen := color("\033[32m")
+	colorYellow := color("\033[33m")
+	colorCyan := color("\033[36m")
+
+	phaseTitles := map[string]string{
+		"provision": "Instance Initiation",
+		"register":  "Instance Registration",
+		"ready":     "Pod Readiness",
+		"dereg":     "Instance Deregistration",
+		"terminate": "Instance Termination",
+	}
+
+	fmt.Printf("\n%s%sDiff: %s -> %s%s\n", colorBold, colorCyan, beforeName, afterName, colorReset)
+	fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+	fmt.Printf("%s%-25s %-10s %-10s %-10s %s%s\n", colorBold+colorGreen, "Phase", "Before", "After", "Delta", "Change", colorReset)
+	for _, phase := range bench.PhaseNames {
+		b, a := before.PhaseDuration(phase), after.PhaseDuration(phase)
+		change := "n/a"
+		if b > 0 {
+			change = fmt.Sprintf("%+.1f%%", (a-b).Seconds()/b.Seconds()*100)
+		}
+		color := ""
+		if a < b {
+			color = colorGreen
+		} else if a > b {
+			color = colorRed
+		}
+		fmt.Printf("%-25s %-10s %-10s %s%-10s %s%s\n", phaseTitles[phase],
+			fmt.Sprintf("%.2fs", b.Seconds()),
+			fmt.Sprintf("%.2fs", a.Seconds()),
+			color, fmt.Sprintf("%+.2fs", (a-b).Seconds()), change, colorReset)
+	}
+	fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
+}
+
+// PrintWeightedComparison displays the weighted score of each benchmark result with the contribution of every weighted
+// phase (weight * seconds), and declares the result with the lowest weighted total the winner.
+func PrintWeightedComparison(results []*bench.BenchmarkResult, weights map[string]float64) {
+	colorReset := color("\033[0m")
+	colorBold := color("\033[1m")
+	colorGreen := color("\033[32m")
+	colorYellow := color("\033[33m")
+	colorCyan := color("\033[36m")
+
+	var phases []string
+	for _, phase := range bench.PhaseNames {
+		if _, ok := weights[phase]; ok {
+			phases = append(phases, phase)
+		}
+	}
+
+	fmt.Printf("\n%s%sWeighted Comparison%s\n", colorBold, colorCyan, colorReset)
+	fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+	fmt.Printf("%s%-30s %-19s", colorBold+colorGreen, "Target", "Autoscaler")
+	for _, phase := range phases {
+		fmt.Printf(" %-14s", fmt.Sprintf("%s (x%g)", phase, weights[phase]))
+	}
+	fmt.Printf(" %s%s\n", "Total", colorReset)
+
+	var winner *bench.BenchmarkResult
+	winnerScore := 0.0
+	for _, result := range results {
+		total, contributions := result.WeightedScore(weights)
+		fmt.Printf("%-30s %-19s", result.Target, result.AutoscalerType)
+		for _, phase := range phases {
+			fmt.Printf(" %-14s", fmt.Sprintf("%.2f", contributions[phase]))
+		}
+		fmt.Printf(" %.2f\n", total)
+		if winner == nil || total < winnerScore {
+			winner, winnerScore = result, total
+		}
+	}
+	fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+	if winner != nil {
+		fmt.Printf("%sWinner:                       %s%s (%s) with a weighted score of %.2f%s\n", colorBold+colorGreen, colorReset, winner.Target, winner.AutoscalerType, winnerScore, colorReset)
+	}
 	fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
 }
 
+// PrintAggregateSummary displays the min, max, mean, median, p95 and standard deviation of every phase across the
+// iterations of each target, in the order the targets first appear in results. iterations is the number of
+// iterations that were run, so failed iterations missing from results show up as fewer samples.
+func PrintAggregateSummary(results []*bench.BenchmarkResult, iterations int) {
+	colorReset := color("\033[0m")
+	colorBold := color("\033[1m")
+	colorGreen := color("\033[32m")
+	colorYellow := color("\033[33m")
+	colorCyan := color("\033[36m")
+
+	var targets []string
+	byTarget := make(map[string][]*bench.BenchmarkResult)
+	for _, result := range results {
+		if _, ok := byTarget[result.Target]; !ok {
+			targets = append(targets, result.Target)
+		}
+		byTarget[result.Target] = append(byTarget[result.Target], result)
+	}
+
+	for _, target := range targets {
+		targetResults := byTarget[target]
+		fmt.Printf("\n%s%sAggregate of %d/%d Iterations: %s (%s)%s\n", colorBold, colorCyan, len(targetResults), iterations, target, targetResults[0].AutoscalerType, colorReset)
+		fmt.Printf("%s%s--------------------------------------------%s\n", colorBold, colorYellow, colorReset)
+		fmt.Printf("%s%-12s %-10s %-10s %-10s %-10s %-10s %s%s\n", colorBold+colorGreen, "Phase", "Min", "Max", "Mean", "Median", "P95", "StdDev", colorReset)
+		for _, stats := range bench.AggregatePhases(targetResults) {
+			fmt.Printf("%-12s %-10s %-10s %-10s %-10s %-10s %s\n", stats.Phase,
+				fmt.Sprintf("%.2fs", stats.Min.Seconds()),
+				fmt.Sprintf("%.2fs", stats.Max.Seconds()),
+				fmt.Sprintf("%.2fs", stats.Mean.Seconds()),
+				fmt.Sprintf("%.2fs", stats.Median.Seconds()),
+				fmt.Sprintf("%.2fs", stats.P95.Seconds()),
+				fmt.Sprintf("%.2fs", stats.StdDev.Seconds()))
+		}
+		fmt.Printf("%s%s--------------------------------------------%s\n\n", colorBold, colorYellow, colorReset)
+	}
+}
+
+// formatCounts renders a count map as "key (n), key (n)" sorted by key, for stable summary output.
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s (%d)", key, counts[key]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatMetadata renders run metadata as "key=value, key=value" sorted by key, for stable output.
+func FormatMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", key, metadata[key]))
+	}
+	return strings.Join(parts, ", ")
+}
+
 // Int32Ptr takes an int32 and returns a pointer to it.
 // This function is a convenience for situations where a pointer is required.
 func Int32Ptr(i int32) *int32 { return &i }
@@ -6,9 +6,12 @@ package utilities
 import (
 	"bytes"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
 )
 
 // TestPrintSummary checks that PrintSummary writes the expected strings to standard output.
@@ -18,7 +21,15 @@ func TestPrintSummary(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	PrintSummary(2*time.Second, 6*time.Second, 1*time.Second, 3*time.Second, 4*time.Second)
+	PrintSummary(&bench.BenchmarkResult{
+		AutoscalerType:           "Karpenter",
+		InstanceProvisioningTime: 2 * time.Second,
+		InstanceRegistrationTime: 6 * time.Second,
+		PodReadinessTime:         1 * time.Second,
+		NodeDeregistrationTime:   3 * time.Second,
+		InstanceTerminationTime:  4 * time.Second,
+		DescribeInstancesCalls:   57,
+	})
 
 	w.Close()
 	os.Stdout = old
@@ -29,7 +40,7 @@ func TestPrintSummary(t *testing.T) {
 		"Benchmarks Summary",
 		"Instance Initiation Time:",
 		"2.00 seconds",
-		"Instance Readiness Time:",
+		"Instance Registration Time:",
 		"6.00 seconds",
 		"Pod Readiness Time:",
 		"1.00 seconds",
@@ -37,6 +48,10 @@ func TestPrintSummary(t *testing.T) {
 		"3.00 seconds",
 		"Instance Termination Time:",
 		"4.00 seconds",
+		"DescribeInstances API Calls:",
+		"57",
+		"Scale-Up Completeness:",
+		"100/100",
 	}
 
 	for _, expected := range expectedStrings {
@@ -46,6 +61,396 @@ func TestPrintSummary(t *testing.T) {
 	}
 }
 
+// TestPrintSummaryNoColor checks that the summaries contain no ANSI escape sequences once colors are disabled, while
+// still showing the measurements.
+func TestPrintSummaryNoColor(t *testing.T) {
+	SetColor(false)
+	defer SetColor(true)
+
+	var buf bytes.Buffer
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	result := &bench.BenchmarkResult{
+		AutoscalerType:           "Karpenter",
+		Target:                   "default",
+		Anomalies:                []string{"Spot interruptions reclaimed i-spot"},
+		InstanceProvisioningTime: 2 * time.Second,
+		InstanceRegistrationTime: 6 * time.Second,
+		PodReadinessTime:         1 * time.Second,
+		NodeDeregistrationTime:   3 * time.Second,
+		InstanceTerminationTime:  4 * time.Second,
+	}
+	PrintSummary(result)
+	PrintFargateSummary(result)
+	PrintComparison([]*bench.BenchmarkResult{result, result})
+	PrintAggregateSummary([]*bench.BenchmarkResult{result, result}, 2)
+
+	w.Close()
+	os.Stdout = old
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	if strings.Contains(output, "\033") {
+		t.Errorf("Summary with colors disabled contains ANSI escape sequences:\n%q", output)
+	}
+	if !strings.Contains(output, "Instance Registration Time:   6.00 seconds") {
+		t.Errorf("Summary with colors disabled = %q, want the label followed directly by its value", output)
+	}
+}
+
+// TestPrintSummaryAllFields checks that every field of a fully populated BenchmarkResult has a home in the summary. It
+// fails when a field is added to BenchmarkResult without being populated here, so new measurements are not silently
+// dropped from the human output: set the new field below and add the summary line that displays it to expected.
+func TestPrintSummaryAllFields(t *testing.T) {
+	result := &bench.BenchmarkResult{
+		AutoscalerType:             "Karpenter",
+		Target:                     "default",
+		Namespace:                  "benchmark",
+		AWSAccountID:               "123456789012",
+		AWSRegion:                  "us-east-1",
+		Metadata:                   map[string]string{"sha": "abc123"},
+		TraceID:                    "4bf92f3577b34da6a3ce929d0e0e4736",
+		RunID:                      "9f86d081884c7d65",
+		Seed:                       42,
+		StabilizationTimedOut:      true,
+		Anomalies:                  []string{"Spot interruptions reclaimed i-spot"},
+		PhaseAttempts:              map[string]int{"pod readiness": 2},
+		CalibratedScaleUpTime:      9 * time.Second,
+		CalibrationCV:              0.042,
+		InstanceProvisioningTime:   2 * time.Second,
+		InstanceRegistrationTime:   6 * time.Second,
+		PodReadinessTime:           1 * time.Second,
+		PodTerminationTime:         5 * time.Second,
+		NodeDeregistrationTime:     3 * time.Second,
+		InstanceTerminationTime:    4 * time.Second,
+		TimeToFirstSchedule:        500 * time.Millisecond,
+		KubeletRegistrationLag:     12 * time.Second,
+		InstanceStateDurations:     map[string][]time.Duration{"pending": {9 * time.Second}},
+		BaselineNodes:              2,
+		InstanceCount:              3,
+		InstanceLaunches:           []bench.InstanceLaunch{{InstanceID: "i-1", InstanceType: "c7i.large", ProvisioningTime: 11 * time.Second}, {InstanceID: "i-2", InstanceType: "c7i.large", ProvisioningTime: 7 * time.Second, Repeat: true}},
+		NodeClaimTimings:           []bench.NodeClaimTiming{{Name: "default-a", LaunchedToRegistered: 30 * time.Second, RegisteredToInitialized: 6 * time.Second}},
+		LaunchTemplates:            map[string]int{"lt-0abc:3": 3},
+		InstanceTypes:              map[string]int{"c7i.large": 2, "m7i.xlarge": 1},
+		AvailabilityZones:          map[string]int{"us-east-1a": 3},
+		SpotInstances:              2,
+		OnDemandInstances:          1,
+		CostEstimateUSD:            0.0312,
+		DescribeInstancesCalls:     57,
+		K8sRequests:                240,
+		K8sRequestsPerSecond:       1.6,
+		TransientTerminationErrors: 1,
+		TerminationSeries:          []bench.TerminationSample{{Elapsed: 0, Running: 3}, {Elapsed: 20 * time.Second, Running: 0}},
+		ExpectedReplicas:           6,
+		ReadyReplicas:              6,
+		PodNodes:                   3,
+		RegisteredNodes:            3,
+		ChurnedInstances:           1,
+		ChurnedInstanceIDs:         []string{"i-churned"},
+		Evictions:                  2,
+		ControllerRestarts:         1,
+		SpotInterruptedInstances:   []string{"i-spot"},
+		ChaosTerminatedInstance:    "i-chaos",
+		ChaosReplacementTime:       40 * time.Second,
+		ChaosRecoveryTime:          90 * time.Second,
+		AllocatableCPUMillis:       7820,
+		AllocatableMemoryBytes:     32 << 30,
+		RequestedCPUMillis:         6000,
+		RampSteps:                  []bench.RampStep{{Step: 0, Replicas: 6, InstanceProvisioningTime: 2 * time.Second}},
+		TenantReadiness:            []bench.TenantReadiness{{Namespace: "inflate-tenant-1", PodReadinessTime: time.Second}},
+		NodeCountSeries:            []bench.NodeCountSample{{Elapsed: 0, Nodes: 0}, {Elapsed: 5 * time.Second, Nodes: 3}},
+		CAFunctionLatencies:        []bench.CAFunctionLatency{{Function: "scaleUp", Calls: 2, Mean: 1500 * time.Millisecond}},
+	}
+
+	value := reflect.ValueOf(result).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if value.Field(i).IsZero() {
+			t.Errorf("BenchmarkResult.%s is not populated: set it here and make sure PrintSummary displays it", value.Type().Field(i).Name)
+		}
+	}
+
+	var buf bytes.Buffer
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintSummary(result)
+
+	w.Close()
+	os.Stdout = old
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	expectedStrings := []string{
+		"default (Karpenter)",
+		"Namespace:",
+		"123456789012 / us-east-1",
+		"sha=abc123",
+		"Pre-Run Stabilization:",
+		"Anomalies (these may invalidate the result):",
+		"! Spot interruptions reclaimed i-spot",
+		"pod readiness (2)",
+		"9.00 seconds (coefficient of variation 0.042)",
+		"Pod Termination Time:",
+		"Time to First Schedule:",
+		"Kubelet Registration Lag:",
+		"12.00 seconds (average)",
+		"Instance State Durations",
+		"3 (3 registered as nodes)",
+		"Baseline Nodes:",
+		"11.00 seconds (1 instances)",
+		"7.00 seconds (1 instances)",
+		"lt-0abc:3",
+		"c7i.large (2), m7i.xlarge (1)",
+		"us-east-1a (3)",
+		"Spot / On-Demand Instances:",
+		"4bf92f3577b34da6a3ce929d0e0e4736",
+		"Run ID:",
+		"9f86d081884c7d65",
+		"$0.0312 (approximate)",
+		"240 (1.60 per second)",
+		"Transient AWS Errors:",
+		"Termination Batches:",
+		"6/6 on 3 nodes",
+		"Provisioning per Node:",
+		"Readiness per Pod:",
+		"i-churned",
+		"i-spot",
+		"i-chaos",
+		"Chaos Pod Recovery Time:",
+		"42 (--seed to repeat the choice)",
+		"32.0 GiB memory allocatable",
+		"Ramp Steps",
+		"inflate-tenant-1",
+		"Nodes Over Time:",
+		"NodeClaim Phases",
+		"launched-registered     1       30.00s",
+		"Cluster Autoscaler Function Latency",
+		"scaleUp                2       1.500s",
+	}
+	for _, expected := range expectedStrings {
+		if !strings.Contains(output, expected) {
+			t.Errorf("PrintSummary() did not write the expected string: got %s, wanted it to contain %s", output, expected)
+		}
+	}
+}
+
+// TestPrintSummaryRampSteps checks that PrintSummary writes a row per ramp step when the result has any.
+func TestPrintSummaryRampSteps(t *testing.T) {
+	var buf bytes.Buffer
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintSummary(&bench.BenchmarkResult{
+		AutoscalerType: "Karpenter",
+		RampSteps: []bench.RampStep{
+			{Step: 0, Replicas: 2, InstanceProvisioningTime: 3 * time.Second, PodReadinessTime: 40 * time.Second, NewInstances: 1},
+			{Step: 1, Replicas: 4, InstanceProvisioningTime: 5 * time.Second, PodReadinessTime: 45 * time.Second, NewInstances: 2},
+		},
+	})
+
+	w.Close()
+	os.Stdout = old
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	for _, expected := range []string{"Ramp Steps", "40.00s", "45.00s", "5.00s"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("PrintSummary() did not write the expected string: got %s, wanted it to contain %s", output, expected)
+		}
+	}
+}
+
+// TestPrintSummaryTenants checks that the pod readiness of every tenant namespace is printed as a table.
+func TestPrintSummaryTenants(t *testing.T) {
+	var buf bytes.Buffer
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintSummary(&bench.BenchmarkResult{
+		AutoscalerType: "Karpenter",
+		TenantReadiness: []bench.TenantReadiness{
+			{Namespace: "inflate-tenant-1", PodReadinessTime: 12 * time.Second},
+			{Namespace: "inflate-tenant-2", PodReadinessTime: 15 * time.Second},
+		},
+	})
+
+	w.Close()
+	os.Stdout = old
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	for _, expected := range []string{"Tenant Readiness", "inflate-tenant-1", "12.00s", "inflate-tenant-2", "15.00s"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("PrintSummary() did not write the expected string: got %s, wanted it to contain %s", output, expected)
+		}
+	}
+}
+
+// TestPrintSummaryInstanceStates checks that the time spent in each instance state is printed as a table.
+func TestPrintSummaryInstanceStates(t *testing.T) {
+	var buf bytes.Buffer
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintSummary(&bench.BenchmarkResult{
+		AutoscalerType:         "Karpenter",
+		InstanceStateDurations: map[string][]time.Duration{"pending": {9 * time.Second, 14 * time.Second, 21 * time.Second}},
+	})
+
+	w.Close()
+	os.Stdout = old
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	for _, expected := range []string{"Instance State Durations", "pending", "9.00s", "14.00s", "21.00s"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("PrintSummary() did not write the expected string: got %s, wanted it to contain %s", output, expected)
+		}
+	}
+}
+
+// TestPrintFargateSummary checks that PrintFargateSummary writes the Fargate phases and omits the EC2 phases.
+func TestPrintFargateSummary(t *testing.T) {
+	var buf bytes.Buffer
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintFargateSummary(&bench.BenchmarkResult{
+		AutoscalerType:           "Fargate",
+		InstanceRegistrationTime: 5 * time.Second,
+		PodReadinessTime:         2 * time.Second,
+		NodeDeregistrationTime:   7 * time.Second,
+	})
+
+	w.Close()
+	os.Stdout = old
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	expectedStrings := []string{
+		"Fargate Benchmarks Summary",
+		"Pod Provisioning Time:",
+		"5.00 seconds",
+		"Pod Readiness Time:",
+		"2.00 seconds",
+		"Node Deregistration Time:",
+		"7.00 seconds",
+	}
+
+	for _, expected := range expectedStrings {
+		if !strings.Contains(output, expected) {
+			t.Errorf("PrintFargateSummary() did not write the expected string: got %s, wanted it to contain %s", output, expected)
+		}
+	}
+
+	if strings.Contains(output, "Instance Termination Time:") {
+		t.Errorf("PrintFargateSummary() wrote an EC2 termination phase: got %s", output)
+	}
+}
+
+// TestPrintComparison checks that every result is printed as a row with its target and phase times.
+func TestPrintComparison(t *testing.T) {
+	var buf bytes.Buffer
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintComparison([]*bench.BenchmarkResult{
+		{AutoscalerType: "Karpenter", Target: "spot-pool", InstanceProvisioningTime: 2 * time.Second},
+		{AutoscalerType: "Cluster Autoscaler", Target: "on-demand-ng", InstanceTerminationTime: 90 * time.Second},
+	})
+
+	w.Close()
+	os.Stdout = old
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	expectedStrings := []string{
+		"Comparison",
+		"spot-pool",
+		"2.00s",
+		"on-demand-ng",
+		"Cluster Autoscaler",
+		"90.00s",
+	}
+
+	for _, expected := range expectedStrings {
+		if !strings.Contains(output, expected) {
+			t.Errorf("PrintComparison() did not write the expected string: got %s, wanted it to contain %s", output, expected)
+		}
+	}
+}
+
+// TestPrintComparisonDeltas checks that every result after the first is printed with its signed phase deltas to the first.
+func TestPrintComparisonDeltas(t *testing.T) {
+	var buf bytes.Buffer
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintComparisonDeltas([]*bench.BenchmarkResult{
+		{AutoscalerType: "Karpenter", Target: "default-c6i", InstanceProvisioningTime: 20 * time.Second, PodReadinessTime: 50 * time.Second},
+		{AutoscalerType: "Karpenter", Target: "default-c7i", InstanceProvisioningTime: 17500 * time.Millisecond, PodReadinessTime: 53 * time.Second},
+	})
+
+	w.Close()
+	os.Stdout = old
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	for _, expected := range []string{"Deltas vs default-c6i", "default-c7i", "-2.50s", "+3.00s", "+0.00s"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("PrintComparisonDeltas() did not write the expected string: got %s, wanted it to contain %s", output, expected)
+		}
+	}
+}
+
+// TestPrintResultDiff checks that every phase is printed with its absolute and percentage change, and that phases
+// without a before value have no percentage.
+func TestPrintResultDiff(t *testing.T) {
+	var buf bytes.Buffer
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintResultDiff(
+		&bench.BenchmarkResult{InstanceProvisioningTime: 20 * time.Second, PodReadinessTime: 10 * time.Second},
+		&bench.BenchmarkResult{InstanceProvisioningTime: 15 * time.Second, PodReadinessTime: 12 * time.Second, InstanceTerminationTime: 30 * time.Second},
+		"a.json", "b.json",
+	)
+
+	w.Close()
+	os.Stdout = old
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	for _, expected := range []string{"Diff: a.json -> b.json", "Instance Initiation", "-5.00s", "-25.0%", "+2.00s", "+20.0%", "+30.00s", "n/a"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("PrintResultDiff() did not write the expected string: got %s, wanted it to contain %s", output, expected)
+		}
+	}
+}
+
+// TestSparkline checks that values are scaled to the block characters and that long series are merged keeping peaks.
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]int{0, 1, 2, 4, 7, 4, 2, 1, 0}, 60); got != "▁▂▃▅█▅▃▂▁" {
+		t.Errorf("Sparkline() = %s, want ▁▂▃▅█▅▃▂▁", got)
+	}
+	if got := Sparkline([]int{0, 0, 0, 9, 0, 0}, 3); got != "▁█▁" {
+		t.Errorf("Sparkline() merged = %s, want ▁█▁", got)
+	}
+	if got := Sparkline(nil, 60); got != "" {
+		t.Errorf("Sparkline() of no values = %q, want empty", got)
+	}
+}
+
 // TestInt32Ptr checks that Int32Ptr returns a non-nil pointer to an int32 and that the value is correct.
 func TestInt32Ptr(t *testing.T) {
 	i := int32(42)
@@ -57,3 +462,65 @@ func TestInt32Ptr(t *testing.T) {
 		t.Errorf("Int32Ptr() returned a pointer to %d, want %d", *ptr, i)
 	}
 }
+
+// TestPrintWeightedComparison checks that the per-phase contributions are printed and the lowest total wins.
+func TestPrintWeightedComparison(t *testing.T) {
+	var buf bytes.Buffer
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintWeightedComparison([]*bench.BenchmarkResult{
+		{AutoscalerType: "Karpenter", Target: "spot-pool", InstanceProvisioningTime: 20 * time.Second, NodeDeregistrationTime: 60 * time.Second},
+		{AutoscalerType: "Cluster Autoscaler", Target: "on-demand-ng", InstanceProvisioningTime: 40 * time.Second, NodeDeregistrationTime: 10 * time.Second},
+	}, map[string]float64{"provision": 2, "dereg": 1})
+
+	w.Close()
+	os.Stdout = old
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	expectedStrings := []string{
+		"Weighted Comparison",
+		"provision (x2)",
+		"dereg (x1)",
+		"100.00",
+		"90.00",
+		"on-demand-ng (Cluster Autoscaler) with a weighted score of 90.00",
+	}
+
+	for _, expected := range expectedStrings {
+		if !strings.Contains(output, expected) {
+			t.Errorf("PrintWeightedComparison() did not write the expected string: got %s, wanted it to contain %s", output, expected)
+		}
+	}
+}
+
+// TestPrintAggregateSummary checks that the phase statistics are printed per target along with the number of
+// successful iterations.
+func TestPrintAggregateSummary(t *testing.T) {
+	var buf bytes.Buffer
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintAggregateSummary([]*bench.BenchmarkResult{
+		{AutoscalerType: "Karpenter", Target: "default", InstanceProvisioningTime: 10 * time.Second},
+		{AutoscalerType: "Cluster Autoscaler", Target: "ng-1", InstanceProvisioningTime: 30 * time.Second},
+		{AutoscalerType: "Karpenter", Target: "default", InstanceProvisioningTime: 20 * time.Second},
+	}, 2)
+
+	w.Close()
+	os.Stdout = old
+	buf.ReadFrom(r)
+	output := buf.String()
+
+	for _, expected := range []string{"Aggregate of 2/2 Iterations: default (Karpenter)", "Aggregate of 1/2 Iterations: ng-1 (Cluster Autoscaler)", "provision", "15.00s", "19.50s", "5.00s"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("PrintAggregateSummary() did not write the expected string: got %s, wanted it to contain %s", output, expected)
+		}
+	}
+	if strings.Contains(output, "terminate") {
+		t.Errorf("PrintAggregateSummary() printed a phase that was not measured: %s", output)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// runIterations runs the benchmarks of the targets --iterations times in a row and returns the results of every
+// iteration. The results of each iteration are passed to report as soon as it completed, so they can be printed
+// without waiting for the remaining iterations. A failed iteration is logged and skipped instead of aborting the remaining ones, so the aggregate covers
+// the successful iterations, and the errors of the failed iterations are returned joined. Configuration errors fail
+// every iteration the same way and abort right away.
+func runIterations(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, configs []Config, iterations int, report func(*bench.BenchmarkResult)) ([]*bench.BenchmarkResult, error) {
+	var results []*bench.BenchmarkResult
+	var errs []error
+	for i := 1; i <= iterations; i++ {
+		progressf("Running iteration %d of %d...\n", i, iterations)
+		iterationResults, err := runBenchmarks(clientset, ec2Svc, configs)
+		for _, result := range iterationResults {
+			report(result)
+		}
+		results = append(results, iterationResults...)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, bench.ErrInvalidConfig) {
+			return results, err
+		}
+		log.Printf("Warning: iteration %d of %d failed and is skipped in the aggregate: %v", i, iterations, err)
+		errs = append(errs, fmt.Errorf("Iteration %d: %w", i, err))
+	}
+	return results, errors.Join(errs...)
+}
+
+// runUntilStable runs the benchmarks of the targets repeatedly for --repeat-until-stable until, for every target, the
+// total scale-up times of its last window successful runs have a coefficient of variation of at most threshold, or
+// maxRuns rounds were made. Each run tears its workload down before the next starts. Results are passed to report as
+// soon as a round completed; the results of the last round carry the calibrated average of their target, which is
+// printed at the end too. Failed runs, including those that still returned a result, are logged, not counted towards
+// stability, and count towards maxRuns; their errors are returned joined. Configuration errors abort right away.
+func runUntilStable(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, configs []Config, window, maxRuns int, threshold float64, report func(*bench.BenchmarkResult)) ([]*bench.BenchmarkResult, error) {
+	var results []*bench.BenchmarkResult
+	var errs []error
+	byTarget := make(map[string][]*bench.BenchmarkResult)
+	var targets []string
+	stable := false
+	run := 1
+	for ; run <= maxRuns && !stable; run++ {
+		progressf("Running calibration run %d of at most %d...\n", run, maxRuns)
+		runResults, targetErrs, err := runTargets(clientset, ec2Svc, configs)
+		if err != nil {
+			return results, err
+		}
+		var roundResults []*bench.BenchmarkResult
+		for i, result := range runResults {
+			if result == nil {
+				continue
+			}
+			roundResults = append(roundResults, result)
+			if _, ok := byTarget[result.Target]; !ok {
+				targets = append(targets, result.Target)
+				byTarget[result.Target] = nil
+			}
+			if targetErrs[i] == nil {
+				byTarget[result.Target] = append(byTarget[result.Target], result)
+			}
+		}
+		if err := errors.Join(targetErrs...); err != nil {
+			if errors.Is(err, bench.ErrInvalidConfig) {
+				for _, result := range roundResults {
+					report(result)
+				}
+				return append(results, roundResults...), err
+			}
+			log.Printf("Warning: calibration run %d failed and is not counted towards stability: %v", run, err)
+			errs = append(errs, fmt.Errorf("Run %d: %w", run, err))
+		}
+
+		stable = len(targets) == len(configs)
+		for _, target := range targets {
+			_, cv, ok := bench.ScaleUpStability(byTarget[target], window)
+			stable = stable && ok && cv <= threshold
+		}
+		// The last round's results carry the calibrated average, so it lands in every output of the run.
+		if stable || run == maxRuns {
+			for _, result := range roundResults {
+				if mean, cv, ok := bench.ScaleUpStability(byTarget[result.Target], window); ok {
+					result.CalibratedScaleUpTime, result.CalibrationCV = mean, cv
+				}
+			}
+		}
+		for _, result := range roundResults {
+			report(result)
+		}
+		results = append(results, roundResults...)
+	}
+
+	for _, target := range targets {
+		mean, cv, ok := bench.ScaleUpStability(byTarget[target], window)
+		switch {
+		case !ok:
+			log.Printf("Warning: %s: only %d of the %d successful runs needed for a stable scale-up time", target, len(byTarget[target]), window)
+		case cv <= threshold:
+			progressf("%s: scale-up time stabilized at %.2f seconds (average of the last %d runs, coefficient of variation %.3f)\n", target, mean.Seconds(), window, cv)
+		default:
+			log.Printf("Warning: %s: scale-up time did not stabilize within %d runs; the last %d runs average %.2f seconds with a coefficient of variation of %.3f, above %.3f", target, run-1, window, mean.Seconds(), cv, threshold)
+		}
+	}
+	return results, errors.Join(errs...)
+}
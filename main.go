@@ -6,32 +6,168 @@
 package main
 
 import (
+	"bufio"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
+	"io"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"golang.org/x/term"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/flowcontrol"
 
 	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/aws"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
 	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/k8s"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
 	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/utilities"
 )
 
 type Config struct {
 	kubeconfigPath, awsProfile, deploymentName, namespace string
-	replicas                                              int
+	awsEndpoint, scenario, scenariosFile                  string
+	replicas, maxTransientErrors, containerPort, tenants  int
+	expectedInstances, maxChurn, phaseRetries, iterations int
+	gpuRequest, stableWindow, maxRuns                     int
 	nodepoolTag, nodeGroup, containerName, containerImage string
 	cpuRequest, tolerationKey, tolerationValue            string
+	cpuLimit, memoryLimit, memoryRequest, gpuResourceName string
 	nodeSelectorKey, nodeSelectorValue                    string
+	deploymentManifest, exponentialRamp, workloadKind     string
+	htmlOutput, templateFile, serveAddr, promTextfile     string
+	pushgatewayURL, csvOutput                             string
+	googleSheetID, googleSheetRange, googleCredentials    string
+	nodeReadyConditions, nodeAbsentTaints, pricingMap     string
+	rolloutStrategy, maxUnavailable, maxSurge             string
+	createPDB, phaseWeights, nodeFilterLabel, totalCPU    string
+	compareInstanceFamilies, instanceFamily               string
+	ec2EventsQueue, caMetricsURL, karpenterAPIVersion     string
+	traceID, regions, reportOutput, outputFormat          string
+	baselineDeployment, podLabels, podAnnotations         string
+	logFormat, cleanupRunID                               string
+	fargate, pauseBeforeScaledown, parallel               bool
+	nonInteractive, observeOnly                           bool
+	createService, chaosTerminateOne, respectHPA          bool
+	precreate, instanceStates, dryRun, onePodPerNode      bool
+	cleanupOnly, cleanupWait, tagInstances                bool
+	repeatUntilStable, quiet, noColor                     bool
+	reuseExisting, replaceExisting, useNodeClaims         bool
+	metadata, instanceTags                                metadataFlag
+	env                                                   envFlag
+	tolerations                                           tolerationFlag
+	stabilizationWindow, preRunStableFor, preRunTimeout   time.Duration
+	firstInstanceTimeout, provisioningTimeout             time.Duration
+	podReadinessTimeout, cleanupWaitTimeout               time.Duration
+	startTime                                             time.Time
+	seed                                                  int64
+	maxK8sRPS, cvThreshold                                float64
+	// extraRegionEC2 holds the EC2 clients of the regions after the first given with --region, in which the run's
+	// instances are listed too.
+	extraRegionEC2 []*ec2.EC2
+	// nodeReadiness holds the --node-ready-conditions and --node-absent-taints a node has to meet to count as ready.
+	nodeReadiness k8s.NodeReadiness
+	// podLabelSet and podAnnotationSet hold the parsed --pod-labels and --pod-annotations.
+	podLabelSet, podAnnotationSet map[string]string
+	// runID is the kab-run-id tag --tag-instances stamps the run's instances with.
+	runID string
+	// runStart and k8sRequestsAtStart are the time and the count of Kubernetes requests when the run started, including
+	// its setup, from which its Kubernetes request rate is measured.
+	runStart           time.Time
+	k8sRequestsAtStart int64
+	// launchHistory remembers the instance types launched by the earlier benchmarks of the same command or serve
+	// request, shared by all their run configurations.
+	launchHistory *bench.LaunchHistory
+}
+
+// metadataFlag collects the repeatable --metadata key=value flag into a map.
+type metadataFlag map[string]string
+
+// String implements flag.Value.
+func (m metadataFlag) String() string {
+	return utilities.FormatMetadata(m)
+}
+
+// Set implements flag.Value by adding one key=value pair.
+func (m metadataFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("Invalid metadata %q, expected key=value", value)
+	}
+	m[strings.TrimSpace(key)] = val
+	return nil
+}
+
+// envFlag collects the repeatable --env key=value flag into container environment variables, in the order given so
+// that later variables can reference earlier ones with $(NAME).
+type envFlag []corev1.EnvVar
+
+// String implements flag.Value.
+func (e *envFlag) String() string {
+	pairs := make([]string, 0, len(*e))
+	for _, env := range *e {
+		pairs = append(pairs, env.Name+"="+env.Value)
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// Set implements flag.Value by adding one key=value pair. The key must be a valid environment variable name.
+func (e *envFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("Invalid environment variable %q, expected key=value", value)
+	}
+	if errs := validation.IsEnvVarName(key); len(errs) > 0 {
+		return fmt.Errorf("Invalid environment variable name %q: %s", key, strings.Join(errs, "; "))
+	}
+	*e = append(*e, corev1.EnvVar{Name: key, Value: val})
+	return nil
+}
+
+// tolerationFlag collects the repeatable --toleration key=value:effect flag into tolerations.
+type tolerationFlag []corev1.Toleration
+
+// String implements flag.Value.
+func (f *tolerationFlag) String() string {
+	specs := make([]string, 0, len(*f))
+	for _, toleration := range *f {
+		spec := toleration.Key
+		if toleration.Operator == corev1.TolerationOpEqual {
+			spec += "=" + toleration.Value
+		}
+		specs = append(specs, spec+":"+string(toleration.Effect))
+	}
+	return strings.Join(specs, ", ")
+}
+
+// Set implements flag.Value by adding one toleration.
+func (f *tolerationFlag) Set(value string) error {
+	toleration, err := k8s.ParseToleration(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, toleration)
+	return nil
 }
 
 // parseFlags parses the command line flags and returns a Config struct populated with the command line arguments.
@@ -41,150 +177,950 @@ func parseFlags() Config {
 
 	flag.StringVar(&config.kubeconfigPath, "kubeconfig", "", "Path to the kubeconfig file to use for CLI requests.")
 	flag.StringVar(&config.awsProfile, "aws-profile", "default", "The AWS profile to use.")
+	flag.StringVar(&config.awsEndpoint, "aws-endpoint", "", "Send all AWS requests to this endpoint instead of the AWS endpoints, e.g. http://localhost:4566 for LocalStack. The AWS profile is not tested when set.")
 	flag.StringVar(&config.deploymentName, "deployment", "", "The deployment name to benchmark.")
 	flag.StringVar(&config.namespace, "namespace", "default", "The namespace of the deployment.")
 	flag.IntVar(&config.replicas, "replicas", 1, "The number of replicas to scale the deployment to.")
-	flag.StringVar(&config.nodepoolTag, "nodepool", "", "The Karpenter node pool tag value to monitor.")
-	flag.StringVar(&config.nodeGroup, "node-group", "", "The ASG node group name to monitor.")
+	flag.StringVar(&config.nodepoolTag, "nodepool", "", "The Karpenter node pool tag value to monitor. Several node pools can be given as a comma-separated list.")
+	flag.StringVar(&config.nodeGroup, "node-group", "", "The ASG node group name to monitor. Several node groups can be given as a comma-separated list.")
+	flag.BoolVar(&config.parallel, "parallel", false, "Benchmark the given node pools and node groups concurrently instead of one after another.")
 	flag.StringVar(&config.containerName, "container-name", "inflate", "The name of the generated deployment and container if an existing deployment isn't supplied.")
 	flag.StringVar(&config.containerImage, "container-image", "public.ecr.aws/eks-distro/kubernetes/pause:3.7", "The image of the container in the generated deployment if an existing deployment isn't supplied.")
 	flag.StringVar(&config.cpuRequest, "cpu-request", "1", "The CPU request for the container in the generated deployment if an existing deployment isn't supplied.")
+	flag.StringVar(&config.totalCPU, "total-cpu", "", "The total CPU (e.g. 500 or 1500m) the generated workload should request. The replicas are computed as total / --cpu-request, rounded up. Overrides --replicas.")
+	flag.StringVar(&config.memoryRequest, "memory-request", "", "The memory request for the container in the generated deployment if an existing deployment isn't supplied, e.g. 4Gi, to benchmark memory-driven scale-ups. No memory request is set when empty.")
+	flag.IntVar(&config.gpuRequest, "gpu-request", 0, "The number of GPUs the container in the generated deployment requests if an existing deployment isn't supplied, set as both request and limit of --gpu-resource-name. No GPU is requested when 0.")
+	flag.StringVar(&config.gpuResourceName, "gpu-resource-name", "nvidia.com/gpu", "The extended resource name of the GPUs requested with --gpu-request, e.g. amd.com/gpu.")
+	flag.StringVar(&config.cpuLimit, "cpu-limit", "", "The CPU limit for the container in the generated deployment if an existing deployment isn't supplied. No limit is set when empty.")
+	flag.StringVar(&config.memoryLimit, "memory-limit", "", "The memory limit for the container in the generated deployment if an existing deployment isn't supplied. No limit is set when empty.")
+	flag.IntVar(&config.containerPort, "container-port", 0, "The TCP port the container in the generated deployment declares if an existing deployment isn't supplied. No port is declared when 0.")
+	flag.BoolVar(&config.createService, "create-service", false, "Create a ClusterIP service exposing --container-port of the generated workload.")
 	flag.StringVar(&config.tolerationKey, "toleration-key", "eks.autify.com/k8s-autoscaler-benchmarker", "The toleration key for the generated deployment if an existing deployment isn't supplied.")
+	flag.Var(&config.tolerations, "toleration", "An additional toleration given as key=value:effect (or key:effect to tolerate any value) for the generated deployment if an existing deployment isn't supplied, e.g. nvidia.com/gpu=true:NoExecute for nodes carrying several taints. Can be repeated; added to the toleration of --toleration-key.")
 	flag.StringVar(&config.tolerationValue, "toleration-value", "", "The toleration value for the generated deployment if an existing deployment isn't supplied.")
 	flag.StringVar(&config.nodeSelectorKey, "node-selector-key", "eks.autify.com/k8s-autoscaler-benchmarker", "The node selector key for the generated deployment if an existing deployment isn't supplied.")
 	flag.StringVar(&config.nodeSelectorValue, "node-selector-value", "true", "The node selector value for the generated deployment if an existing deployment isn't supplied.")
+	flag.StringVar(&config.nodeReadyConditions, "node-ready-conditions", "", "Comma-separated node conditions given as Type=Status that a node must have, besides Ready=True, to count as registered and ready, e.g. MemoryPressure=False,NetworkUnavailable=False for distributions with custom readiness semantics.")
+	flag.StringVar(&config.nodeAbsentTaints, "node-absent-taints", "", "Comma-separated taint keys a node must no longer carry to count as registered and ready, e.g. karpenter.sh/unregistered,node.cloudprovider.kubernetes.io/uninitialized.")
+	flag.StringVar(&config.nodeFilterLabel, "node-filter-label", "", "An additional node label given as key=value that nodes must carry to be counted, on top of the autoscaler's node pool or node group label, e.g. node-role=benchmark to exclude system nodes in mixed clusters.")
+	flag.StringVar(&config.baselineDeployment, "baseline-deployment", "", "Path of a YAML or JSON Deployment manifest deployed, with its own replica count, and awaited before the benchmark workload, so the scale-up is measured on an already busy cluster. The baseline is deleted at the end of the run.")
+	flag.StringVar(&config.deploymentManifest, "deployment-manifest", "", "Path to a Deployment manifest (YAML or JSON) to create and benchmark instead of the generated deployment.")
+	flag.StringVar(&config.exponentialRamp, "exponential-ramp", "", "Scale up following an exponential ramp given as base,growth,steps (replicas = base * growth^i at step i), recording provisioning time per step. Overrides --replicas.")
+	flag.StringVar(&config.workloadKind, "workload-kind", "Deployment", "The kind of generated workload: Deployment, or Job to benchmark batch scale-up with parallelism set to --replicas.")
+	flag.BoolVar(&config.onePodPerNode, "one-pod-per-node", false, "Give the generated workload a required pod anti-affinity so every replica lands on its own node, wait for one instance per replica unless --expected-instances is set, and fail with exit code 7 if more instances than replicas are launched. A run with --replicas 1 fails the same way if more than one instance is launched, unless --expected-instances is above 1.")
+	flag.IntVar(&config.expectedInstances, "expected-instances", 0, "Wait until this many instances are pending or running before ending the provisioning phase, timing the full multi-node launch instead of the first instance. The first pending instance ends it when 0.")
+	flag.StringVar(&config.ec2EventsQueue, "ec2-events-queue", "", "URL of an SQS queue receiving EventBridge EC2 instance state-change notifications. When set, the run's instance launches and terminations are timed by their events rather than by the DescribeInstances polls, which still run every second. Messages are deleted from the queue as they are consumed, so dedicate it to one benchmark process.")
+	flag.StringVar(&config.caMetricsURL, "ca-metrics-url", "", "URL of the Cluster Autoscaler Prometheus metrics endpoint (e.g. http://localhost:8085/metrics via kubectl port-forward). When set, cluster_autoscaler_function_duration_seconds is scraped before the scale-up and once the pods are ready, and Cluster Autoscaler's self-reported per-function latency is included in the summary.")
+	flag.IntVar(&config.maxChurn, "max-churn", -1, "Fail the benchmark with exit code 6 when more than this many launched instances are terminated or replaced before the scale-down, e.g. by consolidation. Disabled when negative.")
+	flag.DurationVar(&config.provisioningTimeout, "provisioning-timeout", 60*time.Second, "How long to wait for the instances to launch before prompting whether to keep waiting, and again after each 'yes'. Raise it for slow AMIs or large scale-ups.")
+	flag.DurationVar(&config.firstInstanceTimeout, "first-instance-timeout", 0, "Fail fast with the reasons the pods are pending when no instance at all has appeared within this time after the scale-up (e.g. 30s), instead of waiting for the full provisioning timeout and prompting. Must be below --provisioning-timeout. Disabled when 0.")
+	flag.DurationVar(&config.podReadinessTimeout, "pod-readiness-timeout", 10*time.Minute, "How long to wait for all pods to become ready, or the job's pods to be running, before failing with exit code 5. Raise it for slow image pulls or large scale-ups.")
+	flag.IntVar(&config.iterations, "iterations", 1, "Run the benchmark this many times in a row and print the min, max, mean, median, p95 and standard deviation of each phase across the iterations. A failed iteration is skipped in the aggregate.")
+	flag.BoolVar(&config.repeatUntilStable, "repeat-until-stable", false, "Run the benchmark repeatedly, tearing it down in between, until the total scale-up times of the last --window runs vary by at most --cv-threshold, or --max-runs is reached, and report their average as a calibrated baseline.")
+	flag.Float64Var(&config.cvThreshold, "cv-threshold", 0.1, "With --repeat-until-stable, the coefficient of variation (standard deviation divided by mean) the scale-up times of the last --window runs must not exceed.")
+	flag.IntVar(&config.stableWindow, "window", 3, "With --repeat-until-stable, the number of most recent runs whose scale-up times must be stable.")
+	flag.IntVar(&config.maxRuns, "max-runs", 10, "With --repeat-until-stable, the most runs to make before giving up on stability and reporting the last --window runs anyway.")
+	flag.IntVar(&config.phaseRetries, "phase-retries", 0, "Retry the whole run up to this many times when a phase times out, e.g. pods that do not become ready in a flaky environment. The failed attempt is cleaned up and its nodes awaited to be gone before retrying, and the attempts each failed phase needed are reported.")
+	flag.IntVar(&config.maxTransientErrors, "max-transient-errors", 3, "The number of consecutive EC2 DescribeInstances errors tolerated while monitoring instance termination before giving up.")
+	flag.BoolVar(&config.fargate, "fargate", false, "Benchmark pod provisioning on EKS Fargate instead of EC2-backed nodes. EC2 monitoring is skipped.")
+	flag.BoolVar(&config.observeOnly, "observe-only", false, "Create and scale no workload and only time the node activity of a scale event triggered externally: the instances launched since the observation started, their registration and, once scaled down, their deregistration and termination.")
+	flag.BoolVar(&config.noColor, "no-color", false, "Print the summaries without ANSI colors. Colors are also disabled when stdout is not a terminal, e.g. when piped to a file.")
+	flag.BoolVar(&config.quiet, "quiet", false, "Suppress the periodic status lines printed while waiting, e.g. the nodes still registered or the EC2 instances still running, when scripting several benchmarks. The phase messages and the final summary are still printed.")
+	flag.BoolVar(&config.nonInteractive, "non-interactive", false, "Never read from stdin, e.g. in CI or cron jobs without a TTY: fail with exit code 4 when the provisioning timeout is exceeded instead of prompting whether to keep waiting. --pause-before-scaledown is skipped.")
+	flag.BoolVar(&config.pauseBeforeScaledown, "pause-before-scaledown", false, "Pause after all pods are ready and wait for Enter before scaling the deployment down, to allow inspecting the fully-scaled cluster.")
+	flag.StringVar(&config.rolloutStrategy, "rollout-strategy", "", "The rollout strategy of the generated deployment: RollingUpdate or Recreate. The Kubernetes default is used when empty.")
+	flag.StringVar(&config.maxUnavailable, "max-unavailable", "", "The maxUnavailable (number or percentage) of the generated deployment's RollingUpdate strategy.")
+	flag.StringVar(&config.maxSurge, "max-surge", "", "The maxSurge (number or percentage) of the generated deployment's RollingUpdate strategy.")
+	flag.StringVar(&config.createPDB, "create-pdb", "", "Create a PodDisruptionBudget for the deployment before scale-down, given as minAvailable=N or maxUnavailable=N (number or percentage).")
+	flag.DurationVar(&config.stabilizationWindow, "min-pod-running-before-scaledown", 0, "How long all pods must stay ready before scale-down is triggered (e.g. 30s). Readiness is awaited again if a pod flaps. Disabled when 0.")
+	flag.DurationVar(&config.preRunStableFor, "pre-run-stable-for", 0, "Before the timed scale-up, wait until the cluster has been quiet for this long (e.g. 30s): no nodes matching the selector appearing, disappearing or not ready, and no pending pods in the namespace. Disabled when 0.")
+	flag.DurationVar(&config.preRunTimeout, "pre-run-stable-timeout", 5*time.Minute, "How long to wait for the cluster to become quiet with --pre-run-stable-for. The benchmark starts anyway after the timeout and the result is flagged as possibly contaminated.")
+	flag.StringVar(&config.templateFile, "template-file", "", "Path to a Go text/template rendered against the benchmark result and printed instead of the built-in summary. See examples/summary.tmpl.")
+	flag.StringVar(&config.compareInstanceFamilies, "compare-instance-families", "", "Benchmark the --nodepool once per instance family given as a comma-separated list (e.g. c6i,c7i), constraining the generated workload to each family in turn, and print a comparison table with the deltas to the first family.")
+	flag.StringVar(&config.phaseWeights, "phase-weights", "", "Weights of the phases (provision, register, ready, dereg, terminate) as phase=weight pairs, e.g. provision=2,dereg=1. When benchmarking several targets, a weighted score per target is printed and the lowest declared the winner.")
+	flag.IntVar(&config.tenants, "tenants", 0, "Deploy the generated workload with --replicas into this many tenant namespaces concurrently, measuring aggregate node provisioning and per-namespace pod readiness. The namespaces are created and deleted by the benchmark.")
+	flag.BoolVar(&config.precreate, "precreate", false, "Create the deployment with 0 replicas and wait for it to settle before the timed scale-up, so deployment creation and admission webhooks are not part of the measurement.")
+	flag.BoolVar(&config.reuseExisting, "reuse-existing", false, "If a deployment already exists at the generated or manifest deployment's name, e.g. left over by a crashed run, adopt it instead of failing: it is scaled to 0, and its nodes are waited for to be removed, before the benchmark scales it to --replicas. Its spec is used as is.")
+	flag.BoolVar(&config.replaceExisting, "replace", false, "If a deployment already exists at the generated or manifest deployment's name, e.g. left over by a crashed run, delete it and wait for its pods to be gone before creating the deployment anew instead of failing.")
+	flag.BoolVar(&config.useNodeClaims, "use-nodeclaims", false, "For Karpenter, additionally read the Launched, Registered and Initialized conditions of the run's NodeClaims once the pods are ready, and report the Launched to Registered and Registered to Initialized durations.")
+	flag.StringVar(&config.regions, "region", "", "The AWS region, or a comma-separated list of regions for node pools spanning regions. The instances are listed in every region concurrently, while the other AWS calls use the first region. Defaults to the region of the AWS profile.")
+	flag.StringVar(&config.karpenterAPIVersion, "karpenter-api-version", "v1beta1", "The Karpenter API version of the node pool (v1beta1 or v1). With v1, provisioning is detected from the node pool's NodeClaims and the instance IDs they report instead of the EC2 node pool tag, and --use-nodeclaims reads v1 NodeClaims.")
+	flag.BoolVar(&config.instanceStates, "instance-states", false, "Keep sampling the launched instances' EC2 states after provisioning is detected until none is pending, and report how long they spent in each state, e.g. pending before running.")
+	flag.BoolVar(&config.respectHPA, "respect-hpa", false, "Pin a HorizontalPodAutoscaler targeting the --deployment to --replicas for the benchmark and restore it on cleanup, instead of only warning that it may undo the scale event.")
+	flag.BoolVar(&config.chaosTerminateOne, "chaos-terminate-one", false, "After the pods are ready, terminate one random launched instance and measure how fast the autoscaler replaces it and the pods recover.")
+	flag.BoolVar(&config.cleanupOnly, "cleanup-only", false, "Skip the benchmark and only delete the resources a crashed run of the configured targets left behind, e.g. the generated deployment. Resources that are already gone are skipped.")
+	flag.BoolVar(&config.cleanupWait, "cleanup-wait", false, "With --cleanup-only, also wait until the EC2 instances of the crashed run given with --cleanup-run-id have terminated.")
+	flag.StringVar(&config.cleanupRunID, "cleanup-run-id", "", "The kab-run-id of the crashed run whose instances --cleanup-wait waits for, as printed when a run with --tag-instances starts. Required with --cleanup-wait.")
+	flag.DurationVar(&config.cleanupWaitTimeout, "cleanup-wait-timeout", 15*time.Minute, "How long --cleanup-wait waits for the instances to terminate before failing.")
+	flag.BoolVar(&config.dryRun, "dry-run", false, "Print the resources the cleanup of the configured run would delete or restore, and whether each currently exists, without benchmarking or acting on them.")
+	flag.StringVar(&config.serveAddr, "serve", "", "Run as a long-lived HTTP server on the given address (e.g. :8080) exposing /metrics with the last run's results and POST /run to trigger a benchmark.")
+	flag.StringVar(&config.logFormat, "log-format", "text", "Format of the logs written to stderr: text for plain lines, or json for one JSON object per line, e.g. for a log aggregation pipeline, including a \"Phase completed\" event with the phase, elapsed_ms, autoscaler and target fields per phase. The summary on stdout is not affected.")
+	flag.StringVar(&config.outputFormat, "output-format", "text", "Format of the results written to stdout: text for the colored summary, or json or csv to write every run, including each iteration, in a machine-readable form. With json or csv the progress output and the logfmt line go to stderr.")
+	flag.StringVar(&config.csvOutput, "csv-output", "", "Path of a CSV file to append a row to as each run completes, with the same columns as --output-format csv. The header is written when the file is new or empty. No file is written when empty.")
+	flag.StringVar(&config.reportOutput, "report-output", "", "Path to write the JSON report of each run to (result, autoscaler type and flag values), as read by the diff command, or - for standard output in place of the results, which requires --output-format json. With several targets the target is added to the file name. No report is written when empty.")
+	flag.StringVar(&config.googleSheetID, "google-sheet-id", "", "ID of a Google Sheet to append a row to as each run completes, with the same columns as --output-format csv. The header is appended when the sheet is empty. The sheet must be shared with the service account of --google-credentials. Nothing is appended when empty.")
+	flag.StringVar(&config.googleSheetRange, "google-sheet-range", "Sheet1", "The sheet, or range in A1 notation, of the --google-sheet-id to append the rows to.")
+	flag.StringVar(&config.googleCredentials, "google-credentials", os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"), "Path of the Google service account key file used to append to the --google-sheet-id. Defaults to $GOOGLE_APPLICATION_CREDENTIALS.")
+	flag.StringVar(&config.pushgatewayURL, "pushgateway-url", "", "URL of a Prometheus Pushgateway (e.g. http://pushgateway:9091) to push the results to under the job k8s_autoscaler_benchmarker. Nothing is pushed when empty.")
+	flag.StringVar(&config.pricingMap, "pricing-map", "", "Path of a YAML or JSON file mapping instance types to their price in USD per hour, e.g. c7i.large: 0.0893, overriding and extending the built-in approximate us-east-1 on-demand prices used for the cost estimate. Keys can be scoped as <region>/<type> and <type>:spot.")
+	flag.StringVar(&config.promTextfile, "prom-textfile", "", "Path of a .prom file to write the results to in the node_exporter textfile collector format. No file is written when empty.")
+	flag.StringVar(&config.htmlOutput, "html-output", "", "Path of a self-contained HTML file to write the benchmark phase timeline to. No file is written when empty.")
+	config.metadata = metadataFlag{}
+	flag.StringVar(&config.traceID, "trace-id", "", "The ID of the trace the run belongs to, recorded in the JSON report and attached as an OpenMetrics exemplar to the runs counter of serve mode's /metrics. Defaults to the trace ID of the W3C TRACEPARENT environment variable, if set.")
+	flag.StringVar(&config.podLabels, "pod-labels", "", "Comma-separated key=value labels to add to the pods of the generated workload, e.g. for node pools selecting on custom pod labels. The app label the workload selects its pods by cannot be overridden.")
+	flag.StringVar(&config.podAnnotations, "pod-annotations", "", "Comma-separated key=value annotations to add to the pods of the generated workload.")
+	flag.Var(&config.env, "env", "An environment variable given as key=value to set on the container of the generated workload, e.g. for app images that only become ready with their configuration. Can be repeated.")
+	flag.Float64Var(&config.maxK8sRPS, "max-k8s-rps", 0, "Maximum rate of requests per second all Kubernetes clients of the benchmark send to the API server combined, to limit the load the monitors' polling puts on shared control planes. client-go's default limit of 5 requests per second per client applies when 0.")
+	flag.Int64Var(&config.seed, "seed", 0, "Seed of the random choices of the benchmark, e.g. the instance terminated by --chaos-terminate-one, so runs with the same seed make identical choices. A random seed is chosen and reported when 0.")
+	flag.Var(config.metadata, "metadata", "A key=value pair to tag the run with in every output, e.g. a git SHA or environment. Can be repeated.")
+	config.instanceTags = metadataFlag{}
+	flag.BoolVar(&config.tagInstances, "tag-instances", false, "Once the run's instances are identified by the autoscaler tag, stamp them with a kab-run-id tag unique to the run, e.g. for cost attribution, and monitor their termination by it.")
+	flag.Var(config.instanceTags, "instance-tag", "A key=value cost allocation tag to stamp the run's instances with besides kab-run-id, e.g. team=platform. Can be repeated; implies --tag-instances.")
+	flag.StringVar(&config.scenario, "scenario", "", "Run the named scenario from --scenarios-file, setting every flag the scenario defines. Flags given on the command line take precedence.")
+	flag.StringVar(&config.scenariosFile, "scenarios-file", "scenarios.yaml", "Path of the YAML file mapping scenario names to flag settings for --scenario. See examples/scenarios.yaml.")
 	flag.Parse()
 
+	if config.scenario != "" {
+		if err := applyScenario(config.scenariosFile, config.scenario); err != nil {
+			err = bench.NewPhaseError("configuration", fmt.Errorf("%v: %w", err, bench.ErrInvalidConfig))
+			fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+			log.Printf("%v", err)
+			os.Exit(exitCode(err))
+		}
+	}
+	utilities.SetColor(colorOutput(config.noColor))
+	if config.outputFormat != "text" {
+		progress = os.Stderr
+	}
+	if err := logging.Setup(os.Stderr, config.logFormat); err != nil {
+		err = bench.NewPhaseError("configuration", fmt.Errorf("--log-format: %v: %w", err, bench.ErrInvalidConfig))
+		fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+		log.Printf("%v", err)
+		os.Exit(exitCode(err))
+	}
+	podLabels, labelsErr := k8s.ParsePodLabels(config.podLabels)
+	podAnnotations, annotationsErr := k8s.ParsePodAnnotations(config.podAnnotations)
+	if err := errors.Join(labelsErr, annotationsErr); err != nil {
+		err = bench.NewPhaseError("configuration", fmt.Errorf("Invalid --pod-labels or --pod-annotations: %v: %w", err, bench.ErrInvalidConfig))
+		fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+		log.Printf("%v", err)
+		os.Exit(exitCode(err))
+	}
+	config.podLabelSet, config.podAnnotationSet = podLabels, podAnnotations
+	if config.maxK8sRPS < 0 {
+		err := bench.NewPhaseError("configuration", fmt.Errorf("--max-k8s-rps must not be negative, got %g: %w", config.maxK8sRPS, bench.ErrInvalidConfig))
+		fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+		log.Printf("%v", err)
+		os.Exit(exitCode(err))
+	}
+	k8sLimiter = k8s.NewRequestLimiter(config.maxK8sRPS)
+	if len(config.instanceTags) > 0 {
+		config.tagInstances = true
+	}
+	if config.traceID == "" {
+		config.traceID = utilities.TraceIDFromTraceparent(os.Getenv("TRACEPARENT"))
+	}
+	if config.seed == 0 {
+		config.seed = time.Now().UnixNano()
+	}
+	seedRandom(config.seed)
+
 	return config
 }
 
+// colorOutput reports whether the summaries printed to stdout use ANSI colors: unless --no-color is given or stdout
+// is not a terminal, e.g. when piped to a file.
+func colorOutput(noColor bool) bool {
+	return !noColor && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// progress is where the progress lines and the logfmt line go: stdout, or stderr with an --output-format other than
+// text, so that stdout only carries the machine-readable results.
+var progress io.Writer = os.Stdout
+
+// progressf writes a progress line, see progress.
+func progressf(format string, args ...any) {
+	fmt.Fprintf(progress, format, args...)
+}
+
 // initializeClients initializes and returns Kubernetes and AWS EC2 clients using the provided configuration.
 // It uses the kubeconfigPath for the Kubernetes client and the awsProfile for the AWS session.
-// This function logs a fatal error and exits the program if either client cannot be initialized successfully.
-func initializeClients(kubeconfigPath, awsProfile string) (*kubernetes.Clientset, *ec2.EC2) {
-	kubeconfig := kubeconfigPath
-	if kubeconfig == "" {
-		kubeconfig = clientcmd.RecommendedHomeFile
-	}
-
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+// When awsEndpoint is set, the AWS clients send their requests to it (e.g. LocalStack) and the profile is not tested.
+// When regions are given, the returned EC2 client uses the first and one EC2 client is returned for each of the others.
+// When skipAWS is set (e.g. for Fargate benchmarks) no AWS session is created and the returned EC2 and STS clients are nil.
+// This function logs a fatal error and exits the program if either client cannot be initialized successfully, see
+// fatalf.
+func initializeClients(kubeconfigPath, awsProfile, awsEndpoint string, regions []string, skipAWS bool) (*kubernetes.Clientset, *ec2.EC2, []*ec2.EC2, *sts.STS) {
+	config, err := buildKubeconfig(kubeconfigPath)
 	if err != nil {
-		log.Fatalf("Failed to build kubeconfig: %v", err)
+		fatalf("Failed to build kubeconfig: %v", err)
 	}
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		log.Fatalf("Failed to create kubernetes clientset: %v", err)
+		fatalf("Failed to create kubernetes clientset: %v", err)
 	}
 
-	awsSessionOpts := session.Options{
-		SharedConfigState: session.SharedConfigEnable,
-		Profile:           awsProfile,
+	if skipAWS {
+		return clientset, nil, nil, nil
+	}
+
+	awsSession, err := aws.NewSession(awsProfile, awsEndpoint)
+	if err != nil {
+		fatalf("%v", err)
 	}
-	awsSession := session.Must(session.NewSessionWithOptions(awsSessionOpts))
 	ec2Svc := ec2.New(awsSession)
-	if _, err := ec2Svc.DescribeRegions(&ec2.DescribeRegionsInput{}); err != nil {
-		log.Fatalf("Failed to test AWS profile '%s': %v. Ensure the AWS profile is configured correctly.", awsProfile, err)
+	var extraRegionEC2 []*ec2.EC2
+	if len(regions) > 0 {
+		regional := aws.NewRegionalEC2Clients(awsSession, regions)
+		ec2Svc, extraRegionEC2 = regional[0], regional[1:]
+	}
+	if awsEndpoint != "" {
+		progressf("Using the AWS endpoint override %s; skipping the AWS profile test.\n", awsEndpoint)
+	} else if _, err := ec2Svc.DescribeRegions(&ec2.DescribeRegionsInput{}); err != nil {
+		fatalf("Failed to test AWS profile '%s': %v. Ensure the AWS profile is configured correctly.", awsProfile, err)
+	}
+
+	return clientset, ec2Svc, extraRegionEC2, sts.New(awsSession)
+}
+
+// fatalf logs a fatal error and exits like log.Fatalf, after emitting the status=failed logfmt line like the other
+// failure exits.
+func fatalf(format string, args ...any) {
+	err := fmt.Errorf(format, args...)
+	fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+	log.Fatalf("%v", err)
+}
+
+// k8sRequests counts the requests of every Kubernetes client, and k8sLimiter, set from --max-k8s-rps, caps their
+// combined rate. Without a limiter, client-go's default limit applies to each client on its own.
+var (
+	k8sRequests k8s.RequestCounter
+	k8sLimiter  flowcontrol.RateLimiter
+)
+
+// buildKubeconfig loads the client configuration from the kubeconfig at kubeconfigPath, or from the default location
+// when it is empty. The clients built from it share k8sRequests and k8sLimiter.
+func buildKubeconfig(kubeconfigPath string) (*rest.Config, error) {
+	if kubeconfigPath == "" {
+		kubeconfigPath = clientcmd.RecommendedHomeFile
 	}
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+	k8s.ConfigureRequests(config, k8sLimiter, &k8sRequests)
+	return config, nil
+}
 
-	return clientset, ec2Svc
+// newDynamicClient creates a dynamic client for the custom resources the benchmark reads, e.g. Karpenter NodeClaims.
+func newDynamicClient(kubeconfigPath string) (dynamic.Interface, error) {
+	config, err := buildKubeconfig(kubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to build kubeconfig: %w", err)
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create dynamic client: %w", err)
+	}
+	return client, nil
 }
 
 // determineAutoscalerType determines the type of autoscaler to be benchmarked based on the provided configuration.
-// It returns the autoscaler type ("Karpenter" or "Cluster Autoscaler"), along with the tag key and value to be used for monitoring.
-// This function checks the configuration to ensure that only one autoscaler type is specified and logs a fatal error if the configuration is invalid.
-func determineAutoscalerType(config Config, clientset *kubernetes.Clientset) (string, string, string) {
+// It returns the autoscaler type ("Karpenter", "Cluster Autoscaler" or "Fargate"), along with the node label selector and the tag key and value to be used for monitoring.
+// This function checks the configuration to ensure that only one autoscaler type is specified and returns an error wrapping
+// bench.ErrInvalidConfig if the configuration is invalid.
+func determineAutoscalerType(config Config, clientset *kubernetes.Clientset) (string, string, string, string, error) {
 	var autoscalerType, tagKey, tagValue, labelSelector string
 
-	if config.nodepoolTag != "" && config.nodeGroup == "" {
+	if config.fargate {
+		if config.nodepoolTag != "" || config.nodeGroup != "" {
+			return "", "", "", "", fmt.Errorf("--fargate cannot be combined with --nodepool or --node-group: %w", bench.ErrInvalidConfig)
+		}
+		autoscalerType = "Fargate"
+		labelSelector = k8s.FargateLabelSelector
+	} else if config.nodepoolTag != "" && config.nodeGroup == "" {
 		autoscalerType = "Karpenter"
-		tagKey = "karpenter.sh/nodepool"
+		nodePoolKey, err := k8s.KarpenterNodePoolKey(config.karpenterAPIVersion)
+		if err != nil {
+			return "", "", "", "", fmt.Errorf("Invalid --karpenter-api-version: %v: %w", err, bench.ErrInvalidConfig)
+		}
+		tagKey = nodePoolKey
 		tagValue = config.nodepoolTag
-		labelSelector = fmt.Sprintf("karpenter.sh/nodepool=%s", config.nodepoolTag)
+		selector, err := k8s.LabelSelector(nodePoolKey, config.nodepoolTag)
+		if err != nil {
+			return "", "", "", "", fmt.Errorf("Invalid --nodepool: %v: %w", err, bench.ErrInvalidConfig)
+		}
+		labelSelector = selector
 	} else if config.nodeGroup != "" && config.nodepoolTag == "" {
 		autoscalerType = "Cluster Autoscaler"
 		tagKey = "eks:nodegroup-name"
 		tagValue = config.nodeGroup
-		labelSelector = fmt.Sprintf("eks.amazonaws.com/nodegroup=%s", config.nodeGroup)
+		selector, err := k8s.LabelSelector("eks.amazonaws.com/nodegroup", config.nodeGroup)
+		if err != nil {
+			return "", "", "", "", fmt.Errorf("Invalid --node-group: %v: %w", err, bench.ErrInvalidConfig)
+		}
+		labelSelector = selector
+	} else {
+		return "", "", "", "", fmt.Errorf("Specify either --nodepool for Karpenter, --node-group for Cluster Autoscaler or --fargate for Fargate, not more than one: %w", bench.ErrInvalidConfig)
+	}
 
+	labelSelector, err := k8s.WithLabelFilter(labelSelector, config.nodeFilterLabel)
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("Invalid --node-filter-label: %v: %w", err, bench.ErrInvalidConfig)
+	}
+
+	if autoscalerType == "Cluster Autoscaler" && !config.observeOnly {
 		isEmpty, err := k8s.CheckNodeGroupEmpty(clientset, labelSelector)
 		if err != nil {
-			log.Fatalf("Error checking if node group '%s' is empty: %v", config.nodeGroup, err)
+			return "", "", "", "", fmt.Errorf("Error checking if node group '%s' is empty: %w", config.nodeGroup, err)
 		}
 		if !isEmpty {
-			log.Fatalf("Node group '%s' is not empty. Please ensure desired capacity is set to 0 before running the benchmark.", config.nodeGroup)
+			return "", "", "", "", fmt.Errorf("Node group '%s' is not empty. Please ensure desired capacity is set to 0 before running the benchmark: %w", config.nodeGroup, bench.ErrInvalidConfig)
 		}
-	} else {
-		log.Fatal("Specify either --nodepool for Karpenter or --node-group for Cluster Autoscaler, not both.")
 	}
 
-	fmt.Printf("Testing with %s...\n", autoscalerType)
-	fmt.Printf("Using node label selector: %s\n", labelSelector)
+	progressf("Testing with %s...\n", autoscalerType)
+	progressf("Using node label selector: %s\n", labelSelector)
+
+	return autoscalerType, labelSelector, tagKey, tagValue, nil
+}
+
+// checkScaleUpTriggered warns if the generated deployment is unlikely to trigger provisioning because existing nodes
+// matching its node selector have enough free CPU to host its pods. Cluster Autoscaler node groups are already required
+// to be empty by determineAutoscalerType, and user-supplied deployments have unknown requests, so only generated
+// Karpenter deployments are checked.
+func checkScaleUpTriggered(clientset *kubernetes.Clientset, config Config, autoscalerType string) {
+	if autoscalerType != "Karpenter" || config.deploymentName != "" || config.deploymentManifest != "" {
+		return
+	}
+
+	selector, err := k8s.LabelSelector(config.nodeSelectorKey, config.nodeSelectorValue)
+	if err != nil {
+		log.Printf("Warning: unable to check existing node headroom: %v", err)
+		return
+	}
+	nodeNames, err := k8s.FindNodesWithHeadroom(clientset, selector, config.cpuRequest)
+	if err != nil {
+		log.Printf("Warning: unable to check existing node headroom: %v", err)
+		return
+	}
+	if len(nodeNames) > 0 {
+		log.Printf("Warning: existing nodes matching '%s' can already host pods requesting %s CPU (%s). The benchmark may not trigger any provisioning; consider a larger --cpu-request.", selector, config.cpuRequest, strings.Join(nodeNames, ", "))
+	}
+}
+
+// generatedWorkloadConfig builds the k8s.DeploymentConfig for the generated Deployment or Job from the command line configuration.
+func generatedWorkloadConfig(config Config) k8s.DeploymentConfig {
+	return k8s.DeploymentConfig{
+		Name:              config.deploymentName,
+		Namespace:         config.namespace,
+		ContainerName:     config.containerName,
+		ContainerImage:    config.containerImage,
+		CPURequest:        config.cpuRequest,
+		MemoryRequest:     config.memoryRequest,
+		GPURequest:        config.gpuRequest,
+		GPUResourceName:   config.gpuResourceName,
+		CPULimit:          config.cpuLimit,
+		MemoryLimit:       config.memoryLimit,
+		TolerationKey:     config.tolerationKey,
+		TolerationValue:   config.tolerationValue,
+		Tolerations:       config.tolerations,
+		NodeSelectorKey:   config.nodeSelectorKey,
+		NodeSelectorValue: config.nodeSelectorValue,
+		Replicas:          config.replicas,
+		RolloutStrategy:   config.rolloutStrategy,
+		MaxUnavailable:    config.maxUnavailable,
+		MaxSurge:          config.maxSurge,
+		ContainerPort:     config.containerPort,
+		InstanceFamily:    config.instanceFamily,
+		OnePodPerNode:     config.onePodPerNode,
+		Env:               config.env,
+		PodLabels:         config.podLabelSet,
+		PodAnnotations:    config.podAnnotationSet,
+	}
+}
 
-	return labelSelector, tagKey, tagValue
+// countDescribeInstancesCalls returns a copy of the EC2 client, and replaces the clients of the extra regions in the
+// config with copies, that count their DescribeInstances calls into the returned counter, so a run counts only its own
+// calls. It returns nil clients and counter when there is no EC2 client, e.g. for Fargate.
+func countDescribeInstancesCalls(ec2Svc *ec2.EC2, config *Config) (*ec2.EC2, *atomic.Int64) {
+	if ec2Svc == nil {
+		return nil, nil
+	}
+	ec2Svc, calls := aws.CountDescribeInstancesCalls(ec2Svc)
+	counted := make([]*ec2.EC2, 0, len(config.extraRegionEC2))
+	for _, regional := range config.extraRegionEC2 {
+		counted = append(counted, aws.CountDescribeInstancesCallsIn(regional, calls))
+	}
+	config.extraRegionEC2 = counted
+	return ec2Svc, calls
 }
 
 // executeBenchmark orchestrates the benchmarking process, including deployment generation/scaling, instance provisioning and readiness monitoring, pod readiness, and cleanup.
-// It takes the Kubernetes and AWS EC2 clients, the configuration, the autoscaler type, and the tag key and value for monitoring.
-// This function defers the deletion of the deployment if it was created during the benchmark and handles errors encountered during the monitoring stages.
-func executeBenchmark(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, config Config, labelSelector, tagKey, tagValue string) {
-	var instanceDeregTime, instanceTermTime time.Duration
+// It takes the Kubernetes and AWS EC2 clients, the configuration, the autoscaler type, the node label selector, and the tag key and value for monitoring.
+// This function defers the deletion of the deployment if it was created during the benchmark and returns the benchmark result,
+// or a *bench.PhaseError describing the phase that failed. Failures can be told apart with errors.Is against the bench sentinel errors.
+// The run only considers EC2 instances launched after it started and counts its own DescribeInstances calls, so
+// several runs can execute concurrently.
+func executeBenchmark(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, config Config, autoscalerType, labelSelector, tagKey, tagValue string) (*bench.BenchmarkResult, error) {
+	if config.observeOnly {
+		return executeObservation(clientset, ec2Svc, config, autoscalerType, labelSelector, tagKey, tagValue)
+	}
+	result := bench.BenchmarkResult{AutoscalerType: autoscalerType, Target: tagValue, Namespace: config.namespace, Metadata: config.metadata, TraceID: config.traceID, Seed: config.seed}
+	if config.instanceFamily != "" {
+		result.Target = fmt.Sprintf("%s-%s", tagValue, config.instanceFamily)
+	}
+	logger := logging.ForRun(autoscalerType, result.Target)
+	config.startTime = time.Now()
+	config.runStart, config.k8sRequestsAtStart = config.startTime, k8sRequests.Requests()
+	ec2Svc, describeInstancesCalls := countDescribeInstancesCalls(ec2Svc, &config)
 	var wg sync.WaitGroup
-	var errMsg string
+	var rampSchedule []int
+	// restoreHPA restores a pinned HPA of the user-supplied deployment right before the scale-down, since the pinned
+	// minimum would scale it straight back up while an HPA leaves a deployment at 0 replicas alone. The deferred
+	// call only covers the error paths.
+	restoreHPA := func() {}
+
+	isJob := strings.EqualFold(config.workloadKind, "Job")
+	if !isJob && !strings.EqualFold(config.workloadKind, "Deployment") {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--workload-kind must be Deployment or Job, got %q: %w", config.workloadKind, bench.ErrInvalidConfig))
+	}
+	if isJob && (config.deploymentName != "" || config.deploymentManifest != "" || config.exponentialRamp != "" || config.fargate || config.stabilizationWindow > 0 || config.createPDB != "") {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--workload-kind Job only supports the generated workload and cannot be combined with --deployment, --deployment-manifest, --exponential-ramp, --fargate, --min-pod-running-before-scaledown or --create-pdb: %w", bench.ErrInvalidConfig))
+	}
+	if config.createService && (config.containerPort == 0 || config.deploymentName != "" || config.deploymentManifest != "") {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--create-service requires --container-port and the generated workload: %w", bench.ErrInvalidConfig))
+	}
+	if config.expectedInstances < 0 {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--expected-instances must not be negative, got %d: %w", config.expectedInstances, bench.ErrInvalidConfig))
+	}
+	if config.tenants > 1 && (config.deploymentName != "" || config.deploymentManifest != "" || isJob || config.fargate || config.exponentialRamp != "" || config.chaosTerminateOne || config.stabilizationWindow > 0 || config.createPDB != "" || config.createService) {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--tenants only supports the generated Deployment and cannot be combined with --deployment, --deployment-manifest, --workload-kind Job, --fargate, --exponential-ramp, --chaos-terminate-one, --min-pod-running-before-scaledown, --create-pdb or --create-service: %w", bench.ErrInvalidConfig))
+	}
+	if config.precreate && (config.deploymentName != "" || isJob || config.tenants > 1) {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--precreate only applies to the generated Deployment or --deployment-manifest and cannot be combined with --deployment, --workload-kind Job or --tenants: %w", bench.ErrInvalidConfig))
+	}
+	if len(config.extraRegionEC2) > 0 && (config.instanceStates || config.chaosTerminateOne) {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--instance-states and --chaos-terminate-one look instances up by ID in the first region only and cannot be combined with several --region: %w", bench.ErrInvalidConfig))
+	}
+	if config.provisioningTimeout <= 0 {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--provisioning-timeout must be positive, got %v: %w", config.provisioningTimeout, bench.ErrInvalidConfig))
+	}
+	if config.firstInstanceTimeout < 0 || config.firstInstanceTimeout >= config.provisioningTimeout {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--first-instance-timeout must be 0 or below --provisioning-timeout of %v, got %v: %w", config.provisioningTimeout, config.firstInstanceTimeout, bench.ErrInvalidConfig))
+	}
+	if config.podReadinessTimeout <= 0 {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--pod-readiness-timeout must be positive, got %v: %w", config.podReadinessTimeout, bench.ErrInvalidConfig))
+	}
+	if config.gpuRequest < 0 {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--gpu-request must not be negative, got %d: %w", config.gpuRequest, bench.ErrInvalidConfig))
+	}
+	if config.gpuRequest > 0 {
+		if errs := validation.IsQualifiedName(config.gpuResourceName); len(errs) > 0 {
+			return nil, bench.NewPhaseError("configuration", fmt.Errorf("Invalid --gpu-resource-name %q: %s: %w", config.gpuResourceName, strings.Join(errs, "; "), bench.ErrInvalidConfig))
+		}
+	}
+	if config.baselineDeployment != "" && config.fargate {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--baseline-deployment is not supported with --fargate: %w", bench.ErrInvalidConfig))
+	}
+	if config.tagInstances && config.fargate {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--tag-instances and --instance-tag are not supported with --fargate: %w", bench.ErrInvalidConfig))
+	}
+	if config.tagInstances {
+		config.runID = newRunID()
+		result.RunID = config.runID
+		progressf("The run's instances will be tagged %s=%s.\n", runIDTagKey, config.runID)
+	}
+	if config.reuseExisting && config.replaceExisting {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("Specify either --reuse-existing or --replace, not both: %w", bench.ErrInvalidConfig))
+	}
+	if config.respectHPA && config.exponentialRamp != "" {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--respect-hpa pins the HPA to a single replica count and cannot be combined with --exponential-ramp: %w", bench.ErrInvalidConfig))
+	}
+	if config.onePodPerNode {
+		if config.deploymentName != "" || config.deploymentManifest != "" || config.fargate || config.exponentialRamp != "" || config.tenants > 1 {
+			return nil, bench.NewPhaseError("configuration", fmt.Errorf("--one-pod-per-node only applies to the generated workload and cannot be combined with --deployment, --deployment-manifest, --fargate, --exponential-ramp or --tenants: %w", bench.ErrInvalidConfig))
+		}
+	}
+	if config.useNodeClaims && autoscalerType != "Karpenter" {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--use-nodeclaims only applies to Karpenter node pools: %w", bench.ErrInvalidConfig))
+	}
+	if config.caMetricsURL != "" && autoscalerType != "Cluster Autoscaler" {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--ca-metrics-url only applies to Cluster Autoscaler node groups: %w", bench.ErrInvalidConfig))
+	}
+	if config.chaosTerminateOne && (isJob || config.fargate) {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--chaos-terminate-one is not supported with --workload-kind Job or --fargate: %w", bench.ErrInvalidConfig))
+	}
+	nodeSelector, err := k8s.LabelSelector(config.nodeSelectorKey, config.nodeSelectorValue)
+	if err != nil {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("Invalid --node-selector-key or --node-selector-value: %v: %w", err, bench.ErrInvalidConfig))
+	}
+	nodeSelector, err = k8s.WithLabelFilter(nodeSelector, config.nodeFilterLabel)
+	if err != nil {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("Invalid --node-filter-label: %v: %w", err, bench.ErrInvalidConfig))
+	}
+	if config.nodeReadiness, err = k8s.ParseNodeReadiness(config.nodeReadyConditions, config.nodeAbsentTaints); err != nil {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("Invalid --node-ready-conditions or --node-absent-taints: %v: %w", err, bench.ErrInvalidConfig))
+	}
+	if config.createPDB != "" {
+		if _, err := k8s.ParsePDBSpec(config.createPDB); err != nil {
+			return nil, bench.NewPhaseError("configuration", fmt.Errorf("Invalid --create-pdb: %v: %w", err, bench.ErrInvalidConfig))
+		}
+	}
+
+	if config.exponentialRamp != "" {
+		if config.fargate {
+			return nil, bench.NewPhaseError("configuration", fmt.Errorf("--exponential-ramp is not supported with --fargate: %w", bench.ErrInvalidConfig))
+		}
+		schedule, err := bench.ParseExponentialRamp(config.exponentialRamp)
+		if err != nil {
+			return nil, bench.NewPhaseError("configuration", fmt.Errorf("Invalid --exponential-ramp: %v: %w", err, bench.ErrInvalidConfig))
+		}
+		rampSchedule = schedule
+		config.replicas = rampSchedule[0]
+		progressf("Using exponential ramp schedule: %v replicas.\n", rampSchedule)
+	}
+	if config.totalCPU != "" {
+		if config.deploymentName != "" || config.deploymentManifest != "" || config.exponentialRamp != "" {
+			return nil, bench.NewPhaseError("configuration", fmt.Errorf("--total-cpu only applies to the generated workload and cannot be combined with --deployment, --deployment-manifest or --exponential-ramp: %w", bench.ErrInvalidConfig))
+		}
+		replicas, rounded, err := k8s.ReplicasForTotalCPU(config.totalCPU, config.cpuRequest)
+		if err != nil {
+			return nil, bench.NewPhaseError("configuration", fmt.Errorf("Invalid --total-cpu: %v: %w", err, bench.ErrInvalidConfig))
+		}
+		if rounded {
+			log.Printf("Warning: --total-cpu %s is not a multiple of --cpu-request %s; rounding up to %d replicas.", config.totalCPU, config.cpuRequest, replicas)
+		}
+		config.replicas = replicas
+		progressf("Using %d replicas requesting %s CPU each for a total of at least %s CPU.\n", replicas, config.cpuRequest, config.totalCPU)
+	}
+	if config.onePodPerNode && config.expectedInstances == 0 {
+		config.expectedInstances = config.replicas
+	}
+
+	// A deployment left over at the name the run creates is replaced or adopted before the clock starts, so that
+	// neither waiting for it to go away nor its running pods are measured.
+	reuse, err := prepareExistingDeployment(clientset, config, isJob, labelSelector)
+	if err != nil {
+		return nil, bench.NewPhaseError("deployment creation", err)
+	}
+	config.startTime = time.Now()
+
+	if autoscalerType == "Cluster Autoscaler" {
+		restoreCapacity := captureNodeGroupCapacity(ec2Svc, config)
+		defer restoreCapacity()
+	}
+
+	// Nodes of the baseline keep running through the scale-down, so the node counts the monitors await are offset by them.
+	var baselineNodes, baselineSelectedNodes int
+	if config.baselineDeployment != "" {
+		deleteBaseline, err := deployBaseline(clientset, config)
+		if err != nil {
+			return nil, bench.NewPhaseError("baseline deployment", err)
+		}
+		defer deleteBaseline()
+		if baselineNodes, err = k8s.CountNodes(clientset, labelSelector); err != nil {
+			return nil, bench.NewPhaseError("baseline deployment", err)
+		}
+		if baselineSelectedNodes, err = k8s.CountNodes(clientset, nodeSelector); err != nil {
+			return nil, bench.NewPhaseError("baseline deployment", err)
+		}
+		result.BaselineNodes = baselineNodes
+		progressf("Baseline established on %d nodes; measuring the incremental scale-up.\n", baselineNodes)
+		config.startTime = time.Now()
+	}
+
+	if config.preRunStableFor > 0 {
+		quiet, err := k8s.WaitForQuiescence(clientset, labelSelector, config.namespace, config.nodeReadiness, config.preRunStableFor, config.preRunTimeout, verbosity(config))
+		if err != nil {
+			return nil, bench.NewPhaseError("pre-run stabilization", err)
+		}
+		if !quiet {
+			log.Printf("Warning: the cluster did not stay quiet for %v within %v; benchmarking anyway, the measurements may include prior activity.", config.preRunStableFor, config.preRunTimeout)
+			result.StabilizationTimedOut = true
+		}
+		config.startTime = time.Now()
+	}
+
+	var instanceEvents *aws.InstanceEvents
+	if config.ec2EventsQueue != "" {
+		if config.fargate {
+			return nil, bench.NewPhaseError("configuration", fmt.Errorf("--ec2-events-queue is not supported with --fargate: %w", bench.ErrInvalidConfig))
+		}
+		if instanceEvents, err = watchInstanceEvents(ec2Svc, config.ec2EventsQueue); err != nil {
+			return nil, bench.NewPhaseError("configuration", err)
+		}
+	}
 
-	if config.deploymentName == "" {
+	// Fargate nodes that already exist, e.g. CoreDNS, are counted before the deployment is created, which may already
+	// register new ones.
+	if config.fargate {
+		if baselineNodes, err = k8s.CountNodes(clientset, labelSelector); err != nil {
+			return nil, bench.NewPhaseError("Fargate node baseline", err)
+		}
+	}
+
+	caMetricsBefore := scrapeCAMetrics(config)
+	restartsBefore := controllerRestarts(clientset, autoscalerType)
+	scaleUpStart := time.Now()
+	if config.tenants > 1 {
+		deleteTenants, err := createTenants(clientset, config)
+		if err != nil {
+			return nil, bench.NewPhaseError("tenant creation", err)
+		}
+		defer deleteTenants()
+		config.namespace = tenantNamespaces(config)[0]
+	}
+	if isJob {
+		config.deploymentName = config.containerName
+		progressf("Using generated job '%s' with parallelism %d.\n", config.deploymentName, config.replicas)
+		if err := shutdown.Guard(func() error {
+			return k8s.GenerateJob(clientset, generatedWorkloadConfig(config))
+		}); err != nil {
+			return nil, bench.NewPhaseError("job creation", err)
+		}
+		defer func() {
+			if err := k8s.DeleteJob(clientset, config.deploymentName, config.namespace); err != nil {
+				log.Printf("Failed to delete job: %v", err)
+			}
+		}()
+	} else if config.deploymentManifest != "" {
+		if config.deploymentName != "" {
+			return nil, bench.NewPhaseError("configuration", fmt.Errorf("Specify either --deployment or --deployment-manifest, not both: %w", bench.ErrInvalidConfig))
+		}
+		deployment, err := k8s.LoadDeploymentManifest(config.deploymentManifest)
+		if err != nil {
+			return nil, bench.NewPhaseError("configuration", fmt.Errorf("%v: %w", err, bench.ErrInvalidConfig))
+		}
+		config.deploymentName = deployment.Name
+		if deployment.Namespace != "" {
+			config.namespace = deployment.Namespace
+		}
+		progressf("Using deployment '%s' from manifest '%s' in the namespace '%s'.\n", config.deploymentName, config.deploymentManifest, config.namespace)
+		if !config.fargate {
+			warnPlacementIssues(config, k8s.PodPlacementIssues(deployment.Spec.Template.Spec, config.nodeSelectorKey, config.nodeSelectorValue, config.tolerationKey))
+		}
+		initialReplicas := config.replicas
+		if config.precreate {
+			initialReplicas = 0
+		}
+		if reuse {
+			err = k8s.ScaleDeployment(clientset, config.deploymentName, config.namespace, initialReplicas)
+		} else {
+			err = shutdown.Guard(func() error {
+				return k8s.ApplyDeploymentManifest(clientset, deployment, config.namespace, initialReplicas)
+			})
+		}
+		if err != nil {
+			return nil, bench.NewPhaseError("deployment creation", err)
+		}
+		defer func() {
+			if err := k8s.DeleteDeployment(clientset, config.deploymentName, config.namespace); err != nil {
+				log.Printf("Failed to delete deployment: %v", err)
+			}
+		}()
+		if config.precreate {
+			if scaleUpStart, err = scaleUpPrecreated(clientset, config); err != nil {
+				return nil, bench.NewPhaseError("deployment scale-up", err)
+			}
+		}
+	} else if config.deploymentName == "" {
 		config.deploymentName = config.containerName
-		fmt.Printf("No existing deployment name supplied, using '%s' for new deployment.\n", config.deploymentName)
-		if err := k8s.GenerateDeployment(clientset, config.deploymentName, config.namespace, config.containerName, config.containerImage, config.cpuRequest, config.tolerationKey, config.tolerationValue, config.nodeSelectorKey, config.nodeSelectorValue, config.replicas); err != nil {
-			log.Fatalf("Failed to generate deployment: %v", err)
+		progressf("No existing deployment name supplied, using '%s' for new deployment.\n", config.deploymentName)
+		workload := generatedWorkloadConfig(config)
+		if config.precreate {
+			workload.Replicas = 0
+		}
+		if reuse {
+			err = k8s.ScaleDeployment(clientset, config.deploymentName, config.namespace, workload.Replicas)
+		} else {
+			err = shutdown.Guard(func() error {
+				return k8s.GenerateDeployment(clientset, workload)
+			})
+		}
+		if err != nil {
+			return nil, bench.NewPhaseError("deployment creation", err)
 		}
 		defer func() {
 			if err := k8s.DeleteDeployment(clientset, config.deploymentName, config.namespace); err != nil {
 				log.Printf("Failed to delete deployment: %v", err)
 			}
 		}()
+		if config.precreate {
+			if scaleUpStart, err = scaleUpPrecreated(clientset, config); err != nil {
+				return nil, bench.NewPhaseError("deployment scale-up", err)
+			}
+		}
 	} else {
-		fmt.Printf("Using user-supplied deployment named '%s' in the namespace '%s'.\n", config.deploymentName, config.namespace)
+		progressf("Using user-supplied deployment named '%s' in the namespace '%s'.\n", config.deploymentName, config.namespace)
+		if !config.fargate {
+			issues, err := k8s.CheckDeploymentPlacement(clientset, config.deploymentName, config.namespace, config.nodeSelectorKey, config.nodeSelectorValue, config.tolerationKey)
+			if err != nil {
+				return nil, bench.NewPhaseError("deployment validation", err)
+			}
+			warnPlacementIssues(config, issues)
+		}
+		var err error
+		if restoreHPA, err = pinHPA(clientset, config); err != nil {
+			return nil, bench.NewPhaseError("deployment validation", err)
+		}
+		defer restoreHPA()
 		if err := k8s.ScaleDeployment(clientset, config.deploymentName, config.namespace, config.replicas); err != nil {
-			log.Fatalf("Failed to scale up deployment: %v", err)
+			return nil, bench.NewPhaseError("deployment scale-up", err)
+		}
+	}
+
+	if config.createService {
+		if err := shutdown.Guard(func() error {
+			return k8s.CreateService(clientset, config.deploymentName, config.namespace, config.containerPort)
+		}); err != nil {
+			return nil, bench.NewPhaseError("service creation", err)
 		}
+		defer func() {
+			if err := k8s.DeleteService(clientset, config.deploymentName, config.namespace); err != nil {
+				log.Printf("Failed to delete service: %v", err)
+			}
+		}()
+	}
+
+	if config.fargate {
+		return executeFargateBenchmark(clientset, config, &result, labelSelector, baselineNodes, scaleUpStart)
 	}
 
+	nodeCounter := k8s.StartNodeCountSampler(clientset, labelSelector, scaleUpStart)
+	defer nodeCounter.Stop()
+
 	deregChan := make(chan time.Duration)
 	termChan := make(chan time.Duration)
-	errChan := make(chan error, 2)
+	podTermChan := make(chan time.Duration, 1)
+	errChan := make(chan error, 3)
 
-	instanceProvisioningTime, launchedInstances, err := aws.MonitorInstanceProvisioning(clientset, ec2Svc, tagKey, tagValue, config.deploymentName, config.namespace)
+	instanceProvisioningTime, instances, err := monitorProvisioning(clientset, ec2Svc, config, autoscalerType, tagKey, tagValue, instanceEvents)
 	if err != nil {
-		errMsg = fmt.Sprintf("Error during instance provisioning: %v", err)
-		cleanupAndFatal(clientset, config, errMsg)
+		if errors.Is(err, bench.ErrProvisioningTimeout) {
+			err = withPendingPodReasons(clientset, config, err)
+		}
+		return nil, bench.NewPhaseError("instance provisioning", err)
+	}
+	launchedInstances := len(instances)
+	// Instances launched and terminated again while the nodes register and the pods get ready count as churn too.
+	instanceTracker := aws.StartInstanceTracker(instanceClients(ec2Svc, config), tagKey, tagValue, config.startTime, instances)
+	defer instanceTracker.Stop(nil)
+	result.InstanceProvisioningTime = instanceProvisioningTime
+	logging.Phase(logger, "instance provisioning", instanceProvisioningTime)
+	result.InstanceCount = launchedInstances
+	result.LaunchTemplates = aws.CountLaunchTemplates(instances)
+	result.InstanceTypes = aws.CountInstanceTypes(instances)
+	result.AvailabilityZones = aws.CountAvailabilityZones(instances)
+	result.SpotInstances, result.OnDemandInstances = aws.CountLifecycles(instances)
+	if config.tagInstances {
+		if _, err := tagRunInstances(ec2Svc, config, tagKey, tagValue, instances); err != nil {
+			log.Printf("Warning: unable to tag the run's instances: %v", err)
+		}
+	}
+
+	var stateChan chan map[string][]time.Duration
+	if config.instanceStates {
+		stateChan = make(chan map[string][]time.Duration, 1)
+		go func() {
+			durations, err := aws.TrackInstanceStates(ec2Svc, instances)
+			if err != nil {
+				log.Printf("Warning: instance state durations may be incomplete: %v", err)
+			}
+			stateChan <- durations
+		}()
 	}
 
-	instanceRegistrationTime, err := k8s.MonitorInstanceRegistration(clientset, labelSelector, launchedInstances)
+	instanceRegistrationTime, readyNodes, err := k8s.MonitorInstanceRegistration(clientset, labelSelector, baselineNodes+launchedInstances, config.nodeReadiness)
 	if err != nil {
-		errMsg = fmt.Sprintf("Error during instance registration: %v", err)
-		cleanupAndFatal(clientset, config, errMsg)
+		return nil, bench.NewPhaseError("instance registration", err)
 	}
+	result.InstanceRegistrationTime = instanceRegistrationTime
+	logging.Phase(logger, "instance registration", instanceRegistrationTime)
+	result.RegisteredNodes = readyNodes - baselineNodes
+	recordBinPacking(clientset, config, &result, labelSelector)
 
-	podReadinessTime, err := k8s.WaitForPodsReady(clientset, config.deploymentName, config.namespace, config.replicas)
+	var podReadinessTime time.Duration
+	if isJob {
+		podReadinessTime, err = k8s.WaitForJobPodsRunning(clientset, config.deploymentName, config.namespace, config.replicas, config.podReadinessTimeout, verbosity(config))
+	} else if config.tenants > 1 {
+		result.TenantReadiness, err = waitForTenantsReady(clientset, config)
+		podReadinessTime = slowestTenant(result.TenantReadiness)
+	} else {
+		podReadinessTime, err = k8s.WaitForPodsReady(clientset, config.deploymentName, config.namespace, config.replicas, config.podReadinessTimeout, verbosity(config))
+	}
 	if err != nil {
-		errMsg = fmt.Sprintf("Error during pod readiness: %v", err)
-		cleanupAndFatal(clientset, config, errMsg)
+		return nil, bench.NewPhaseError("pod readiness", err)
+	}
+	result.PodReadinessTime = podReadinessTime
+	logging.Phase(logger, "pod readiness", podReadinessTime)
+	if stateChan != nil {
+		result.InstanceStateDurations = <-stateChan
+	}
+	if config.useNodeClaims {
+		recordNodeClaimTimings(config, &result, tagValue)
+	}
+	recordFirstSchedule(clientset, config, &result, scaleUpStart)
+	checkPodPlacement(clientset, ec2Svc, config, tagKey, tagValue)
+	result.ExpectedReplicas = totalReplicas(config)
+	result.ReadyReplicas = measureReadyReplicas(clientset, config, isJob)
+	if !isJob && config.tenants <= 1 {
+		running, nodes, err := checkRunningPods(clientset, config)
+		if err != nil {
+			return nil, bench.NewPhaseError("pod count check", err)
+		}
+		result.ReadyReplicas = running
+		result.PodNodes = nodes
+	}
+	if confirmsOneInstancePerPod(config) {
+		if err := checkOneInstancePerPod(ec2Svc, config, tagKey, tagValue); err != nil {
+			return nil, bench.NewPhaseError("node count check", err)
+		}
 	}
 
-	if err := k8s.ScaleDeployment(clientset, config.deploymentName, config.namespace, 0); err != nil {
-		errMsg = fmt.Sprintf("Failed to scale down deployment to 0: %v", err)
-		cleanupAndFatal(clientset, config, errMsg)
+	if len(rampSchedule) > 0 {
+		knownInstances, err := settledInstanceCount(ec2Svc, config, tagKey, tagValue)
+		if err != nil {
+			return nil, bench.NewPhaseError("exponential ramp", err)
+		}
+		result.RampSteps = append(result.RampSteps, bench.RampStep{
+			Step:                     0,
+			Replicas:                 rampSchedule[0],
+			InstanceProvisioningTime: instanceProvisioningTime,
+			PodReadinessTime:         instanceProvisioningTime + instanceRegistrationTime + podReadinessTime,
+			NewInstances:             knownInstances,
+		})
+		for i := 1; i < len(rampSchedule); i++ {
+			rampStep, err := executeRampStep(clientset, ec2Svc, config, tagKey, tagValue, i, rampSchedule[i], knownInstances)
+			if err != nil {
+				return nil, bench.NewPhaseError("exponential ramp", err)
+			}
+			result.RampSteps = append(result.RampSteps, rampStep)
+			knownInstances += rampStep.NewInstances
+		}
+		result.InstanceCount = knownInstances
+		if nodes, err := k8s.CountNodes(clientset, labelSelector); err != nil {
+			log.Printf("Warning: unable to count the registered nodes after the ramp: %v", err)
+		} else {
+			result.RegisteredNodes = nodes - baselineNodes
+		}
+		result.ExpectedReplicas = rampSchedule[len(rampSchedule)-1]
+		result.ReadyReplicas = measureReadyReplicas(clientset, config, isJob)
+	}
+
+	// Scraped once every pod is ready, so that Cluster Autoscaler's latencies cover the scale-up window only and not
+	// the idle time and scale-down that follow.
+	if caMetricsBefore != nil {
+		if caMetricsAfter := scrapeCAMetrics(config); caMetricsAfter != nil {
+			result.CAFunctionLatencies = bench.CAFunctionLatencies(caMetricsBefore, caMetricsAfter)
+		}
+	}
+
+	if config.chaosTerminateOne {
+		if err := executeChaosTermination(clientset, ec2Svc, config, &result, tagKey, tagValue, result.ExpectedReplicas); err != nil {
+			return nil, bench.NewPhaseError("chaos termination", err)
+		}
 	}
 
+	currentInstances, err := aws.GetEC2Instances(instanceClients(ec2Svc, config), "tag:"+tagKey, tagValue, config.startTime)
+	if err != nil {
+		log.Printf("Warning: unable to list the instances at readiness, the instance tallies only cover the first provisioning poll and churn is not checked: %v", err)
+	}
+	// Every instance launched during the scale-up is billed, including the churned ones.
+	seenInstances := instanceTracker.Seen(currentInstances)
+	result.InstanceLaunches = config.launchHistory.Classify(aws.InstanceLaunches(seenInstances, config.startTime))
+	recordRegistrationLag(clientset, &result, labelSelector, instanceTracker.RunningTimes(), len(currentInstances))
+	if err == nil {
+		// The provisioning monitor returns once the instances it awaits launched, usually only the first ones.
+		result.InstanceTypes = aws.CountInstanceTypes(currentInstances)
+		result.AvailabilityZones = aws.CountAvailabilityZones(currentInstances)
+		result.SpotInstances, result.OnDemandInstances = aws.CountLifecycles(currentInstances)
+		var missingIDs []string
+		for _, id := range aws.MissingInstanceIDs(seenInstances, currentInstances) {
+			if id != result.ChaosTerminatedInstance {
+				missingIDs = append(missingIDs, id)
+			}
+		}
+		result.ChurnedInstances = len(missingIDs)
+		result.ChurnedInstanceIDs = missingIDs
+		if result.SpotInterruptedInstances, err = aws.FindSpotInterruptions(instanceClients(ec2Svc, config), missingIDs); err != nil {
+			log.Printf("Warning: unable to check churned instances for spot interruptions: %v", err)
+		}
+	}
+
+	if evictions, err := k8s.CountEvictions(clientset, config.namespace, scaleUpStart); err != nil {
+		log.Printf("Warning: unable to count pod evictions: %v", err)
+	} else {
+		result.Evictions = evictions
+	}
+
+	if err := waitForStablePods(clientset, config, result.ExpectedReplicas); err != nil {
+		return nil, bench.NewPhaseError("pod stabilization", err)
+	}
+
+	// Once every instance left is tagged with the run ID, the termination is monitored by it rather than by the
+	// autoscaler's tag, which other runs and workloads share, and the deregistration by the nodes of those instances.
+	terminationTagKey, terminationTagValue := tagKey, tagValue
+	var runInstanceIDs []string
+	if config.tagInstances {
+		if tagged, err := tagRunInstances(ec2Svc, config, tagKey, tagValue, instanceTracker.Seen(nil)); err != nil {
+			log.Printf("Warning: unable to tag the run's instances, monitoring their termination by the autoscaler tag: %v", err)
+		} else if len(tagged) > 0 {
+			terminationTagKey, terminationTagValue = runIDTagKey, config.runID
+			runInstanceIDs = tagged
+		}
+	}
+
+	pauseBeforeScaledown(config)
+
+	deletePDB, err := createPodDisruptionBudget(clientset, config)
+	if err != nil {
+		return nil, bench.NewPhaseError("pod disruption budget creation", err)
+	}
+	defer deletePDB()
+
+	podSelector, err := workloadPodSelector(clientset, config)
+	if err != nil {
+		log.Printf("Warning: pod termination time will not be reported: %v", err)
+	}
+
+	restoreHPA()
+	if isJob {
+		if err := k8s.DeleteJob(clientset, config.deploymentName, config.namespace); err != nil {
+			return nil, bench.NewPhaseError("job deletion", err)
+		}
+	} else if err := k8s.ScaleDeployment(clientset, config.deploymentName, config.namespace, 0); err != nil {
+		return nil, bench.NewPhaseError("deployment scale-down", err)
+	}
+	if config.tenants > 1 {
+		if err := scaleDownTenants(clientset, config); err != nil {
+			return nil, bench.NewPhaseError("deployment scale-down", err)
+		}
+	}
+
+	var terminationStats k8s.TerminationStats
+	monitors := 2
+	if podSelector != "" && config.tenants <= 1 {
+		monitors++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k8s.MonitorPodTermination(clientset, config.namespace, podSelector, podTermChan, errChan)
+		}()
+	}
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		k8s.MonitorNodeDeregistration(clientset, config.nodeSelectorKey, config.nodeSelectorValue, deregChan, errChan)
+		if runInstanceIDs != nil {
+			k8s.MonitorNodeDeregistration(clientset, nodeSelector, runInstanceIDs, 0, verbosity(config), deregChan, errChan)
+		} else {
+			k8s.MonitorNodeDeregistration(clientset, nodeSelector, nil, baselineSelectedNodes, verbosity(config), deregChan, errChan)
+		}
 	}()
 	go func() {
 		defer wg.Done()
-		k8s.MonitorNodeTermination(ec2Svc, tagKey, tagValue, termChan, errChan)
+		k8s.MonitorNodeTermination(instanceClients(ec2Svc, config), terminationTagKey, terminationTagValue, config.startTime, aws.InstanceIDs(instances), config.maxTransientErrors, verbosity(config), instanceEvents, &terminationStats, termChan, errChan)
 	}()
 
 	go func() {
@@ -192,48 +1128,892 @@ func executeBenchmark(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, config C
 		close(errChan)
 	}()
 
-	for i := 0; i < 2; i++ {
+	for i := 0; i < monitors; i++ {
 		select {
 		case err := <-errChan:
-			errMsg = fmt.Sprintf("Error occurred during node termination and deregistration: %v", err)
-			cleanupAndFatal(clientset, config, errMsg)
+			return nil, bench.NewPhaseError("node termination and deregistration", err)
+		case duration := <-podTermChan:
+			result.PodTerminationTime = duration
+			logging.Phase(logger, "pod termination", duration)
 		case duration := <-deregChan:
-			instanceDeregTime = duration
+			result.NodeDeregistrationTime = duration
+			logging.Phase(logger, "node deregistration", duration)
 		case duration := <-termChan:
-			instanceTermTime = duration
+			result.InstanceTerminationTime = duration
+			logging.Phase(logger, "instance termination", duration)
 		}
 	}
+	result.TransientTerminationErrors = terminationStats.TransientErrors
+	result.TerminationSeries = terminationStats.Series
+	result.NodeCountSeries = nodeCounter.Stop()
+	recordCostEstimate(config, &result)
+	// Spot interruptions during the scale-down disrupt its measurements too, so every instance seen during the run is
+	// checked again once they terminated.
+	if interrupted, err := aws.FindSpotInterruptions(instanceClients(ec2Svc, config), aws.InstanceIDs(instanceTracker.Stop(nil))); err != nil {
+		log.Printf("Warning: unable to check the run's instances for spot interruptions after the termination: %v", err)
+	} else {
+		result.SpotInterruptedInstances = interrupted
+	}
 
-	utilities.PrintSummary(instanceProvisioningTime, instanceRegistrationTime, podReadinessTime, instanceDeregTime, instanceTermTime)
+	if restartsBefore != nil {
+		if restartsAfter := controllerRestarts(clientset, autoscalerType); restartsAfter != nil {
+			result.ControllerRestarts = k8s.ControllerRestartsSince(restartsBefore, restartsAfter)
+		}
+	}
+
+	result.DescribeInstancesCalls = describeInstancesCalls.Load()
+	recordK8sRequests(config, &result)
+	result.Anomalies = result.DetectAnomalies()
+	if err := result.CheckChurn(config.maxChurn); err != nil {
+		// The run completed, so its result is still reported along with the failure.
+		return &result, bench.NewPhaseError("churn check", err)
+	}
+	return &result, nil
+}
+
+// executeFargateBenchmark runs the measurement phases that apply to EKS Fargate, where every pod is backed by its own
+// Fargate node and there are no EC2 instances to monitor. Pod provisioning time is measured as the time until one new
+// Fargate node per replica has registered, and deregistration as the time until those nodes are gone again.
+// The baselineNodes Fargate nodes that already existed before the benchmark (e.g. CoreDNS) are not counted.
+func executeFargateBenchmark(clientset *kubernetes.Clientset, config Config, result *bench.BenchmarkResult, labelSelector string, baselineNodes int, scaleUpStart time.Time) (*bench.BenchmarkResult, error) {
+	podProvisioningTime, _, err := k8s.MonitorInstanceRegistration(clientset, labelSelector, baselineNodes+config.replicas, config.nodeReadiness)
+	if err != nil {
+		return nil, bench.NewPhaseError("Fargate pod provisioning", err)
+	}
+	result.InstanceRegistrationTime = podProvisioningTime
+	logger := logging.ForRun(result.AutoscalerType, result.Target)
+	logging.Phase(logger, "Fargate pod provisioning", podProvisioningTime)
+
+	podReadinessTime, err := k8s.WaitForPodsReady(clientset, config.deploymentName, config.namespace, config.replicas, config.podReadinessTimeout, verbosity(config))
+	if err != nil {
+		return nil, bench.NewPhaseError("pod readiness", err)
+	}
+	result.PodReadinessTime = podReadinessTime
+	logging.Phase(logger, "pod readiness", podReadinessTime)
+	recordFirstSchedule(clientset, config, result, scaleUpStart)
+
+	if err := waitForStablePods(clientset, config, config.replicas); err != nil {
+		return nil, bench.NewPhaseError("pod stabilization", err)
+	}
+
+	pauseBeforeScaledown(config)
+
+	deletePDB, err := createPodDisruptionBudget(clientset, config)
+	if err != nil {
+		return nil, bench.NewPhaseError("pod disruption budget creation", err)
+	}
+	defer deletePDB()
+
+	if err := k8s.ScaleDeployment(clientset, config.deploymentName, config.namespace, 0); err != nil {
+		return nil, bench.NewPhaseError("deployment scale-down", err)
+	}
+
+	deregChan := make(chan time.Duration, 1)
+	errChan := make(chan error, 1)
+	k8s.MonitorNodeDeregistration(clientset, labelSelector, nil, baselineNodes, verbosity(config), deregChan, errChan)
+
+	select {
+	case err := <-errChan:
+		return nil, bench.NewPhaseError("Fargate node deregistration", err)
+	case result.NodeDeregistrationTime = <-deregChan:
+		logging.Phase(logger, "Fargate node deregistration", result.NodeDeregistrationTime)
+	}
+
+	recordK8sRequests(config, result)
+	result.Anomalies = result.DetectAnomalies()
+	return result, nil
+}
+
+// recordK8sRequests records the number of Kubernetes requests sent since the run started, and their average rate, on
+// the result. The count is shared by all clients, so runs executing concurrently count each other's requests too.
+func recordK8sRequests(config Config, result *bench.BenchmarkResult) {
+	result.K8sRequests = k8sRequests.Requests() - config.k8sRequestsAtStart
+	if elapsed := time.Since(config.runStart).Seconds(); elapsed > 0 {
+		result.K8sRequestsPerSecond = float64(result.K8sRequests) / elapsed
+	}
+}
+
+// recordCostEstimate records the estimated cost of the launched instances, each alive from its launch until now, i.e.
+// the end of the termination monitoring, on the result. Missing prices only log a warning since the estimate is
+// informational: an unreadable --pricing-map leaves it at zero and instance types without a price are left out.
+func recordCostEstimate(config Config, result *bench.BenchmarkResult) {
+	prices, err := aws.LoadPriceMap(config.pricingMap)
+	if err != nil {
+		log.Printf("Warning: cost will not be estimated: %v", err)
+		return
+	}
+	cost, missing := prices.EstimateCost(result.InstanceLaunches, time.Since(config.startTime))
+	if len(missing) > 0 {
+		log.Printf("Warning: cost estimate excludes instance types without a price, add them with --pricing-map: %s", strings.Join(missing, ", "))
+	}
+	result.CostEstimateUSD = cost
+}
+
+// recordRegistrationLag records the average kubelet registration lag of the instances with a known running time on the
+// result, out of the given number of instances. Failures only log a warning since the data is informational.
+func recordRegistrationLag(clientset *kubernetes.Clientset, result *bench.BenchmarkResult, labelSelector string, runningTimes map[string]time.Time, instances int) {
+	lag, matched, err := k8s.KubeletRegistrationLag(clientset, labelSelector, runningTimes)
+	if err != nil {
+		log.Printf("Warning: unable to measure the kubelet registration lag: %v", err)
+		return
+	}
+	if matched < instances {
+		log.Printf("Warning: only %d of %d instances could be matched to a node by provider ID and a time they started running for the kubelet registration lag", matched, instances)
+	}
+	result.KubeletRegistrationLag = lag
+}
+
+// recordBinPacking records the allocatable capacity of the registered nodes and the CPU requested by the deployment
+// on the result, so the summary can report bin-packing efficiency. Failures only log a warning since the metric is informational.
+func recordBinPacking(clientset *kubernetes.Clientset, config Config, result *bench.BenchmarkResult, labelSelector string) {
+	cpuMillis, memoryBytes, err := k8s.SumAllocatable(clientset, labelSelector)
+	if err != nil {
+		log.Printf("Warning: unable to collect node allocatable capacity: %v", err)
+		return
+	}
+	var podCPUMillis int64
+	if strings.EqualFold(config.workloadKind, "Job") {
+		quantity, err := resource.ParseQuantity(config.cpuRequest)
+		if err != nil {
+			log.Printf("Warning: unable to parse job CPU request: %v", err)
+			return
+		}
+		podCPUMillis = quantity.MilliValue()
+	} else {
+		podCPUMillis, err = k8s.GetPodCPURequest(clientset, config.deploymentName, config.namespace)
+		if err != nil {
+			log.Printf("Warning: unable to read deployment CPU request: %v", err)
+			return
+		}
+	}
+
+	result.AllocatableCPUMillis = cpuMillis
+	result.AllocatableMemoryBytes = memoryBytes
+	result.RequestedCPUMillis = podCPUMillis * int64(totalReplicas(config))
+}
+
+// recordAWSIdentity records the AWS account and region the benchmark ran in on the result, so archived results are
+// self-describing. It is a no-op without AWS clients (Fargate), and failures only log a warning since the data is informational.
+func recordAWSIdentity(stsSvc *sts.STS, ec2Svc *ec2.EC2, result *bench.BenchmarkResult) {
+	if stsSvc == nil || ec2Svc == nil {
+		return
+	}
+	result.AWSRegion = aws.GetRegion(ec2Svc)
+	accountID, err := aws.GetAccountID(stsSvc)
+	if err != nil {
+		log.Printf("Warning: unable to determine the AWS account: %v", err)
+		return
+	}
+	result.AWSAccountID = accountID
+}
+
+// warnPlacementIssues warns about every way the benchmarked deployment's pods are not pinned to the autoscaler's nodes,
+// since such pods may schedule onto unrelated nodes and leave nothing to measure.
+func warnPlacementIssues(config Config, issues []string) {
+	for _, issue := range issues {
+		progressf("Warning: deployment '%s' has %s; its pods may schedule onto nodes the autoscaler does not manage. Adjust --node-selector-key/--node-selector-value/--toleration-key if they differ.\n", config.deploymentName, issue)
+	}
+}
+
+// workloadPodSelector returns the label selector of the benchmarked workload's pods.
+func workloadPodSelector(clientset *kubernetes.Clientset, config Config) (string, error) {
+	if strings.EqualFold(config.workloadKind, "Job") {
+		return fmt.Sprintf("job-name=%s", config.deploymentName), nil
+	}
+	return k8s.DeploymentPodSelector(clientset, config.deploymentName, config.namespace)
+}
+
+// withPendingPodReasons adds the reasons the workload's pods are still pending to a provisioning timeout error, as a
+// diagnostic for the misconfigurations that usually cause it, e.g. a wrong node pool or unschedulable pods.
+func withPendingPodReasons(clientset *kubernetes.Clientset, config Config, err error) error {
+	podSelector, selectorErr := workloadPodSelector(clientset, config)
+	if selectorErr != nil {
+		log.Printf("Warning: unable to determine the deployment's pod selector: %v", selectorErr)
+		return err
+	}
+	reasons, reasonsErr := k8s.PendingPodReasons(clientset, config.namespace, podSelector)
+	if reasonsErr != nil {
+		log.Printf("Warning: unable to determine why the pods are pending: %v", reasonsErr)
+		return err
+	}
+	if len(reasons) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w\nPending pods:\n  %s", err, strings.Join(reasons, "\n  "))
+}
+
+// recordFirstSchedule records the time from the scale-up until the first of the workload's pods was scheduled onto a node
+// on the result. Failures only log a warning since the metric is informational.
+func recordFirstSchedule(clientset *kubernetes.Clientset, config Config, result *bench.BenchmarkResult, scaleUpStart time.Time) {
+	podSelector, err := workloadPodSelector(clientset, config)
+	if err != nil {
+		log.Printf("Warning: unable to determine the deployment's pod selector: %v", err)
+		return
+	}
+
+	firstScheduled, err := k8s.FirstPodScheduledTime(clientset, config.namespace, podSelector, scaleUpStart)
+	if err != nil {
+		log.Printf("Warning: unable to determine the first pod schedule time: %v", err)
+		return
+	}
+	if !firstScheduled.IsZero() {
+		result.TimeToFirstSchedule = firstScheduled.Sub(scaleUpStart.Truncate(time.Second))
+	}
+}
+
+// checkPodPlacement warns if pods of the workload were scheduled onto nodes that are not backed by an instance the run
+// launched, e.g. pre-existing nodes of the same node pool, as the benchmark then does not reflect the provisioning of
+// all pods. This catches node selectors and tolerations that silently do not match the autoscaler's nodes. Tenant
+// namespaces are not checked.
+func checkPodPlacement(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, config Config, tagKey, tagValue string) {
+	if config.tenants > 1 {
+		return
+	}
+	podSelector, err := workloadPodSelector(clientset, config)
+	if err != nil {
+		log.Printf("Warning: unable to determine the deployment's pod selector: %v", err)
+		return
+	}
+	instances, err := aws.GetEC2Instances(instanceClients(ec2Svc, config), "tag:"+tagKey, tagValue, config.startTime)
+	if err != nil {
+		log.Printf("Warning: unable to list the run's instances to check where the pods were scheduled: %v", err)
+		return
+	}
+	outside, err := k8s.PodsOutsideNodes(clientset, config.namespace, podSelector, aws.InstanceIDs(instances))
+	if err != nil {
+		log.Printf("Warning: unable to check where the pods were scheduled: %v", err)
+		return
+	}
+	if len(outside) > 0 {
+		log.Printf("Warning: %d pods were scheduled onto nodes whose instances were not launched by this run: %s. The benchmark may not reflect true provisioning; check the workload's node selector and tolerations.", len(outside), strings.Join(outside, ", "))
+	}
+}
+
+// checkRunningPods confirms after readiness that at least as many pods of the deployment are Running as it was scaled
+// to, so an incomplete scale-up, e.g. pods that failed right after the ready count was reached, is not reported as a
+// success. Surplus pods, e.g. from an HPA on --deployment, are accepted. With --one-pod-per-node the pods must also run
+// on as many distinct nodes. It returns the Running pods and the distinct nodes they run on, or an error wrapping
+// bench.ErrIncompleteScaleUp.
+func checkRunningPods(clientset *kubernetes.Clientset, config Config) (int, int, error) {
+	podSelector, err := workloadPodSelector(clientset, config)
+	if err != nil {
+		return 0, 0, err
+	}
+	running, nodes, others, err := k8s.CountRunningPods(clientset, config.namespace, podSelector)
+	if err != nil {
+		return 0, 0, err
+	}
+	if running < config.replicas || (config.onePodPerNode && nodes < config.replicas) {
+		details := ""
+		if len(others) > 0 {
+			details = fmt.Sprintf("; pods not running: %s", strings.Join(others, ", "))
+		}
+		return running, nodes, fmt.Errorf("%d pods are Running on %d nodes after readiness, want %d pods%s: %w", running, nodes, config.replicas, details, bench.ErrIncompleteScaleUp)
+	}
+	progressf("Confirmed %d Running pods on %d nodes.\n", running, nodes)
+	return running, nodes, nil
+}
+
+// measureReadyReplicas returns the number of ready pods of the workload, or of all tenants' deployments, as reported
+// by the API once the benchmark considers them ready, counting the Running pods of a Job. When they cannot be read,
+// a warning is logged and the replicas the benchmark scaled to are returned.
+func measureReadyReplicas(clientset *kubernetes.Clientset, config Config, isJob bool) int {
+	ready := 0
+	var err error
+	switch {
+	case isJob:
+		var podSelector string
+		if podSelector, err = workloadPodSelector(clientset, config); err == nil {
+			ready, _, _, err = k8s.CountRunningPods(clientset, config.namespace, podSelector)
+		}
+	case config.tenants > 1:
+		for _, namespace := range tenantNamespaces(config) {
+			tenantReady, tenantErr := k8s.GetReadyReplicas(clientset, config.containerName, namespace)
+			if tenantErr != nil {
+				err = tenantErr
+				break
+			}
+			ready += tenantReady
+		}
+	default:
+		ready, err = k8s.GetReadyReplicas(clientset, config.deploymentName, config.namespace)
+	}
+	if err != nil {
+		log.Printf("Warning: unable to read the ready replicas: %v", err)
+		return totalReplicas(config)
+	}
+	return ready
+}
+
+// prepareExistingDeployment resolves the name of the deployment the run creates, the generated one or the one of
+// --deployment-manifest, and handles a deployment left over at that name with prepareDeploymentName. An adopted
+// deployment is scaled to 0 and its nodes are waited for to be removed, so that the run measures a full scale-up
+// rather than pods that are already running. Fargate starts a node per pod, whose nodes are never reused. It reports
+// whether the deployment is adopted.
+func prepareExistingDeployment(clientset kubernetes.Interface, config Config, isJob bool, labelSelector string) (bool, error) {
+	if isJob || config.deploymentName != "" {
+		return false, nil
+	}
+	config.deploymentName = config.containerName
+	if config.tenants > 1 {
+		config.namespace = tenantNamespaces(config)[0]
+	}
+	if config.deploymentManifest != "" {
+		deployment, err := k8s.LoadDeploymentManifest(config.deploymentManifest)
+		if err != nil {
+			return false, fmt.Errorf("%v: %w", err, bench.ErrInvalidConfig)
+		}
+		config.deploymentName = deployment.Name
+		if deployment.Namespace != "" {
+			config.namespace = deployment.Namespace
+		}
+	}
+
+	reuse, err := prepareDeploymentName(clientset, config)
+	if err != nil || !reuse {
+		return reuse, err
+	}
+	progressf("Scaling the reused deployment '%s' to 0 replicas before the benchmark.\n", config.deploymentName)
+	if err := k8s.ScaleDeployment(clientset, config.deploymentName, config.namespace, 0); err != nil {
+		return false, err
+	}
+	if config.fargate {
+		return true, nil
+	}
+	return true, waitForNodesGone(clientset, labelSelector, 0)
+}
+
+// prepareDeploymentName handles a deployment that already exists at the name the benchmark is about to create, e.g.
+// one left over by a crashed run. With --replace it is deleted so it can be recreated, with --reuse-existing true is
+// returned so the caller adopts and scales it instead, and otherwise an error naming the stale deployment is returned.
+func prepareDeploymentName(clientset kubernetes.Interface, config Config) (bool, error) {
+	exists, err := k8s.ResourceExists(clientset, "Deployment", config.deploymentName, config.namespace)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	switch {
+	case config.reuseExisting:
+		progressf("Reusing the existing deployment '%s' in the namespace '%s'.\n", config.deploymentName, config.namespace)
+		return true, nil
+	case config.replaceExisting:
+		progressf("Replacing the existing deployment '%s' in the namespace '%s'.\n", config.deploymentName, config.namespace)
+		if err := k8s.DeleteDeployment(clientset, config.deploymentName, config.namespace); err != nil {
+			return false, err
+		}
+		return false, k8s.WaitForDeploymentDeleted(clientset, config.deploymentName, config.namespace)
+	default:
+		return false, fmt.Errorf("A deployment named '%s' already exists in the namespace '%s', probably left over by a previous run. Delete it, or rerun with --replace to recreate it or --reuse-existing to adopt it: %w", config.deploymentName, config.namespace, bench.ErrInvalidConfig)
+	}
+}
+
+// confirmsOneInstancePerPod reports whether the run must not launch more instances than pods: with --one-pod-per-node,
+// and for a single pod, e.g. one with a node-filling CPU request for a single-node measurement, unless more instances
+// are expected.
+func confirmsOneInstancePerPod(config Config) bool {
+	return config.onePodPerNode || (totalReplicas(config) == 1 && config.exponentialRamp == "" && config.expectedInstances <= 1)
+}
+
+// checkOneInstancePerPod confirms for --one-pod-per-node and single-pod runs that no more instances were launched than
+// there are replicas, so the replica count maps 1:1 to nodes and a single pod provisioned exactly one node. It returns
+// an error wrapping bench.ErrUnexpectedNodeCount otherwise.
+func checkOneInstancePerPod(ec2Svc *ec2.EC2, config Config, tagKey, tagValue string) error {
+	instances, err := aws.GetEC2Instances(instanceClients(ec2Svc, config), "tag:"+tagKey, tagValue, config.startTime)
+	if err != nil {
+		return err
+	}
+	if len(instances) > config.replicas {
+		return fmt.Errorf("%d instances (%s) were launched for %d pods, want one per pod: %w", len(instances), strings.Join(aws.InstanceIDs(instances), ", "), config.replicas, bench.ErrUnexpectedNodeCount)
+	}
+	progressf("Confirmed one instance per pod: %d instances for %d pods.\n", len(instances), config.replicas)
+	return nil
+}
+
+// runIDTagKey is the tag --tag-instances stamps the run's instances with.
+const runIDTagKey = "kab-run-id"
+
+// newRunID returns a random ID identifying a run in the tags of its instances. It does not use the --seed random
+// source, so runs replaying a seed are still told apart.
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := cryptorand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// tagRunInstances stamps the instances the run observed, in each region, with the run ID and the --instance-tag tags,
+// and returns the IDs it tagged. Only the observed instances still listed with the autoscaler's tag since the run
+// started are tagged, and none another run already claimed with its own run ID, so parallel runs and other runs sharing
+// the node pool keep theirs. Tagging is idempotent, so it is repeated to cover instances launched after the first call,
+// e.g. by a ramp step or a replacement.
+func tagRunInstances(ec2Svc *ec2.EC2, config Config, tagKey, tagValue string, observed []*ec2.Instance) ([]string, error) {
+	tags := map[string]string{runIDTagKey: config.runID}
+	for key, value := range config.instanceTags {
+		tags[key] = value
+	}
+	observedIDs := make(map[string]bool, len(observed))
+	for _, id := range aws.InstanceIDs(observed) {
+		observedIDs[id] = true
+	}
+	var tagged []string
+	for _, client := range instanceClients(ec2Svc, config) {
+		instances, err := aws.GetEC2Instances([]*ec2.EC2{client}, "tag:"+tagKey, tagValue, config.startTime)
+		if err != nil {
+			return tagged, err
+		}
+		var ids []string
+		for i, id := range aws.InstanceIDs(instances) {
+			if runID, ok := aws.InstanceTag(instances[i], runIDTagKey); observedIDs[id] && (!ok || runID == config.runID) {
+				ids = append(ids, id)
+			}
+		}
+		if err := aws.CreateTags(client, ids, tags); err != nil {
+			return tagged, err
+		}
+		tagged = append(tagged, ids...)
+	}
+	return tagged, nil
+}
+
+// verbosity returns the verbosity of the monitors for --quiet.
+func verbosity(config Config) k8s.Verbosity {
+	if config.quiet {
+		return k8s.Quiet
+	}
+	return k8s.Normal
+}
+
+// instanceClients returns the EC2 clients to list the run's instances with: the primary region's, followed by those of
+// the other regions given with --region.
+func instanceClients(ec2Svc *ec2.EC2, config Config) []*ec2.EC2 {
+	return append([]*ec2.EC2{ec2Svc}, config.extraRegionEC2...)
+}
+
+// monitorProvisioning waits for the scale-up's instances to launch. Karpenter v1 node pools are tracked through their
+// NodeClaims, which report the IDs of the instances they launched, and everything else through the EC2 tag.
+func monitorProvisioning(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, config Config, autoscalerType, tagKey, tagValue string, instanceEvents *aws.InstanceEvents) (time.Duration, []*ec2.Instance, error) {
+	if autoscalerType != "Karpenter" || config.karpenterAPIVersion != "v1" {
+		return aws.MonitorInstanceProvisioning(shutdown.Context(), clientset, instanceClients(ec2Svc, config), tagKey, tagValue, config.deploymentName, config.namespace, config.startTime, config.expectedInstances, config.firstInstanceTimeout, config.provisioningTimeout, promptInput(config), instanceEvents)
+	}
+	client, err := newDynamicClient(config.kubeconfigPath)
+	if err != nil {
+		return 0, nil, err
+	}
+	selector, err := k8s.LabelSelector(tagKey, tagValue)
+	if err != nil {
+		return 0, nil, err
+	}
+	return aws.MonitorNodeClaimProvisioning(shutdown.Context(), client, k8s.NodeClaimGVR(config.karpenterAPIVersion), instanceClients(ec2Svc, config), selector, config.startTime, config.expectedInstances, config.firstInstanceTimeout, config.provisioningTimeout, promptInput(config))
+}
+
+// recordNodeClaimTimings records the condition timings of the node pool's NodeClaims created during the run on the
+// result. Failures only log a warning since the timings complement the EC2 and node measurements.
+func recordNodeClaimTimings(config Config, result *bench.BenchmarkResult, nodepool string) {
+	client, err := newDynamicClient(config.kubeconfigPath)
+	if err != nil {
+		log.Printf("Warning: NodeClaim timings will not be reported: %v", err)
+		return
+	}
+	timings, err := k8s.NodeClaimTimings(client, config.karpenterAPIVersion, nodepool, config.startTime)
+	if err != nil {
+		log.Printf("Warning: NodeClaim timings may be incomplete: %v", err)
+	}
+	result.NodeClaimTimings = timings
+}
+
+// scrapeCAMetrics scrapes Cluster Autoscaler's function duration histograms from --ca-metrics-url. It returns nil when
+// no URL is configured or the scrape fails, which only logs a warning since the metrics are informational.
+func scrapeCAMetrics(config Config) map[string]bench.FunctionTotals {
+	if config.caMetricsURL == "" {
+		return nil
+	}
+	totals, err := utilities.ScrapeCAFunctionDurations(config.caMetricsURL)
+	if err != nil {
+		log.Printf("Warning: Cluster Autoscaler latency will not be reported: %v", err)
+		return nil
+	}
+	return totals
+}
+
+// controllerRestarts returns a snapshot of the restart counts of the autoscaler's controller pods, or nil when they
+// cannot be listed, which only logs a warning since the restarts are informational.
+func controllerRestarts(clientset *kubernetes.Clientset, autoscalerType string) map[string]int {
+	restarts, err := k8s.ControllerRestarts(clientset, autoscalerType)
+	if err != nil {
+		log.Printf("Warning: controller restarts will not be reported: %v", err)
+		return nil
+	}
+	return restarts
+}
+
+// waitForStablePods confirms that the deployment's pods stay ready for the --min-pod-running-before-scaledown window,
+// so scale-down starts from a genuinely stable state. It is a no-op when the window is 0.
+func waitForStablePods(clientset *kubernetes.Clientset, config Config, replicas int) error {
+	if config.stabilizationWindow <= 0 {
+		return nil
+	}
+	return k8s.WaitForPodsStable(clientset, config.deploymentName, config.namespace, replicas, config.stabilizationWindow, config.podReadinessTimeout, verbosity(config))
+}
+
+// createPodDisruptionBudget creates the PodDisruptionBudget requested with --create-pdb for the benchmark deployment,
+// so its drain-blocking effect shows up in the scale-down phases. It returns a function that deletes the budget again,
+// which is a no-op when no budget was requested.
+func createPodDisruptionBudget(clientset *kubernetes.Clientset, config Config) (func(), error) {
+	if config.createPDB == "" {
+		return func() {}, nil
+	}
+
+	spec, err := k8s.ParsePDBSpec(config.createPDB)
+	if err != nil {
+		return nil, err
+	}
+	if err := shutdown.Guard(func() error {
+		return k8s.CreatePodDisruptionBudget(clientset, config.deploymentName, config.namespace, spec)
+	}); err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := k8s.DeletePodDisruptionBudget(clientset, config.deploymentName, config.namespace); err != nil {
+			log.Printf("Failed to delete pod disruption budget: %v", err)
+		}
+	}, nil
+}
+
+// instanceEventQueues holds the recorded events of each --ec2-events-queue. Each queue is consumed once per process, so
+// that concurrent runs share its messages instead of deleting each other's.
+var (
+	instanceEventQueuesMu sync.Mutex
+	instanceEventQueues   = map[string]*aws.InstanceEvents{}
+)
+
+// watchInstanceEvents returns the recorded events of the queue, and starts consuming it on first use until the process
+// exits.
+func watchInstanceEvents(ec2Svc *ec2.EC2, queueURL string) (*aws.InstanceEvents, error) {
+	instanceEventQueuesMu.Lock()
+	defer instanceEventQueuesMu.Unlock()
+	if events, ok := instanceEventQueues[queueURL]; ok {
+		return events, nil
+	}
+	sqsSvc, err := aws.NewSQS(ec2Svc, queueURL)
+	if err != nil {
+		return nil, err
+	}
+	events := aws.NewInstanceEvents()
+	go aws.WatchInstanceStateEvents(sqsSvc, queueURL, events, nil)
+	instanceEventQueues[queueURL] = events
+	return events, nil
+}
+
+// capacityRestorers holds, per benchmarked node group, the function restoring its Auto Scaling groups' pre-run desired
+// capacity, so the SIGINT cleanup can restore it too. Each restorer is removed when it runs, so it runs only once.
+var capacityRestorers sync.Map
+
+// captureNodeGroupCapacity records the desired capacity of the Auto Scaling groups backing the benchmarked node group
+// before the run, and returns a function that sets it back, so back-to-back Cluster Autoscaler runs start from the same
+// state. Failures only produce warnings, as the benchmark itself does not depend on them.
+func captureNodeGroupCapacity(ec2Svc *ec2.EC2, config Config) func() {
+	asgSvc, err := aws.NewAutoScaling(ec2Svc)
+	if err != nil {
+		log.Printf("Warning: unable to restore the node group's desired capacity after the run: %v", err)
+		return func() {}
+	}
+	capacities, err := aws.GetNodeGroupDesiredCapacities(asgSvc, config.nodeGroup)
+	if err != nil {
+		log.Printf("Warning: unable to restore the node group's desired capacity after the run: %v", err)
+		return func() {}
+	}
+
+	capacityRestorers.Store(config.nodeGroup, func() {
+		if err := aws.RestoreDesiredCapacities(asgSvc, capacities); err != nil {
+			log.Printf("Failed to restore node group desired capacity: %v", err)
+		}
+	})
+	return func() { restoreNodeGroupCapacity(config.nodeGroup) }
+}
+
+// restoreNodeGroupCapacity runs the desired capacity restorer of the node group, if one is still pending.
+func restoreNodeGroupCapacity(nodeGroup string) {
+	if restore, ok := capacityRestorers.LoadAndDelete(nodeGroup); ok {
+		restore.(func())()
+	}
+}
+
+// scaleUpPrecreated waits for the deployment pre-created with 0 replicas by --precreate to settle, then scales it to
+// the benchmark replicas. It returns the time the timed scale-up started.
+func scaleUpPrecreated(clientset *kubernetes.Clientset, config Config) (time.Time, error) {
+	if err := k8s.WaitForDeploymentSettled(clientset, config.deploymentName, config.namespace); err != nil {
+		return time.Time{}, err
+	}
+	scaleUpStart := time.Now()
+	if err := k8s.ScaleDeployment(clientset, config.deploymentName, config.namespace, config.replicas); err != nil {
+		return time.Time{}, err
+	}
+	return scaleUpStart, nil
+}
+
+// pinHPA looks for a HorizontalPodAutoscaler targeting the user-supplied deployment. Without --respect-hpa it only warns
+// that the HPA may undo the scale event; with it the HPA is pinned to the benchmark replicas. It returns a function that
+// restores the HPA again, which is a no-op when nothing was pinned or it was already restored.
+func pinHPA(clientset *kubernetes.Clientset, config Config) (func(), error) {
+	hpa, err := k8s.FindHPA(clientset, config.deploymentName, config.namespace)
+	if err != nil || hpa == nil {
+		return func() {}, err
+	}
+	if !config.respectHPA {
+		progressf("Warning: horizontal pod autoscaler %q targets deployment '%s' and may undo the scale to %d replicas. Use --respect-hpa to pin it during the benchmark.\n", hpa.Name, config.deploymentName, config.replicas)
+		return func() {}, nil
+	}
+
+	if err := k8s.PinHPA(clientset, hpa, config.replicas); err != nil {
+		return nil, err
+	}
+	var restore sync.Once
+	return func() {
+		restore.Do(func() {
+			if err := k8s.RestoreHPA(clientset, config.deploymentName, config.namespace); err != nil {
+				log.Printf("Failed to restore horizontal pod autoscaler: %v", err)
+			}
+		})
+	}, nil
+}
+
+// promptInput returns where the provisioning timeout prompt reads its answer from: stdin, or nil with --non-interactive
+// so that the timeout fails instead of blocking.
+func promptInput(config Config) io.Reader {
+	if config.nonInteractive {
+		return nil
+	}
+	return os.Stdin
 }
 
-// cleanupAndFatal attempts to delete the specified deployment from the given namespace
+// pauseBeforeScaledown blocks until the user presses Enter when --pause-before-scaledown is set, giving them time to
+// run diagnostics against the fully-scaled cluster. Time spent paused is not part of any measured phase. With
+// --non-interactive, e.g. in serve mode, there is no one to press Enter and the pause is skipped.
+func pauseBeforeScaledown(config Config) {
+	if !config.pauseBeforeScaledown || config.nonInteractive {
+		return
+	}
+
+	progressf("All pods are ready. Press Enter to trigger scale-down...\n")
+	if _, err := bufio.NewReader(os.Stdin).ReadString('\n'); err != nil {
+		log.Printf("Failed to read input, continuing with scale-down: %v", err)
+	}
+}
+
+// cleanupAndFatal attempts to delete the generated deployments of the given run configurations
 // and logs the provided error message before exiting the program. It is designed to ensure
-// that a generated deployment is cleaned up in the event of a critical failure during execution.
-func cleanupAndFatal(clientset *kubernetes.Clientset, config Config, errMsg string) {
+// that generated deployments are cleaned up in the event of a critical failure during execution.
+// The cleanup runs as the shutdown, so the interrupted run waits for it instead of exiting first, and the program
+// exits once it finished or shutdownCleanupTimeout elapsed.
+func cleanupAndFatal(clientset kubernetes.Interface, configs []Config, errMsg string) {
 	log.Printf(errMsg)
-	if config.deploymentName == "" {
-		if err := k8s.DeleteDeployment(clientset, config.containerName, config.namespace); err != nil {
-			log.Printf("Failed to delete deployment during cleanup: %v", err)
+	cleanup := func() {
+		for _, config := range configs {
+			cleanupRun(clientset, config)
 		}
 	}
+	if !shutdown.Begin(cleanup, shutdownCleanupTimeout) {
+		log.Printf("Warning: cleanup did not finish within %v, resources may be left behind", shutdownCleanupTimeout)
+	}
 	log.Fatalf("Exiting...")
 }
 
+// cleanupAction is one step of the cleanup of a benchmark run. exists looks up the resource the action targets, for
+// --dry-run; it is nil for actions that do not target a Kubernetes resource.
+type cleanupAction struct {
+	description string
+	exists      func() (bool, error)
+	run         func() error
+}
+
+// resourceExists returns the exists lookup of a cleanup action targeting the given resource.
+func resourceExists(clientset kubernetes.Interface, kind, name, namespace string) func() (bool, error) {
+	return func() (bool, error) {
+		return k8s.ResourceExists(clientset, kind, name, namespace)
+	}
+}
+
+// cleanupActions returns the steps to clean up the resources a single benchmark run created: the PodDisruptionBudget,
+// the node group's desired capacity, the HPA, the service and the generated workload or the deployment of the manifest.
+// There are none with --observe-only, which creates nothing and must not touch the externally scaled workload.
+func cleanupActions(clientset kubernetes.Interface, config Config) []cleanupAction {
+	if config.observeOnly {
+		return nil
+	}
+	var actions []cleanupAction
+	if config.createPDB != "" && config.deploymentManifest == "" {
+		deploymentName := config.deploymentName
+		if deploymentName == "" {
+			deploymentName = config.containerName
+		}
+		actions = append(actions, cleanupAction{
+			description: fmt.Sprintf("delete pod disruption budget %q in namespace %q", k8s.PDBName(deploymentName), config.namespace),
+			exists:      resourceExists(clientset, "PodDisruptionBudget", k8s.PDBName(deploymentName), config.namespace),
+			run:         func() error { return k8s.DeletePodDisruptionBudget(clientset, deploymentName, config.namespace) },
+		})
+	}
+	if config.nodeGroup != "" {
+		actions = append(actions, cleanupAction{
+			description: fmt.Sprintf("restore the desired capacity of node group %q to its pre-run value", config.nodeGroup),
+			run: func() error {
+				restoreNodeGroupCapacity(config.nodeGroup)
+				return nil
+			},
+		})
+	}
+	if config.respectHPA && config.deploymentName != "" {
+		actions = append(actions, cleanupAction{
+			description: fmt.Sprintf("restore the horizontal pod autoscaler of deployment %q in namespace %q", config.deploymentName, config.namespace),
+			exists: func() (bool, error) {
+				hpa, err := k8s.FindHPA(clientset, config.deploymentName, config.namespace)
+				return hpa != nil, err
+			},
+			run: func() error { return k8s.RestoreHPA(clientset, config.deploymentName, config.namespace) },
+		})
+	}
+	if config.createService {
+		actions = append(actions, cleanupAction{
+			description: fmt.Sprintf("delete service %q in namespace %q", config.containerName, config.namespace),
+			exists:      resourceExists(clientset, "Service", config.containerName, config.namespace),
+			run:         func() error { return k8s.DeleteService(clientset, config.containerName, config.namespace) },
+		})
+	}
+	if config.baselineDeployment != "" {
+		if baseline, namespace, err := loadBaseline(config); err != nil {
+			log.Printf("Warning: the baseline deployment will not be cleaned up: %v", err)
+		} else {
+			actions = append(actions, cleanupAction{
+				description: fmt.Sprintf("delete baseline deployment %q in namespace %q", baseline.Name, namespace),
+				exists:      resourceExists(clientset, "Deployment", baseline.Name, namespace),
+				run:         func() error { return k8s.DeleteDeployment(clientset, baseline.Name, namespace) },
+			})
+		}
+	}
+	if strings.EqualFold(config.workloadKind, "Job") {
+		actions = append(actions, cleanupAction{
+			description: fmt.Sprintf("delete job %q in namespace %q", config.containerName, config.namespace),
+			exists:      resourceExists(clientset, "Job", config.containerName, config.namespace),
+			run:         func() error { return k8s.DeleteJob(clientset, config.containerName, config.namespace) },
+		})
+	} else if config.tenants > 1 {
+		for _, namespace := range tenantNamespaces(config) {
+			namespace := namespace
+			actions = append(actions, cleanupAction{
+				description: fmt.Sprintf("delete tenant namespace %q", namespace),
+				exists:      resourceExists(clientset, "Namespace", namespace, ""),
+				run:         func() error { return k8s.DeleteNamespace(clientset, namespace) },
+			})
+		}
+	} else if config.deploymentManifest != "" {
+		if deployment, err := k8s.LoadDeploymentManifest(config.deploymentManifest); err != nil {
+			log.Printf("Warning: the deployment of the manifest will not be cleaned up: %v", err)
+		} else {
+			namespace := config.namespace
+			if deployment.Namespace != "" {
+				namespace = deployment.Namespace
+			}
+			actions = append(actions, cleanupAction{
+				description: fmt.Sprintf("delete deployment %q in namespace %q", deployment.Name, namespace),
+				exists:      resourceExists(clientset, "Deployment", deployment.Name, namespace),
+				run:         func() error { return k8s.DeleteDeployment(clientset, deployment.Name, namespace) },
+			})
+		}
+	} else if config.deploymentName == "" {
+		actions = append(actions, cleanupAction{
+			description: fmt.Sprintf("delete deployment %q in namespace %q", config.containerName, config.namespace),
+			exists:      resourceExists(clientset, "Deployment", config.containerName, config.namespace),
+			run:         func() error { return k8s.DeleteDeployment(clientset, config.containerName, config.namespace) },
+		})
+	}
+	return actions
+}
+
+// cleanupRun deletes the resources a single benchmark run created, logging the steps that fail.
+func cleanupRun(clientset kubernetes.Interface, config Config) {
+	for _, action := range cleanupActions(clientset, config) {
+		if err := action.run(); err != nil {
+			log.Printf("Failed to %s during cleanup: %v", action.description, err)
+		}
+	}
+}
+
+// previewCleanup prints the steps the cleanup of a single benchmark run would take and whether the resources they
+// target currently exist, without acting on them.
+func previewCleanup(clientset *kubernetes.Clientset, config Config) {
+	actions := cleanupActions(clientset, config)
+	if len(actions) == 0 {
+		fmt.Println("Dry run: the cleanup would not act on any resource.")
+		return
+	}
+	fmt.Println("Dry run: the cleanup would")
+	for _, action := range actions {
+		status := ""
+		if action.exists != nil {
+			if exists, err := action.exists(); err != nil {
+				status = fmt.Sprintf(" (lookup failed: %v)", err)
+			} else if exists {
+				status = " (exists)"
+			} else {
+				status = " (not found)"
+			}
+		}
+		fmt.Printf("  - %s%s\n", action.description, status)
+	}
+}
+
+// shutdown begins on SIGINT: its context is cancelled so that the running monitors stop polling and waiting for input,
+// and the interrupted run waits for the cleanup before the process exits.
+var shutdown = utilities.NewShutdown()
+
+// shutdownCleanupTimeout bounds how long the process waits for the cleanup after SIGINT before exiting anyway.
+var shutdownCleanupTimeout = 2 * time.Minute
+
 // monitorForSigint sets up a listener for SIGINT signals to gracefully terminate the program.
-// Upon receiving a SIGINT signal (e.g., Ctrl+C), it ensures the cleanup of deployments by
-// calling cleanupAndFatal.
-func monitorForSigint(clientset *kubernetes.Clientset, config Config) {
+// Upon receiving a SIGINT signal (e.g., Ctrl+C), it ensures the cleanup of the deployments of the
+// run configurations returned by activeConfigs by calling cleanupAndFatal.
+// A second SIGINT exits right away, without waiting for a cleanup that hangs, e.g. on an unreachable API server.
+func monitorForSigint(clientset kubernetes.Interface, activeConfigs func() []Config) {
 	sigint := make(chan os.Signal, 1)
 	signal.Notify(sigint, syscall.SIGINT)
 
 	go func() {
 			<-sigint
+			go func() {
+					<-sigint
+					log.Fatalf("Received a second SIGINT, exiting without waiting for the cleanup, resources may be left behind")
+			}()
 			errMsg := fmt.Sprintf("Received SIGINT, cleaning up...")
-			cleanupAndFatal(clientset, config, errMsg)
+			cleanupAndFatal(clientset, activeConfigs(), errMsg)
 	}()
 }
 
+// exitCode maps a benchmark error to the process exit code, so that scripts can tell failure causes apart:
+// 2 for invalid configuration, 3 for credential/permission errors, 4 for provisioning or registration timeouts,
+// 5 for pods that never became ready, 6 for instance churn above --max-churn,
+// 7 for more instances than pods with --one-pod-per-node, 8 for fewer Running pods than replicas after readiness,
+// and 1 for anything else.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, bench.ErrInvalidConfig):
+		return 2
+	case errors.Is(err, bench.ErrCredentials):
+		return 3
+	case errors.Is(err, bench.ErrProvisioningTimeout), errors.Is(err, bench.ErrRegistrationTimeout):
+		return 4
+	case errors.Is(err, bench.ErrSchedulingFailed):
+		return 5
+	case errors.Is(err, bench.ErrChurnExceeded):
+		return 6
+	case errors.Is(err, bench.ErrUnexpectedNodeCount):
+		return 7
+	case errors.Is(err, bench.ErrIncompleteScaleUp):
+		return 8
+	default:
+		return 1
+	}
+}
+
 // Command k8s-autoscaler-benchmarker orchestrates the setup, execution, and teardown
 // of a Kubernetes deployment to benchmark either Cluster Autoscaler or Karpenter autoscaling functionalities.
 // It determines the autoscaler type based on input flags, creates or uses an existing deployment, scales it,
@@ -241,13 +2021,276 @@ func monitorForSigint(clientset *kubernetes.Clientset, config Config) {
 // It concludes by scaling down the deployment and monitoring node deregistration and EC2 instance termination,
 // before printing out a summary of the benchmark results to stdout.
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		if err := runDiff(os.Args[2:]); err != nil {
+			log.Printf("%v", err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+
 	config := parseFlags()
 
-	clientset, ec2Svc := initializeClients(config.kubeconfigPath, config.awsProfile)
+	clientset, ec2Svc, extraRegionEC2, stsSvc := initializeClients(config.kubeconfigPath, config.awsProfile, config.awsEndpoint, splitList(config.regions), config.fargate)
+	config.extraRegionEC2 = extraRegionEC2
 
-	monitorForSigint(clientset, config)
+	if config.serveAddr != "" {
+		log.Fatal(serve(config.serveAddr, clientset, ec2Svc, stsSvc, config))
+	}
 
-	labelSelector, tagKey, tagValue := determineAutoscalerType(config, clientset)
+	config.launchHistory = bench.NewLaunchHistory()
+	runConfigs, err := splitTargets(config)
+	if err != nil {
+		fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+		log.Printf("%v", err)
+		os.Exit(exitCode(err))
+	}
+	var phaseWeights map[string]float64
+	if config.phaseWeights != "" {
+		if phaseWeights, err = bench.ParsePhaseWeights(config.phaseWeights); err != nil {
+			err = bench.NewPhaseError("configuration", fmt.Errorf("Invalid --phase-weights: %v: %w", err, bench.ErrInvalidConfig))
+			fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+			log.Printf("%v", err)
+			os.Exit(exitCode(err))
+		}
+	}
+
+	if config.iterations < 1 || (config.iterations > 1 && (config.htmlOutput != "" || config.reportOutput != "")) {
+		err = bench.NewPhaseError("configuration", fmt.Errorf("--iterations must be at least 1, and cannot be combined with --html-output or --report-output, got %d: %w", config.iterations, bench.ErrInvalidConfig))
+		fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+		log.Printf("%v", err)
+		os.Exit(exitCode(err))
+	}
+	if config.repeatUntilStable && (config.stableWindow < 2 || config.maxRuns < config.stableWindow || config.cvThreshold <= 0 || config.iterations > 1 || config.htmlOutput != "" || config.reportOutput != "") {
+		err = bench.NewPhaseError("configuration", fmt.Errorf("--repeat-until-stable needs a --window of at least 2, --max-runs of at least --window and a positive --cv-threshold, and cannot be combined with --iterations, --html-output or --report-output: %w", bench.ErrInvalidConfig))
+		fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+		log.Printf("%v", err)
+		os.Exit(exitCode(err))
+	}
+	if !slices.Contains(bench.OutputFormats, config.outputFormat) || (config.outputFormat != "text" && config.templateFile != "") {
+		err = bench.NewPhaseError("configuration", fmt.Errorf("--output-format must be one of %s and only text can be combined with --template-file, got %q: %w", strings.Join(bench.OutputFormats, ", "), config.outputFormat, bench.ErrInvalidConfig))
+		fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+		log.Printf("%v", err)
+		os.Exit(exitCode(err))
+	}
+	if config.reportOutput == "-" && config.outputFormat != "json" {
+		err = bench.NewPhaseError("configuration", fmt.Errorf("--report-output - writes the report to standard output and requires --output-format json, got %q: %w", config.outputFormat, bench.ErrInvalidConfig))
+		fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+		log.Printf("%v", err)
+		os.Exit(exitCode(err))
+	}
+
+	if config.dryRun {
+		for _, runConfig := range runConfigs {
+			previewCleanup(clientset, runConfig)
+		}
+		return
+	}
+	if config.cleanupOnly {
+		if err := runCleanupOnly(clientset, ec2Svc, runConfigs); err != nil {
+			fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+			log.Printf("%v", err)
+			os.Exit(exitCode(err))
+		}
+		progressf("Cleanup complete.\n")
+		return
+	}
+
+	monitorForSigint(clientset, func() []Config { return runConfigs })
+
+	var csvReporters []*bench.CSVReporter
+	if config.outputFormat == "csv" {
+		csvReporters = append(csvReporters, bench.NewCSVReporter(os.Stdout, true))
+	}
+	if config.csvOutput != "" {
+		reporter, closeFile, err := openCSVOutput(config.csvOutput)
+		if err != nil {
+			fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+			log.Printf("%v", err)
+			os.Exit(exitCode(err))
+		}
+		defer closeFile()
+		csvReporters = append(csvReporters, reporter)
+	}
+	sheetsExporter := openSheetsExporter(config)
+	// report prints a result as soon as its run completed, so long sessions with several iterations give feedback early.
+	// A result that cannot be printed fails the command once all runs finished.
+	var outputErr error
+	report := func(result *bench.BenchmarkResult) {
+		recordAWSIdentity(stsSvc, ec2Svc, result)
+		if err := printResult(config, result, len(runConfigs) > 1); err != nil {
+			log.Printf("%v", err)
+			outputErr = err
+		}
+		for _, reporter := range csvReporters {
+			if err := reporter.Write(result); err != nil {
+				log.Printf("%v", err)
+			}
+		}
+		if sheetsExporter != nil {
+			if err := sheetsExporter.Append(result); err != nil {
+				log.Printf("Warning: result not exported to Google Sheets: %v", err)
+			}
+		}
+	}
+
+	var results []*bench.BenchmarkResult
+	if config.repeatUntilStable {
+		results, err = runUntilStable(clientset, ec2Svc, runConfigs, config.stableWindow, config.maxRuns, config.cvThreshold, report)
+	} else if config.iterations > 1 {
+		results, err = runIterations(clientset, ec2Svc, runConfigs, config.iterations, report)
+	} else {
+		results, err = runBenchmarks(clientset, ec2Svc, runConfigs)
+		for _, result := range results {
+			report(result)
+		}
+	}
+	// On SIGINT the runs may return early, and the SIGINT handler exits once the cleanup finished or timed out: block
+	// until then rather than printing partial results or exiting underneath the cleanup.
+	if shutdown.Context().Err() != nil {
+		select {}
+	}
+	err = errors.Join(err, outputErr)
+
+	// A report written to standard output takes the place of the results there, so stdout holds one JSON document.
+	if config.outputFormat == "json" && config.reportOutput != "-" {
+		if err := bench.WriteResults(os.Stdout, config.outputFormat, results); err != nil {
+			log.Printf("%v", err)
+		}
+	} else if config.outputFormat == "text" && config.iterations > 1 {
+		utilities.PrintAggregateSummary(results, config.iterations)
+	} else if config.outputFormat == "text" && len(runConfigs) > 1 {
+		utilities.PrintComparison(results)
+		if phaseWeights != nil {
+			utilities.PrintWeightedComparison(results, phaseWeights)
+		}
+		if config.compareInstanceFamilies != "" {
+			utilities.PrintComparisonDeltas(results)
+		}
+	}
+	writePromTextfile(config, results)
+	pushMetrics(config, results)
+
+	for _, result := range results {
+		fmt.Fprintln(progress, utilities.FormatLogfmt(result))
+	}
+	if err != nil {
+		fmt.Fprintln(progress, utilities.FormatLogfmtFailure(err))
+		log.Printf("%v", err)
+		log.Printf("Exiting...")
+		os.Exit(exitCode(err))
+	}
+}
+
+// writePromTextfile writes the results to the --prom-textfile, if one was given.
+func writePromTextfile(config Config, results []*bench.BenchmarkResult) {
+	if config.promTextfile == "" {
+		return
+	}
+	if err := utilities.WritePrometheusTextfile(config.promTextfile, results, time.Now()); err != nil {
+		log.Printf("%v", err)
+	} else {
+		progressf("Prometheus metrics written to %s\n", config.promTextfile)
+	}
+}
+
+// openCSVOutput opens the --csv-output file for appending and returns a CSVReporter writing to it, which writes the
+// header only when the file is new or empty and continues the run numbering of the rows already in it, and a function
+// closing the file.
+func openCSVOutput(path string) (*bench.CSVReporter, func(), error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to open CSV output: %w", err)
+	}
+	reporter, err := bench.AppendCSVReporter(file, file)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("Failed to open CSV output %s: %w", path, err)
+	}
+	return reporter, func() { file.Close() }, nil
+}
+
+// openSheetsExporter returns the exporter appending results to the --google-sheet-id, or nil if none was given or the
+// credentials are unusable. Failures only log a warning since the results are printed anyway.
+func openSheetsExporter(config Config) *utilities.SheetsExporter {
+	if config.googleSheetID == "" {
+		return nil
+	}
+	if config.googleCredentials == "" {
+		log.Printf("Warning: results will not be exported to Google Sheets: --google-sheet-id needs --google-credentials or $GOOGLE_APPLICATION_CREDENTIALS")
+		return nil
+	}
+	exporter, err := utilities.NewSheetsExporter(config.googleSheetID, config.googleSheetRange, config.googleCredentials)
+	if err != nil {
+		log.Printf("Warning: results will not be exported to Google Sheets: %v", err)
+		return nil
+	}
+	return exporter
+}
+
+// pushMetrics pushes the results to the --pushgateway-url, if one was given. A failed push only logs an error since
+// the results were already printed.
+func pushMetrics(config Config, results []*bench.BenchmarkResult) {
+	if config.pushgatewayURL == "" {
+		return
+	}
+	if err := utilities.PushPrometheusMetrics(config.pushgatewayURL, "k8s_autoscaler_benchmarker", results, time.Now()); err != nil {
+		log.Printf("%v", err)
+	} else {
+		progressf("Prometheus metrics pushed to %s\n", config.pushgatewayURL)
+	}
+}
+
+// printResult prints the summary of a benchmark result (or renders the --template-file) and writes the --html-output timeline.
+// The summary is left out for a machine-readable --output-format, whose results are written together once all runs finished.
+// When several targets were benchmarked, the timeline file name is suffixed with the result's target.
+// It returns an error if the --template-file cannot be rendered, since the result is then not printed at all; failures
+// to write the timeline or report only log an error.
+func printResult(config Config, result *bench.BenchmarkResult, multipleTargets bool) error {
+	var templateErr error
+	if config.templateFile != "" {
+		if err := utilities.RenderTemplate(os.Stdout, config.templateFile, result); err != nil {
+			templateErr = bench.NewPhaseError("result output", err)
+		}
+	} else if config.outputFormat == "text" && config.fargate {
+		utilities.PrintFargateSummary(result)
+	} else if config.outputFormat == "text" {
+		utilities.PrintSummary(result)
+	}
+
+	if config.htmlOutput != "" {
+		path := utilities.TargetOutputPath(config.htmlOutput, result.Target, multipleTargets)
+		if err := utilities.WriteHTMLTimeline(path, result, config.fargate); err != nil {
+			log.Printf("%v", err)
+		} else {
+			progressf("HTML timeline written to %s\n", path)
+		}
+	}
+
+	if config.reportOutput != "" {
+		path := utilities.TargetOutputPath(config.reportOutput, result.Target, multipleTargets)
+		if err := bench.SaveBenchmarkReport(path, result.AutoscalerType, reportConfig(config), result); err != nil {
+			log.Printf("%v", err)
+		} else if path != "-" {
+			progressf("Benchmark report written to %s\n", path)
+		}
+	}
+	return templateErr
+}
 
-	executeBenchmark(clientset, ec2Svc, config, labelSelector, tagKey, tagValue)
+// reportConfig returns the flag values a run was configured with for its JSON report. The flags that differ per run,
+// i.e. the target and workload of several targets or a serve mode request, are taken from the run's configuration.
+func reportConfig(config Config) map[string]string {
+	values := utilities.FlagValues(flag.CommandLine)
+	values["nodepool"] = config.nodepoolTag
+	values["node-group"] = config.nodeGroup
+	values["deployment"] = config.deploymentName
+	values["namespace"] = config.namespace
+	values["replicas"] = strconv.Itoa(config.replicas)
+	values["container-name"] = config.containerName
+	values["container-image"] = config.containerImage
+	values["cpu-request"] = config.cpuRequest
+	values["metadata"] = config.metadata.String()
+	values["trace-id"] = config.traceID
+	return values
 }
@@ -0,0 +1,351 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/aws"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/k8s"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/utilities"
+)
+
+// TestShutdownCleansUpCancelledRun simulates a SIGINT while a run waits for its instances: the provisioning monitor
+// must stop, and the cleanup must have deleted the run's deployment and service once the shutdown returned.
+func TestShutdownCleansUpCancelledRun(t *testing.T) {
+	defer func(previous *utilities.Shutdown) { shutdown = previous }(shutdown)
+	shutdown = utilities.NewShutdown()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><reservationSet/></DescribeInstancesResponse>`))
+	}))
+	defer server.Close()
+	sess := session.Must(session.NewSession(&awssdk.Config{
+		Endpoint:    awssdk.String(server.URL),
+		Region:      awssdk.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+
+	config := Config{containerName: "inflate", namespace: "default", createService: true}
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "inflate", Namespace: "default"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "inflate", Namespace: "default"}},
+	)
+
+	monitored := make(chan error, 1)
+	go func() {
+		_, _, err := aws.MonitorInstanceProvisioning(shutdown.Context(), clientset, []*ec2.EC2{ec2.New(sess)}, "karpenter.sh/nodepool", "default", "", "default", time.Now(), 1, 0, time.Minute, nil, nil)
+		monitored <- err
+	}()
+
+	shutdown.Begin(func() { cleanupRun(clientset, config) }, time.Second)
+	select {
+	case err := <-monitored:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("MonitorInstanceProvisioning() returned %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("MonitorInstanceProvisioning() kept polling after the shutdown began")
+	}
+
+	for _, kind := range []string{"Deployment", "Service"} {
+		if exists, err := k8s.ResourceExists(clientset, kind, "inflate", "default"); err != nil || exists {
+			t.Errorf("%s still exists after the cleanup: %v, %v", kind, exists, err)
+		}
+	}
+}
+
+// TestShutdownGuardedManifestCreation simulates a SIGINT while a run is creating the deployment of its
+// --deployment-manifest: the cleanup must wait for the creation and then delete the deployment through the run's
+// cleanup actions, and no deployment may be created once the shutdown began.
+func TestShutdownGuardedManifestCreation(t *testing.T) {
+	defer func(previous *utilities.Shutdown) { shutdown = previous }(shutdown)
+	shutdown = utilities.NewShutdown()
+
+	manifest := filepath.Join(t.TempDir(), "deployment.yaml")
+	if err := os.WriteFile(manifest, []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: inflate\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	deployment, err := k8s.LoadDeploymentManifest(manifest)
+	if err != nil {
+		t.Fatalf("LoadDeploymentManifest() returned error: %v", err)
+	}
+	config := Config{deploymentManifest: manifest, namespace: "default"}
+	clientset := fake.NewSimpleClientset()
+
+	creating := make(chan struct{})
+	release := make(chan struct{})
+	created := make(chan error)
+	go func() {
+		created <- shutdown.Guard(func() error {
+			close(creating)
+			<-release
+			return k8s.ApplyDeploymentManifest(clientset, deployment, config.namespace, 3)
+		})
+	}()
+	<-creating
+
+	finished := make(chan struct{})
+	go func() {
+		shutdown.Begin(func() { cleanupRun(clientset, config) }, time.Second)
+		close(finished)
+	}()
+	<-shutdown.Context().Done()
+	close(release)
+	if err := <-created; err != nil {
+		t.Fatalf("Guard() returned error: %v", err)
+	}
+	<-finished
+
+	if exists, err := k8s.ResourceExists(clientset, "Deployment", "inflate", "default"); err != nil || exists {
+		t.Errorf("Deployment still exists after the cleanup: %v, %v", exists, err)
+	}
+	if err := shutdown.Guard(func() error {
+		return k8s.ApplyDeploymentManifest(clientset, deployment, config.namespace, 3)
+	}); !errors.Is(err, utilities.ErrShuttingDown) {
+		t.Errorf("Guard() after the shutdown = %v, want ErrShuttingDown", err)
+	}
+}
+
+// TestPrepareExistingDeployment checks that a deployment left over at the generated name fails the run by default,
+// is deleted with --replace, and is scaled to 0 and adopted with --reuse-existing.
+func TestPrepareExistingDeployment(t *testing.T) {
+	leftover := func() *appsv1.Deployment {
+		replicas := int32(5)
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "inflate", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		}
+	}
+	config := Config{containerName: "inflate", namespace: "default"}
+
+	if _, err := prepareExistingDeployment(fake.NewSimpleClientset(leftover()), config, false, "karpenter.sh/nodepool=default"); !errors.Is(err, bench.ErrInvalidConfig) {
+		t.Errorf("prepareExistingDeployment() of a leftover deployment returned %v, want ErrInvalidConfig", err)
+	}
+
+	replace := config
+	replace.replaceExisting = true
+	clientset := fake.NewSimpleClientset(leftover())
+	if reuse, err := prepareExistingDeployment(clientset, replace, false, "karpenter.sh/nodepool=default"); err != nil || reuse {
+		t.Errorf("prepareExistingDeployment() with --replace = %v, %v, want false and no error", reuse, err)
+	}
+	if exists, _ := k8s.ResourceExists(clientset, "Deployment", "inflate", "default"); exists {
+		t.Errorf("prepareExistingDeployment() with --replace left the deployment in place")
+	}
+
+	adopt := config
+	adopt.reuseExisting = true
+	clientset = fake.NewSimpleClientset(leftover())
+	if reuse, err := prepareExistingDeployment(clientset, adopt, false, "karpenter.sh/nodepool=default"); err != nil || !reuse {
+		t.Errorf("prepareExistingDeployment() with --reuse-existing = %v, %v, want true and no error", reuse, err)
+	}
+	deployment, err := clientset.AppsV1().Deployments("default").Get(context.Background(), "inflate", metav1.GetOptions{})
+	if err != nil || *deployment.Spec.Replicas != 0 {
+		t.Errorf("prepareExistingDeployment() with --reuse-existing did not scale the deployment to 0: %v, %v", deployment, err)
+	}
+}
+
+// TestRunCleanupOnly checks that --cleanup-only deletes the leftover deployment and service and can be repeated, that
+// --cleanup-wait requires the run ID, and that the wait lists only the run's instances and gives up at its timeout.
+func TestRunCleanupOnly(t *testing.T) {
+	var filters []string
+	running := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		filters = append(filters, r.PostForm.Get("Filter.1.Name")+"="+r.PostForm.Get("Filter.1.Value.1"))
+		instances := ""
+		if running {
+			instances = `<item><instancesSet><item><instanceId>i-left</instanceId><launchTime>2024-04-01T12:00:00.000Z</launchTime><privateDnsName>ip-10-0-0-1</privateDnsName><instanceState><name>running</name></instanceState></item></instancesSet></item>`
+		}
+		w.Write([]byte(`<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><reservationSet>` + instances + `</reservationSet></DescribeInstancesResponse>`))
+	}))
+	defer server.Close()
+	sess := session.Must(session.NewSession(&awssdk.Config{
+		Endpoint:    awssdk.String(server.URL),
+		Region:      awssdk.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	ec2Svc := ec2.New(sess)
+
+	config := Config{containerName: "inflate", namespace: "default", createService: true, quiet: true}
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "inflate", Namespace: "default"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "inflate", Namespace: "default"}},
+	)
+	for i := 0; i < 2; i++ {
+		if err := runCleanupOnly(clientset, ec2Svc, []Config{config}); err != nil {
+			t.Fatalf("runCleanupOnly() attempt %d returned error: %v", i+1, err)
+		}
+	}
+	for _, kind := range []string{"Deployment", "Service"} {
+		if exists, err := k8s.ResourceExists(clientset, kind, "inflate", "default"); err != nil || exists {
+			t.Errorf("%s still exists after the cleanup: %v, %v", kind, exists, err)
+		}
+	}
+
+	wait := config
+	wait.cleanupWait, wait.cleanupWaitTimeout = true, time.Second
+	if err := runCleanupOnly(clientset, ec2Svc, []Config{wait}); !errors.Is(err, bench.ErrInvalidConfig) {
+		t.Errorf("runCleanupOnly() with --cleanup-wait and no run ID returned %v, want ErrInvalidConfig", err)
+	}
+
+	wait.cleanupRunID = "run-1"
+	if err := runCleanupOnly(clientset, ec2Svc, []Config{wait}); err != nil {
+		t.Errorf("runCleanupOnly() with --cleanup-wait and no instances left returned error: %v", err)
+	}
+	if len(filters) == 0 || filters[0] != "tag:kab-run-id=run-1" {
+		t.Errorf("runCleanupOnly() listed the instances with filters %v, want tag:kab-run-id=run-1", filters)
+	}
+
+	running = true
+	if err := runCleanupOnly(clientset, ec2Svc, []Config{wait}); err == nil {
+		t.Errorf("runCleanupOnly() with an instance still running returned no error, want a timeout")
+	}
+}
+
+// TestConfirmsOneInstancePerPod checks that single-pod runs are confirmed to launch one instance like
+// --one-pod-per-node, unless more instances are expected or the replicas ramp up.
+func TestConfirmsOneInstancePerPod(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config Config
+		want   bool
+	}{
+		{"one pod", Config{replicas: 1}, true},
+		{"one pod, one expected instance", Config{replicas: 1, expectedInstances: 1}, true},
+		{"one pod, more expected instances", Config{replicas: 1, expectedInstances: 2}, false},
+		{"one pod per tenant", Config{replicas: 1, tenants: 3}, false},
+		{"ramp", Config{replicas: 1, exponentialRamp: "1,2,4"}, false},
+		{"several pods", Config{replicas: 5}, false},
+		{"one pod per node", Config{replicas: 5, onePodPerNode: true}, true},
+	}
+	for _, tc := range testCases {
+		if got := confirmsOneInstancePerPod(tc.config); got != tc.want {
+			t.Errorf("confirmsOneInstancePerPod() for %s = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestSettledInstanceCount checks that the instance count of the first ramp step includes an instance listed only after
+// a few polls, once the count stays unchanged.
+func TestSettledInstanceCount(t *testing.T) {
+	defer func(settle time.Duration) { rampInstanceSettle = settle }(rampInstanceSettle)
+	rampInstanceSettle = 2 * time.Second
+
+	instance := func(id string) string {
+		return `<item><instanceId>` + id + `</instanceId><launchTime>2024-04-01T12:00:00.000Z</launchTime><instanceState><name>running</name></instanceState></item>`
+	}
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		instances := instance("i-1")
+		if polls >= 3 {
+			instances += instance("i-2")
+		}
+		w.Write([]byte(`<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><reservationSet><item><instancesSet>` + instances + `</instancesSet></item></reservationSet></DescribeInstancesResponse>`))
+	}))
+	defer server.Close()
+	sess := session.Must(session.NewSession(&awssdk.Config{
+		Endpoint:    awssdk.String(server.URL),
+		Region:      awssdk.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+
+	count, err := settledInstanceCount(ec2.New(sess), Config{}, "kab-run-id", "run-1")
+	if err != nil {
+		t.Fatalf("settledInstanceCount() returned error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("settledInstanceCount() = %d, want 2", count)
+	}
+}
+
+// TestTagRunInstances checks that only the instances the run observed are tagged with its run ID, and none that
+// another run already claimed.
+func TestTagRunInstances(t *testing.T) {
+	instance := func(id, runID string) string {
+		tags := ""
+		if runID != "" {
+			tags = `<tagSet><item><key>kab-run-id</key><value>` + runID + `</value></item></tagSet>`
+		}
+		return `<item><instanceId>` + id + `</instanceId><launchTime>2024-04-01T12:00:00.000Z</launchTime><instanceState><name>running</name></instanceState>` + tags + `</item>`
+	}
+	var tagged []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("Action") == "CreateTags" {
+			for i := 1; r.PostForm.Get(fmt.Sprintf("ResourceId.%d", i)) != ""; i++ {
+				tagged = append(tagged, r.PostForm.Get(fmt.Sprintf("ResourceId.%d", i)))
+			}
+			w.Write([]byte(`<CreateTagsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><return>true</return></CreateTagsResponse>`))
+			return
+		}
+		instances := instance("i-mine", "") + instance("i-again", "run-1") + instance("i-unseen", "") + instance("i-claimed", "run-2")
+		w.Write([]byte(`<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><reservationSet><item><instancesSet>` + instances + `</instancesSet></item></reservationSet></DescribeInstancesResponse>`))
+	}))
+	defer server.Close()
+	sess := session.Must(session.NewSession(&awssdk.Config{
+		Endpoint:    awssdk.String(server.URL),
+		Region:      awssdk.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+
+	observed := []*ec2.Instance{
+		{InstanceId: awssdk.String("i-mine")},
+		{InstanceId: awssdk.String("i-again")},
+		{InstanceId: awssdk.String("i-claimed")},
+	}
+	ids, err := tagRunInstances(ec2.New(sess), Config{runID: "run-1"}, "karpenter.sh/nodepool", "default", observed)
+	if err != nil {
+		t.Fatalf("tagRunInstances() returned error: %v", err)
+	}
+	want := []string{"i-mine", "i-again"}
+	if !reflect.DeepEqual(ids, want) || !reflect.DeepEqual(tagged, want) {
+		t.Errorf("tagRunInstances() = %v and tagged %v, want %v", ids, tagged, want)
+	}
+}
+
+// TestPrintResultReport checks that printResult writes the report of a run to the target's path, with the run's own
+// target and workload in its configuration, so that it loads back for the diff command.
+func TestPrintResultReport(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{reportOutput: filepath.Join(dir, "report.json"), outputFormat: "json", nodepoolTag: "gpu", replicas: 6, namespace: "bench"}
+	result := &bench.BenchmarkResult{AutoscalerType: "Karpenter", Target: "gpu", InstanceProvisioningTime: 12 * time.Second}
+	if err := printResult(config, result, true); err != nil {
+		t.Fatalf("printResult() returned error: %v", err)
+	}
+
+	report, err := bench.LoadBenchmarkReport(filepath.Join(dir, "report-gpu.json"))
+	if err != nil {
+		t.Fatalf("LoadBenchmarkReport() returned error: %v", err)
+	}
+	if report.AutoscalerType != "Karpenter" || !reflect.DeepEqual(report.Result, result) {
+		t.Errorf("LoadBenchmarkReport() = %+v, want the Karpenter report of %+v", report, result)
+	}
+	for key, want := range map[string]string{"nodepool": "gpu", "replicas": "6", "namespace": "bench"} {
+		if got := report.Config[key]; got != want {
+			t.Errorf("Report config %s = %q, want %q", key, got, want)
+		}
+	}
+}
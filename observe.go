@@ -0,0 +1,121 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/aws"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/k8s"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/logging"
+)
+
+// executeObservation measures a scale event triggered outside of the benchmark for --observe-only. No workload is
+// created or scaled: the instances launched since the observation started are awaited, then their registration as
+// nodes, and finally their deregistration and termination once the external scale-down happens. Nodes that already
+// matched the label selector when the observation started are treated as a baseline and not counted.
+func executeObservation(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, config Config, autoscalerType, labelSelector, tagKey, tagValue string) (*bench.BenchmarkResult, error) {
+	if config.fargate || config.deploymentName != "" || config.deploymentManifest != "" || config.baselineDeployment != "" || config.exponentialRamp != "" || config.tenants > 1 || config.chaosTerminateOne || config.precreate || config.phaseRetries > 0 || config.tagInstances {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--observe-only creates no workload and cannot be combined with --fargate, --deployment, --deployment-manifest, --baseline-deployment, --exponential-ramp, --tenants, --chaos-terminate-one, --precreate, --phase-retries or --tag-instances: %w", bench.ErrInvalidConfig))
+	}
+	if config.provisioningTimeout <= 0 {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("--provisioning-timeout must be positive, got %v: %w", config.provisioningTimeout, bench.ErrInvalidConfig))
+	}
+	readiness, err := k8s.ParseNodeReadiness(config.nodeReadyConditions, config.nodeAbsentTaints)
+	if err != nil {
+		return nil, bench.NewPhaseError("configuration", fmt.Errorf("Invalid --node-ready-conditions or --node-absent-taints: %v: %w", err, bench.ErrInvalidConfig))
+	}
+	config.nodeReadiness = readiness
+
+	result := bench.BenchmarkResult{AutoscalerType: autoscalerType, Target: tagValue, Metadata: config.metadata, TraceID: config.traceID, Seed: config.seed}
+	logger := logging.ForRun(autoscalerType, tagValue)
+	config.startTime = time.Now()
+	config.runStart, config.k8sRequestsAtStart = config.startTime, k8sRequests.Requests()
+	ec2Svc, describeInstancesCalls := countDescribeInstancesCalls(ec2Svc, &config)
+
+	baselineNodes, err := k8s.CountNodes(clientset, labelSelector)
+	if err != nil {
+		return nil, bench.NewPhaseError("node baseline", err)
+	}
+	progressf("Observing node activity since %s (%d nodes already registered)...\n", config.startTime.Format(time.RFC3339), baselineNodes)
+
+	nodeCounter := k8s.StartNodeCountSampler(clientset, labelSelector, config.startTime)
+	defer nodeCounter.Stop()
+
+	instanceProvisioningTime, instances, err := monitorProvisioning(clientset, ec2Svc, config, autoscalerType, tagKey, tagValue, nil)
+	if err != nil {
+		return nil, bench.NewPhaseError("instance provisioning", err)
+	}
+	launchedInstances := len(instances)
+	instanceTracker := aws.StartInstanceTracker(instanceClients(ec2Svc, config), tagKey, tagValue, config.startTime, instances)
+	defer instanceTracker.Stop(nil)
+	result.InstanceProvisioningTime = instanceProvisioningTime
+	logging.Phase(logger, "instance provisioning", instanceProvisioningTime)
+	result.InstanceCount = launchedInstances
+	result.LaunchTemplates = aws.CountLaunchTemplates(instances)
+	result.InstanceTypes = aws.CountInstanceTypes(instances)
+	result.AvailabilityZones = aws.CountAvailabilityZones(instances)
+	result.SpotInstances, result.OnDemandInstances = aws.CountLifecycles(instances)
+	result.InstanceLaunches = config.launchHistory.Classify(aws.InstanceLaunches(instances, config.startTime))
+
+	instanceRegistrationTime, readyNodes, err := k8s.MonitorInstanceRegistration(clientset, labelSelector, baselineNodes+launchedInstances, config.nodeReadiness)
+	if err != nil {
+		return nil, bench.NewPhaseError("instance registration", err)
+	}
+	result.InstanceRegistrationTime = instanceRegistrationTime
+	logging.Phase(logger, "instance registration", instanceRegistrationTime)
+	result.RegisteredNodes = readyNodes - baselineNodes
+	instanceTracker.Stop(nil)
+	recordRegistrationLag(clientset, &result, labelSelector, instanceTracker.RunningTimes(), launchedInstances)
+
+	progressf("Waiting for the observed nodes to be scaled down...\n")
+	var terminationStats k8s.TerminationStats
+	deregChan := make(chan time.Duration)
+	termChan := make(chan time.Duration)
+	errChan := make(chan error, 2)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		k8s.MonitorNodeDeregistration(clientset, labelSelector, nil, baselineNodes, verbosity(config), deregChan, errChan)
+	}()
+	go func() {
+		defer wg.Done()
+		k8s.MonitorNodeTermination(instanceClients(ec2Svc, config), tagKey, tagValue, config.startTime, aws.InstanceIDs(instances), config.maxTransientErrors, verbosity(config), nil, &terminationStats, termChan, errChan)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(errChan)
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errChan:
+			return nil, bench.NewPhaseError("node termination and deregistration", err)
+		case duration := <-deregChan:
+			result.NodeDeregistrationTime = duration
+			logging.Phase(logger, "node deregistration", duration)
+		case duration := <-termChan:
+			result.InstanceTerminationTime = duration
+			logging.Phase(logger, "instance termination", duration)
+		}
+	}
+	result.TransientTerminationErrors = terminationStats.TransientErrors
+	result.TerminationSeries = terminationStats.Series
+	result.NodeCountSeries = nodeCounter.Stop()
+	recordCostEstimate(config, &result)
+
+	result.DescribeInstancesCalls = describeInstancesCalls.Load()
+	recordK8sRequests(config, &result)
+	result.Anomalies = result.DetectAnomalies()
+	return &result, nil
+}
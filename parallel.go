@@ -0,0 +1,156 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+)
+
+// splitList splits a comma-separated flag value into its trimmed, non-empty elements.
+func splitList(value string) []string {
+	var elements []string
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// splitTargets returns one run configuration per node pool and node group given as comma-separated lists to --nodepool
+// and --node-group. A single target is returned unchanged. With several targets, each run gets an isolated generated
+// workload named after its target and pinned to its target's nodes, so concurrent runs do not share pods or nodes.
+func splitTargets(config Config) ([]Config, error) {
+	nodepools, nodeGroups := splitList(config.nodepoolTag), splitList(config.nodeGroup)
+	if config.compareInstanceFamilies != "" {
+		return splitInstanceFamilies(config, nodepools, nodeGroups)
+	}
+	if len(nodepools)+len(nodeGroups) <= 1 {
+		if config.parallel {
+			return nil, fmt.Errorf("--parallel requires several node pools or node groups: %w", bench.ErrInvalidConfig)
+		}
+		if config.phaseWeights != "" {
+			return nil, fmt.Errorf("--phase-weights requires several node pools or node groups to compare: %w", bench.ErrInvalidConfig)
+		}
+		return []Config{config}, nil
+	}
+	if config.deploymentName != "" || config.deploymentManifest != "" || config.fargate {
+		return nil, fmt.Errorf("Benchmarking several node pools or node groups only supports the generated workload and cannot be combined with --deployment, --deployment-manifest or --fargate: %w", bench.ErrInvalidConfig)
+	}
+
+	var configs []Config
+	addTarget := func(nodepool, nodeGroup, labelKey, target string) {
+		runConfig := config
+		runConfig.nodepoolTag, runConfig.nodeGroup = nodepool, nodeGroup
+		runConfig.containerName = fmt.Sprintf("%s-%s", config.containerName, target)
+		runConfig.nodeSelectorKey, runConfig.nodeSelectorValue = labelKey, target
+		configs = append(configs, runConfig)
+	}
+	for _, nodepool := range nodepools {
+		addTarget(nodepool, "", "karpenter.sh/nodepool", nodepool)
+	}
+	for _, nodeGroup := range nodeGroups {
+		addTarget("", nodeGroup, "eks.amazonaws.com/nodegroup", nodeGroup)
+	}
+	return configs, nil
+}
+
+// splitInstanceFamilies returns one run configuration per instance family given to --compare-instance-families. Each run
+// benchmarks the single node pool with a generated workload named after its family and constrained to it. The runs
+// share the node pool's instances, so they always run one after another.
+func splitInstanceFamilies(config Config, nodepools, nodeGroups []string) ([]Config, error) {
+	families := splitList(config.compareInstanceFamilies)
+	if len(families) < 2 {
+		return nil, fmt.Errorf("--compare-instance-families requires at least two instance families: %w", bench.ErrInvalidConfig)
+	}
+	if len(nodepools) != 1 || len(nodeGroups) > 0 {
+		return nil, fmt.Errorf("--compare-instance-families constrains the Karpenter instance family and requires a single --nodepool: %w", bench.ErrInvalidConfig)
+	}
+	if config.deploymentName != "" || config.deploymentManifest != "" || config.fargate || config.parallel {
+		return nil, fmt.Errorf("--compare-instance-families only supports the generated workload and cannot be combined with --deployment, --deployment-manifest, --fargate or --parallel: %w", bench.ErrInvalidConfig)
+	}
+
+	var configs []Config
+	for _, family := range families {
+		runConfig := config
+		runConfig.containerName = fmt.Sprintf("%s-%s", config.containerName, family)
+		runConfig.instanceFamily = family
+		configs = append(configs, runConfig)
+	}
+	return configs, nil
+}
+
+// runBenchmarks benchmarks every run configuration, concurrently when --parallel is set and one after another otherwise.
+// It returns the results of the successful runs in configuration order, and the errors of the failed runs joined together.
+func runBenchmarks(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, configs []Config) ([]*bench.BenchmarkResult, error) {
+	results, errs, err := runTargets(clientset, ec2Svc, configs)
+	if err != nil {
+		return nil, err
+	}
+	var succeeded []*bench.BenchmarkResult
+	for _, result := range results {
+		if result != nil {
+			succeeded = append(succeeded, result)
+		}
+	}
+	return succeeded, errors.Join(errs...)
+}
+
+// runTargets benchmarks every run configuration like runBenchmarks, but returns the result and the error of each in
+// configuration order, so a result that came with an error, e.g. of a failed churn check, can be told from a
+// successful one. The error it returns itself is that of a target that could not be resolved, before any run started.
+func runTargets(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, configs []Config) ([]*bench.BenchmarkResult, []error, error) {
+	type target struct {
+		autoscalerType, labelSelector, tagKey, tagValue string
+	}
+	targets := make([]target, len(configs))
+	for i, config := range configs {
+		autoscalerType, labelSelector, tagKey, tagValue, err := determineAutoscalerType(config, clientset)
+		if err != nil {
+			return nil, nil, bench.NewPhaseError("configuration", err)
+		}
+		checkScaleUpTriggered(clientset, config, autoscalerType)
+		targets[i] = target{autoscalerType, labelSelector, tagKey, tagValue}
+	}
+
+	results := make([]*bench.BenchmarkResult, len(configs))
+	errs := make([]error, len(configs))
+	run := func(i int) {
+		t := targets[i]
+		results[i], errs[i] = executeWithRetries(clientset, ec2Svc, configs[i], t.autoscalerType, t.labelSelector, t.tagKey, t.tagValue)
+		if errs[i] != nil && len(configs) > 1 {
+			target := t.tagValue
+			if configs[i].instanceFamily != "" {
+				target = fmt.Sprintf("%s-%s", target, configs[i].instanceFamily)
+			}
+			errs[i] = fmt.Errorf("%s: %w", target, errs[i])
+		}
+	}
+
+	if len(configs) > 1 && configs[0].parallel {
+		var wg sync.WaitGroup
+		for i := range configs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				run(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range configs {
+			run(i)
+		}
+	}
+	return results, errs, nil
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/aws"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/k8s"
+)
+
+// rampStepTimeout bounds how long a single ramp step may take to become fully ready.
+const rampStepTimeout = 10 * time.Minute
+
+// rampInstanceSettle is how long the instance count must stay unchanged, once a step's replicas are ready, before the
+// step is closed, so that instances the autoscaler launches late are attributed to the step that caused them.
+var rampInstanceSettle = 10 * time.Second
+
+// executeRampStep scales the deployment to the given replica count and measures the step: the time until the
+// first new EC2 instance launched (if any) and the time until all replicas were ready.
+// knownInstances is the number of instances launched by previous steps, so only instances beyond it count as new.
+// The step is closed once its replicas are ready and the instance count has settled, see rampInstanceSettle.
+func executeRampStep(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, config Config, tagKey, tagValue string, step, replicas, knownInstances int) (bench.RampStep, error) {
+	rampStep := bench.RampStep{Step: step, Replicas: replicas}
+
+	progressf("Ramp step %d: scaling to %d replicas...\n", step, replicas)
+	startTime := time.Now()
+	if err := k8s.ScaleDeployment(clientset, config.deploymentName, config.namespace, replicas); err != nil {
+		return rampStep, fmt.Errorf("Failed to scale deployment for ramp step %d: %w", step, err)
+	}
+
+	ready := false
+	lastCount, lastChange := -1, startTime
+	for {
+		if !ready && time.Since(startTime) >= rampStepTimeout {
+			return rampStep, fmt.Errorf("Timed out waiting for ramp step %d to reach %d ready replicas: %w", step, replicas, bench.ErrSchedulingFailed)
+		}
+
+		pollTime := time.Now()
+		instances, err := aws.GetEC2Instances(instanceClients(ec2Svc, config), "tag:"+tagKey, tagValue, config.startTime)
+		if err != nil {
+			return rampStep, fmt.Errorf("Error retrieving EC2 instances: %w", err)
+		}
+		if len(instances) != lastCount {
+			lastCount, lastChange = len(instances), pollTime
+		}
+		if len(instances) > knownInstances {
+			if rampStep.NewInstances == 0 {
+				rampStep.InstanceProvisioningTime = pollTime.Sub(startTime)
+			}
+			rampStep.NewInstances = len(instances) - knownInstances
+		}
+
+		if !ready {
+			readyReplicas, err := k8s.GetReadyReplicas(clientset, config.deploymentName, config.namespace)
+			if err != nil {
+				return rampStep, err
+			}
+			if readyReplicas >= replicas {
+				ready = true
+				rampStep.PodReadinessTime = pollTime.Sub(startTime)
+			}
+		}
+		// The step timeout also bounds the wait for the count to settle, but an unsettled count does not fail the step.
+		if ready && (pollTime.Sub(lastChange) >= rampInstanceSettle || pollTime.Sub(startTime) >= rampStepTimeout) {
+			progressf("Ramp step %d: %d replicas ready, %d new instances.\n", step, replicas, rampStep.NewInstances)
+			return rampStep, nil
+		}
+
+		time.Sleep(1 * time.Second)
+	}
+}
+
+// settledInstanceCount returns the number of instances launched for the run once the count has stayed unchanged for
+// rampInstanceSettle, or when rampStepTimeout has passed, so that the first ramp step also counts the instances
+// launched after its replicas became ready.
+func settledInstanceCount(ec2Svc *ec2.EC2, config Config, tagKey, tagValue string) (int, error) {
+	startTime := time.Now()
+	lastCount, lastChange := -1, startTime
+	for {
+		pollTime := time.Now()
+		instances, err := aws.GetEC2Instances(instanceClients(ec2Svc, config), "tag:"+tagKey, tagValue, config.startTime)
+		if err != nil {
+			return 0, fmt.Errorf("Error retrieving EC2 instances: %w", err)
+		}
+		if len(instances) != lastCount {
+			lastCount, lastChange = len(instances), pollTime
+		}
+		if pollTime.Sub(lastChange) >= rampInstanceSettle || pollTime.Sub(startTime) >= rampStepTimeout {
+			return lastCount, nil
+		}
+
+		time.Sleep(1 * time.Second)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// random is the source of every random choice of the benchmark, seeded with --seed so that runs with the same seed
+// make identical choices. It is guarded by randomMu since parallel targets draw from it concurrently.
+var (
+	randomMu sync.Mutex
+	random   = rand.New(rand.NewSource(1))
+)
+
+// seedRandom reseeds the source of the benchmark's random choices.
+func seedRandom(seed int64) {
+	randomMu.Lock()
+	defer randomMu.Unlock()
+	random = rand.New(rand.NewSource(seed))
+}
+
+// randomIntn returns a random number in [0, n) from the seeded source.
+func randomIntn(n int) int {
+	randomMu.Lock()
+	defer randomMu.Unlock()
+	return random.Intn(n)
+}
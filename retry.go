@@ -0,0 +1,85 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/k8s"
+)
+
+// executeWithRetries runs executeBenchmark and, when a phase times out (see bench.Retryable), retries the whole run up
+// to --phase-retries times. The failed attempt's resources are torn down by its own deferred cleanup, a user-supplied
+// --deployment is scaled to 0, and its nodes are awaited to be gone before the next attempt so they do not count
+// towards it. The attempts the failed phases needed are recorded on the result.
+func executeWithRetries(clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, config Config, autoscalerType, labelSelector, tagKey, tagValue string) (*bench.BenchmarkResult, error) {
+	if config.phaseRetries <= 0 {
+		return executeBenchmark(clientset, ec2Svc, config, autoscalerType, labelSelector, tagKey, tagValue)
+	}
+
+	baselineNodes, err := k8s.CountNodes(clientset, labelSelector)
+	if err != nil {
+		return nil, bench.NewPhaseError("configuration", err)
+	}
+
+	attempts := make(map[string]int)
+	for attempt := 1; ; attempt++ {
+		result, err := executeBenchmark(clientset, ec2Svc, config, autoscalerType, labelSelector, tagKey, tagValue)
+		var phaseErr *bench.PhaseError
+		if err == nil || result != nil || attempt > config.phaseRetries || !bench.Retryable(err) || !errors.As(err, &phaseErr) {
+			if result != nil && len(attempts) > 0 {
+				result.PhaseAttempts = make(map[string]int)
+				for phase, failures := range attempts {
+					result.PhaseAttempts[phase] = failures + 1
+				}
+			}
+			return result, err
+		}
+
+		attempts[phaseErr.Phase]++
+		log.Printf("Attempt %d of %d failed: %v. Retrying the run after its nodes are gone...", attempt, config.phaseRetries+1, err)
+		if config.deploymentName != "" {
+			if err := k8s.ScaleDeployment(clientset, config.deploymentName, config.namespace, 0); err != nil {
+				return nil, bench.NewPhaseError("retry cleanup", err)
+			}
+		}
+		if err := waitForNodesGone(clientset, labelSelector, baselineNodes); err != nil {
+			return nil, bench.NewPhaseError("retry cleanup", err)
+		}
+	}
+}
+
+// retryCleanupTimeout bounds how long a retry waits for the nodes of the failed attempt to be removed, and
+// retryCleanupPollInterval how often it counts them.
+var (
+	retryCleanupTimeout      = 15 * time.Minute
+	retryCleanupPollInterval = 5 * time.Second
+)
+
+// waitForNodesGone waits until no more than baselineNodes nodes match labelSelector, i.e. until the nodes a failed
+// attempt launched have been removed by the autoscaler. It gives up after retryCleanupTimeout.
+func waitForNodesGone(clientset kubernetes.Interface, labelSelector string, baselineNodes int) error {
+	deadline := time.Now().Add(retryCleanupTimeout)
+	for {
+		nodes, err := k8s.CountNodes(clientset, labelSelector)
+		if err != nil {
+			return fmt.Errorf("Failed to wait for the previous attempt's nodes to be removed: %w", err)
+		}
+		if nodes <= baselineNodes {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d nodes of the previous attempt were not removed within %v", nodes-baselineNodes, retryCleanupTimeout)
+		}
+		time.Sleep(retryCleanupPollInterval)
+	}
+}
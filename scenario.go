@@ -0,0 +1,48 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/utilities"
+)
+
+// applyScenario sets the flags of the named --scenario from the scenarios file. Flags given on the command line take
+// precedence over the scenario, so a scenario can be tweaked for a single run.
+func applyScenario(path, name string) error {
+	settings, err := utilities.LoadScenario(path, name)
+	if err != nil {
+		return err
+	}
+
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	names := make([]string, 0, len(settings))
+	for flagName := range settings {
+		names = append(names, flagName)
+	}
+	sort.Strings(names)
+	for _, flagName := range names {
+		if flagName == "scenario" || flagName == "scenarios-file" {
+			return fmt.Errorf("Scenario %q must not set --%s", name, flagName)
+		}
+		if flag.Lookup(flagName) == nil {
+			return fmt.Errorf("Scenario %q sets the unknown flag --%s", name, flagName)
+		}
+		if explicit[flagName] {
+			continue
+		}
+		for _, value := range settings[flagName] {
+			if err := flag.Set(flagName, value); err != nil {
+				return fmt.Errorf("Scenario %q sets an invalid --%s: %w", name, flagName, err)
+			}
+		}
+	}
+	progressf("Using scenario '%s' from '%s'.\n", name, path)
+	return nil
+}
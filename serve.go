@@ -0,0 +1,223 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/utilities"
+)
+
+// runRequest is the JSON body of a POST /run request. Fields that are omitted keep the value given on the command line.
+type runRequest struct {
+	Nodepool       string            `json:"nodepool"`
+	NodeGroup      string            `json:"node_group"`
+	Deployment     string            `json:"deployment"`
+	Namespace      string            `json:"namespace"`
+	Replicas       int               `json:"replicas"`
+	ContainerName  string            `json:"container_name"`
+	ContainerImage string            `json:"container_image"`
+	CPURequest     string            `json:"cpu_request"`
+	Parallel       *bool             `json:"parallel"`
+	Metadata       map[string]string `json:"metadata"`
+	TraceID        string            `json:"trace_id"`
+}
+
+// apply returns a copy of the base configuration overridden with the fields set in the request.
+func (r runRequest) apply(base Config) Config {
+	config := base
+	if r.Nodepool != "" || r.NodeGroup != "" {
+		config.nodepoolTag, config.nodeGroup = r.Nodepool, r.NodeGroup
+	}
+	if r.Deployment != "" {
+		config.deploymentName = r.Deployment
+	}
+	if r.Namespace != "" {
+		config.namespace = r.Namespace
+	}
+	if r.Replicas > 0 {
+		config.replicas = r.Replicas
+	}
+	if r.ContainerName != "" {
+		config.containerName = r.ContainerName
+	}
+	if r.ContainerImage != "" {
+		config.containerImage = r.ContainerImage
+	}
+	if r.CPURequest != "" {
+		config.cpuRequest = r.CPURequest
+	}
+	if r.Parallel != nil {
+		config.parallel = *r.Parallel
+	}
+	if r.TraceID != "" {
+		config.traceID = r.TraceID
+	}
+	if len(r.Metadata) > 0 {
+		config.metadata = metadataFlag{}
+		for key, value := range base.metadata {
+			config.metadata[key] = value
+		}
+		for key, value := range r.Metadata {
+			config.metadata[key] = value
+		}
+	}
+	return config
+}
+
+// benchmarkServer exposes the results of the last benchmark on /metrics and runs new benchmarks on POST /run.
+// Only one benchmark runs at a time.
+type benchmarkServer struct {
+	clientset *kubernetes.Clientset
+	ec2Svc    *ec2.EC2
+	stsSvc    *sts.STS
+	base      Config
+
+	mu          sync.Mutex
+	running     bool
+	active      []Config
+	lastResults []*bench.BenchmarkResult
+	lastErr     error
+	// completedRuns counts the finished benchmarks, and lastTraceID and lastFinished identify the last one for the
+	// exemplar of the runs counter.
+	completedRuns int
+	lastTraceID   string
+	lastFinished  time.Time
+}
+
+// activeConfigs returns the run configurations of the benchmark in progress, for cleanup on SIGINT.
+func (s *benchmarkServer) activeConfigs() []Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// handleMetrics serves the results of the last completed benchmark in the Prometheus text format, or in the OpenMetrics
+// format with the last run's trace ID as an exemplar if the scraper accepts it.
+func (s *benchmarkServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	results, running, failed := s.lastResults, s.running, s.lastErr != nil
+	runs, traceID, finished := s.completedRuns, s.lastTraceID, s.lastFinished
+	s.mu.Unlock()
+
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", utilities.OpenMetricsContentType)
+	} else {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	}
+	fmt.Fprintf(w, "# HELP k8s_autoscaler_benchmarker_run_in_progress Whether a benchmark is currently running.\n")
+	fmt.Fprintf(w, "# TYPE k8s_autoscaler_benchmarker_run_in_progress gauge\n")
+	fmt.Fprintf(w, "k8s_autoscaler_benchmarker_run_in_progress %d\n", boolToInt(running))
+	fmt.Fprintf(w, "# HELP k8s_autoscaler_benchmarker_last_run_failed Whether the last benchmark failed for at least one target.\n")
+	fmt.Fprintf(w, "# TYPE k8s_autoscaler_benchmarker_last_run_failed gauge\n")
+	fmt.Fprintf(w, "k8s_autoscaler_benchmarker_last_run_failed %d\n", boolToInt(failed))
+	if err := utilities.WriteRunsCounter(w, runs, traceID, finished, openMetrics); err != nil {
+		log.Printf("Failed to write metrics: %v", err)
+	}
+	if err := utilities.WritePrometheusMetrics(w, results); err != nil {
+		log.Printf("Failed to write metrics: %v", err)
+	}
+	if openMetrics {
+		fmt.Fprintf(w, "# EOF\n")
+	}
+}
+
+// handleRun starts a benchmark in the background with the configuration of the JSON request body.
+// It responds 202 Accepted once the run started, or 409 Conflict if a benchmark is already running.
+func (s *benchmarkServer) handleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Use POST to start a benchmark", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request runRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid run request: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	// Each request classifies its launches on its own, so that a run is not taken for a repeat of an earlier request's.
+	base := request.apply(s.base)
+	base.launchHistory = bench.NewLaunchHistory()
+	configs, err := splitTargets(base)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		http.Error(w, "A benchmark is already running", http.StatusConflict)
+		return
+	}
+	s.running, s.active = true, configs
+	s.mu.Unlock()
+
+	go s.run(configs)
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintln(w, "Benchmark started")
+}
+
+// run executes a benchmark, stores its results for /metrics and exports them like a command line run.
+func (s *benchmarkServer) run(configs []Config) {
+	results, err := runBenchmarks(s.clientset, s.ec2Svc, configs)
+	for _, result := range results {
+		recordAWSIdentity(s.stsSvc, s.ec2Svc, result)
+		if printErr := printResult(configs[0], result, len(configs) > 1); printErr != nil {
+			err = errors.Join(err, printErr)
+		}
+	}
+	writePromTextfile(configs[0], results)
+	pushMetrics(configs[0], results)
+	if err != nil {
+		log.Printf("Benchmark failed: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running, s.active = false, nil
+	s.lastResults, s.lastErr = results, err
+	s.completedRuns++
+	s.lastTraceID, s.lastFinished = configs[0].traceID, time.Now()
+}
+
+// boolToInt converts a bool to a 0/1 metric value.
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// serve runs the HTTP server mode on addr until it fails: /metrics exposes the last run's results and POST /run
+// triggers a new benchmark. The runs never read from stdin, as if --non-interactive was given, so a provisioning
+// timeout fails the run instead of blocking the server on a prompt nobody answers.
+func serve(addr string, clientset *kubernetes.Clientset, ec2Svc *ec2.EC2, stsSvc *sts.STS, base Config) error {
+	base.nonInteractive = true
+	server := &benchmarkServer{clientset: clientset, ec2Svc: ec2Svc, stsSvc: stsSvc, base: base}
+	monitorForSigint(clientset, server.activeConfigs)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", server.handleMetrics)
+	mux.HandleFunc("/run", server.handleRun)
+
+	progressf("Serving /metrics and /run on %s...\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2024 Matthew Hopkins
+// This file is part of the k8s-autoscaler-benchmarker project, under the MIT License.
+// For full license text, see the LICENSE file in the root directory or https://github.com/moebaca/k8s-autoscaler-benchmarker.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/bench"
+	"github.com/moebaca/k8s-autoscaler-benchmarker/internal/k8s"
+)
+
+// tenantNamespaces returns the namespaces the --tenants workloads are deployed into, named after the generated workload.
+func tenantNamespaces(config Config) []string {
+	namespaces := make([]string, 0, config.tenants)
+	for i := 1; i <= config.tenants; i++ {
+		namespaces = append(namespaces, fmt.Sprintf("%s-tenant-%d", config.containerName, i))
+	}
+	return namespaces
+}
+
+// createTenants creates the tenant namespaces and the generated workload in every one of them but the first, whose
+// workload executeBenchmark creates as in a single-tenant run. It returns a function deleting the namespaces, and
+// with them the workloads, again.
+func createTenants(clientset *kubernetes.Clientset, config Config) (func(), error) {
+	namespaces := tenantNamespaces(config)
+	deleteNamespaces := func() {
+		for _, namespace := range namespaces {
+			if err := k8s.DeleteNamespace(clientset, namespace); err != nil {
+				log.Printf("Failed to delete tenant namespace: %v", err)
+			}
+		}
+	}
+
+	for i, namespace := range namespaces {
+		if err := shutdown.Guard(func() error {
+			return k8s.CreateNamespace(clientset, namespace)
+		}); err != nil {
+			deleteNamespaces()
+			return nil, err
+		}
+		if i == 0 {
+			continue
+		}
+		tenantConfig := config
+		tenantConfig.deploymentName, tenantConfig.namespace = config.containerName, namespace
+		if err := shutdown.Guard(func() error {
+			return k8s.GenerateDeployment(clientset, generatedWorkloadConfig(tenantConfig))
+		}); err != nil {
+			deleteNamespaces()
+			return nil, err
+		}
+	}
+	return deleteNamespaces, nil
+}
+
+// waitForTenantsReady waits for the pods of every tenant concurrently and returns each tenant's pod readiness time,
+// in namespace order, with the errors of the tenants whose pods did not become ready joined together.
+func waitForTenantsReady(clientset *kubernetes.Clientset, config Config) ([]bench.TenantReadiness, error) {
+	namespaces := tenantNamespaces(config)
+	readiness := make([]bench.TenantReadiness, len(namespaces))
+	errs := make([]error, len(namespaces))
+
+	var wg sync.WaitGroup
+	for i, namespace := range namespaces {
+		wg.Add(1)
+		go func(i int, namespace string) {
+			defer wg.Done()
+			podReadinessTime, err := k8s.WaitForPodsReady(clientset, config.containerName, namespace, config.replicas, config.podReadinessTimeout, verbosity(config))
+			readiness[i] = bench.TenantReadiness{Namespace: namespace, PodReadinessTime: podReadinessTime}
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", namespace, err)
+			}
+		}(i, namespace)
+	}
+	wg.Wait()
+
+	return readiness, errors.Join(errs...)
+}
+
+// slowestTenant returns the longest pod readiness time among the tenants.
+func slowestTenant(readiness []bench.TenantReadiness) time.Duration {
+	var slowest time.Duration
+	for _, tenant := range readiness {
+		if tenant.PodReadinessTime > slowest {
+			slowest = tenant.PodReadinessTime
+		}
+	}
+	return slowest
+}
+
+// scaleDownTenants scales the workloads of every tenant but the first, which executeBenchmark scales down itself, to 0.
+func scaleDownTenants(clientset *kubernetes.Clientset, config Config) error {
+	for _, namespace := range tenantNamespaces(config)[1:] {
+		if err := k8s.ScaleDeployment(clientset, config.containerName, namespace, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// totalReplicas returns the number of pods the benchmark scales to across all tenants.
+func totalReplicas(config Config) int {
+	if config.tenants > 1 {
+		return config.replicas * config.tenants
+	}
+	return config.replicas
+}